@@ -0,0 +1,356 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.mau.fi/whatsmeow/types"
+
+	"whatsmeow-go/cmd/wavy/common"
+)
+
+// maxAliasDepth is how deeply aliases can refer to other aliases
+const maxAliasDepth = 10
+
+// aliasNamePattern is the form of alias names. They cannot contain spaces, but can still be the one-word
+// name of a contact or group, which the alias then hides; 'alias set' warns about that.
+var aliasNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+
+var aliasCmd = &cobra.Command{
+	Use:   "alias",
+	Short: "Manage recipient aliases",
+	Long: `Manage short names for recipients, stored in ~/.config/wavy/aliases.yaml.
+
+An alias stands for a phone number, a group ID, the name of a contact or group, another alias, or a
+list of them. Aliases can be used wherever a recipient is given, for example 'wavy send oncall "..."'.
+A list alias sends to every recipient of the list; commands that take a single chat reject it.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var aliasSetCmd = &cobra.Command{
+	Use:   "set [alias] [recipients...]",
+	Short: "Create or replace an alias",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 2 {
+			cmd.Help()
+			os.Exit(1)
+		}
+
+		runAliasSet(args[0], args[1:])
+	},
+}
+
+var aliasGetCmd = &cobra.Command{
+	Use:   "get [alias]",
+	Short: "Show the recipients of an alias",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			cmd.Help()
+			os.Exit(1)
+		}
+
+		runAliasGet(args[0])
+	},
+}
+
+var aliasListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all aliases",
+	Run: func(cmd *cobra.Command, args []string) {
+		runAliasList()
+	},
+}
+
+var aliasRemoveCmd = &cobra.Command{
+	Use:     "rm [aliases...]",
+	Aliases: []string{"remove"},
+	Short:   "Remove aliases",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			cmd.Help()
+			os.Exit(1)
+		}
+
+		runAliasRemove(args)
+	},
+}
+
+func init() {
+	aliasCmd.AddCommand(aliasSetCmd)
+	aliasCmd.AddCommand(aliasGetCmd)
+	aliasCmd.AddCommand(aliasListCmd)
+	aliasCmd.AddCommand(aliasRemoveCmd)
+}
+
+// normalizeAliasName returns the name an alias is stored under. Alias names are not case-sensitive.
+func normalizeAliasName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// validateAliasName checks that an alias name cannot be mistaken for a phone number, JID or contact name
+func validateAliasName(name string) error {
+	if !aliasNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid alias %q, use letters, digits, dots, dashes and underscores", name)
+	}
+	if isPhoneNumber(name) {
+		return fmt.Errorf("invalid alias %q, aliases cannot look like phone numbers", name)
+	}
+	return nil
+}
+
+// expandAliases replaces the aliases among the recipients with the recipients they stand for.
+// Aliases can refer to other aliases. Recipients that are not aliases are kept, and duplicates are dropped.
+func expandAliases(aliases common.Aliases, recipients []string) ([]string, error) {
+	var expanded []string
+	seen := make(map[string]bool)
+
+	var expand func(recipient string, path []string) error
+	expand = func(recipient string, path []string) error {
+		targets, ok := aliases[normalizeAliasName(recipient)]
+		if !ok {
+			if !seen[recipient] {
+				seen[recipient] = true
+				expanded = append(expanded, recipient)
+			}
+			return nil
+		}
+
+		name := normalizeAliasName(recipient)
+		next := append(path[:len(path):len(path)], name)
+		for _, previous := range path {
+			if previous == name {
+				return fmt.Errorf("alias %q refers to itself through %s", name, strings.Join(next, " -> "))
+			}
+		}
+		if len(path) >= maxAliasDepth {
+			return fmt.Errorf("alias %q is nested more than %d levels deep", path[0], maxAliasDepth)
+		}
+
+		for _, target := range splitRecipients(targets) {
+			if err := expand(target, next); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, recipient := range recipients {
+		if err := expand(recipient, nil); err != nil {
+			return nil, err
+		}
+	}
+	return expanded, nil
+}
+
+// loadAliases reads the aliases file, exiting the program if it is invalid
+func loadAliases() common.Aliases {
+	aliases, err := common.LoadAliases()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return aliases
+}
+
+// parseRecipientList splits repeated and comma-separated recipients and expands the aliases among them
+func parseRecipientList(values []string) ([]string, error) {
+	aliases, err := common.LoadAliases()
+	if err != nil {
+		return nil, err
+	}
+	return expandAliases(aliases, splitRecipients(values))
+}
+
+// resolveAlias returns the recipient an alias stands for, or the recipient itself if it is not an alias.
+// Aliases for several recipients are rejected, as the caller needs a single chat.
+func resolveAlias(recipient string) (string, error) {
+	aliases, err := common.LoadAliases()
+	if err != nil {
+		return "", err
+	}
+
+	expanded, err := expandAliases(aliases, []string{recipient})
+	if err != nil {
+		return "", invalidRecipient(err)
+	}
+	if len(expanded) != 1 {
+		return "", invalidRecipient(fmt.Errorf("alias %q stands for %d recipients, but a single chat is needed here", normalizeAliasName(recipient), len(expanded)))
+	}
+	return expanded[0], nil
+}
+
+// parseUserAlias parses a user JID or phone number, or an alias that stands for one
+func parseUserAlias(value string) (types.JID, error) {
+	user, err := resolveAlias(value)
+	if err != nil {
+		return types.EmptyJID, err
+	}
+	return parseUserJID(user)
+}
+
+// sameNameCandidates returns the contacts and groups whose name is the alias name, ignoring case
+func sameNameCandidates(candidates []recipientCandidate, name string) []recipientCandidate {
+	var same []recipientCandidate
+	seen := make(map[types.JID]bool)
+	for _, candidate := range candidates {
+		if normalizeName(candidate.Name) == name && !seen[candidate.JID] {
+			seen[candidate.JID] = true
+			same = append(same, candidate)
+		}
+	}
+	return same
+}
+
+// localCandidates returns the contacts and cached groups stored locally, without connecting to WhatsApp.
+// Anything that cannot be read is left out, as it is only used for warnings.
+func localCandidates() []recipientCandidate {
+	var candidates []recipientCandidate
+	if client, err := loadClient(false); err == nil {
+		if contacts, err := client.Store.Contacts.GetAllContacts(context.Background()); err == nil {
+			candidates = contactCandidates(contacts)
+		}
+	}
+	if db, err := loadStorage(); err == nil {
+		if groups, _, err := cachedGroups(db, time.Now()); err == nil {
+			candidates = append(candidates, groupCandidates(groups)...)
+		}
+		db.Close()
+	}
+	return candidates
+}
+
+// aliasReferences returns the aliases whose recipients include the given alias
+func aliasReferences(aliases common.Aliases, name string) []string {
+	var references []string
+	for alias, targets := range aliases {
+		for _, target := range splitRecipients(targets) {
+			if normalizeAliasName(target) == name {
+				references = append(references, alias)
+				break
+			}
+		}
+	}
+	sort.Strings(references)
+	return references
+}
+
+// printAliases prints the aliases sorted by name
+func printAliases(out io.Writer, aliases common.Aliases) {
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ALIAS\tRECIPIENTS")
+	for _, name := range names {
+		fmt.Fprintf(w, "%s\t%s\n", name, strings.Join(aliases[name], ", "))
+	}
+	w.Flush()
+}
+
+func runAliasSet(name string, values []string) {
+	name = normalizeAliasName(name)
+	if err := validateAliasName(name); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	targets := splitRecipients(values)
+	if len(targets) == 0 {
+		fmt.Fprintf(os.Stderr, "Error: alias %q needs at least one recipient\n", name)
+		os.Exit(1)
+	}
+
+	aliases := loadAliases()
+	_, replaced := aliases[name]
+	aliases[name] = targets
+
+	// Refuse aliases that would loop, before they break every recipient lookup
+	if _, err := expandAliases(aliases, []string{name}); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := common.SaveAliases(aliases); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if replaced {
+		fmt.Printf("Alias %q replaced: %s\n", name, strings.Join(targets, ", "))
+	} else {
+		fmt.Printf("Alias %q set: %s\n", name, strings.Join(targets, ", "))
+	}
+
+	// Aliases are expanded before names are looked up, so the alias wins
+	for _, hidden := range sameNameCandidates(localCandidates(), name) {
+		fmt.Fprintf(os.Stderr, "Warning: %s has the same name and can now only be reached by its phone number or ID\n", hidden)
+	}
+}
+
+func runAliasGet(name string) {
+	name = normalizeAliasName(name)
+	aliases := loadAliases()
+
+	if _, ok := aliases[name]; !ok {
+		fmt.Fprintf(os.Stderr, "Error: alias %q not found\n", name)
+		os.Exit(1)
+	}
+
+	recipients, err := expandAliases(aliases, []string{name})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	for _, recipient := range recipients {
+		fmt.Println(recipient)
+	}
+}
+
+func runAliasList() {
+	aliases := loadAliases()
+	if len(aliases) == 0 {
+		fmt.Println("No aliases")
+		return
+	}
+	printAliases(os.Stdout, aliases)
+}
+
+func runAliasRemove(names []string) {
+	aliases := loadAliases()
+
+	for _, name := range names {
+		name = normalizeAliasName(name)
+		if _, ok := aliases[name]; !ok {
+			fmt.Fprintf(os.Stderr, "Error: alias %q not found\n", name)
+			os.Exit(1)
+		}
+		delete(aliases, name)
+	}
+
+	if err := common.SaveAliases(aliases); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	for _, name := range names {
+		name = normalizeAliasName(name)
+		fmt.Printf("Alias %q removed\n", name)
+		if references := aliasReferences(aliases, name); len(references) > 0 {
+			fmt.Fprintf(os.Stderr, "Warning: %q is still used by %s and is now treated as a contact or group name\n",
+				name, strings.Join(references, ", "))
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"whatsmeow-go/cmd/wavy/common"
+)
+
+func testAliases() common.Aliases {
+	return common.Aliases{
+		"oncall":  {"+15550100"},
+		"leads":   {"+15550101", "+15550102"},
+		"team":    {"leads", "oncall", "123456789@g.us"},
+		"dev-ops": {"Alice Smith"},
+	}
+}
+
+func TestExpandAliases(t *testing.T) {
+	tests := []struct {
+		name       string
+		recipients []string
+		want       []string
+	}{
+		{name: "no aliases", recipients: []string{"+15550199", "Bob"}, want: []string{"+15550199", "Bob"}},
+		{name: "single", recipients: []string{"oncall"}, want: []string{"+15550100"}},
+		{name: "case-insensitive", recipients: []string{"OnCall"}, want: []string{"+15550100"}},
+		{name: "list", recipients: []string{"leads"}, want: []string{"+15550101", "+15550102"}},
+		{name: "nested", recipients: []string{"team"}, want: []string{"+15550101", "+15550102", "+15550100", "123456789@g.us"}},
+		{name: "duplicates dropped", recipients: []string{"oncall", "+15550100", "team"}, want: []string{"+15550100", "+15550101", "+15550102", "123456789@g.us"}},
+		{name: "to a name", recipients: []string{"dev-ops"}, want: []string{"Alice Smith"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandAliases(testAliases(), tt.recipients)
+			if err != nil {
+				t.Fatalf("expandAliases() failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expandAliases(%v) = %v, want %v", tt.recipients, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandAliasesCycle(t *testing.T) {
+	aliases := common.Aliases{
+		"a": {"b"},
+		"b": {"+15550100", "a"},
+	}
+
+	_, err := expandAliases(aliases, []string{"a"})
+	if err == nil || !strings.Contains(err.Error(), "a -> b -> a") {
+		t.Errorf("Expected a cycle error, got %v", err)
+	}
+}
+
+func TestValidateAliasName(t *testing.T) {
+	for _, name := range []string{"oncall", "team-a", "ops_2", "v1.2"} {
+		if err := validateAliasName(name); err != nil {
+			t.Errorf("validateAliasName(%q) failed: %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "on call", "a@g.us", "15550100", "-team", "a,b"} {
+		if err := validateAliasName(name); err == nil {
+			t.Errorf("validateAliasName(%q) should fail", name)
+		}
+	}
+}
+
+func TestResolveAlias(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	if err := common.SaveAliases(testAliases()); err != nil {
+		t.Fatalf("SaveAliases() failed: %v", err)
+	}
+
+	if recipient, err := resolveAlias("oncall"); err != nil || recipient != "+15550100" {
+		t.Errorf("resolveAlias(oncall) = %q, %v", recipient, err)
+	}
+	if recipient, err := resolveAlias("123456789@g.us"); err != nil || recipient != "123456789@g.us" {
+		t.Errorf("Expected a recipient that is not an alias to be kept, got %q, %v", recipient, err)
+	}
+	if _, err := resolveAlias("leads"); err == nil {
+		t.Error("Expected a list alias to be rejected where a single chat is needed")
+	}
+
+	recipients, err := parseRecipientList([]string{"oncall,leads", "+15550199"})
+	if err != nil {
+		t.Fatalf("parseRecipientList() failed: %v", err)
+	}
+	if want := []string{"+15550100", "+15550101", "+15550102", "+15550199"}; !reflect.DeepEqual(recipients, want) {
+		t.Errorf("parseRecipientList() = %v, want %v", recipients, want)
+	}
+}
+
+func TestAliasReferences(t *testing.T) {
+	if got := aliasReferences(testAliases(), "leads"); !reflect.DeepEqual(got, []string{"team"}) {
+		t.Errorf("aliasReferences(leads) = %v, want [team]", got)
+	}
+	if got := aliasReferences(testAliases(), "team"); len(got) != 0 {
+		t.Errorf("aliasReferences(team) = %v, want none", got)
+	}
+}
+
+func TestPrintAliases(t *testing.T) {
+	var out bytes.Buffer
+	printAliases(&out, testAliases())
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 5 || !strings.HasPrefix(lines[1], "dev-ops") || !strings.Contains(lines[2], "+15550101, +15550102") {
+		t.Errorf("Unexpected output:\n%s", out.String())
+	}
+}
+
+func TestSameNameCandidates(t *testing.T) {
+	same := sameNameCandidates(testCandidates(), "bobby")
+	if len(same) != 1 || same[0].JID.User != "15550102" {
+		t.Errorf("Expected the contact named Bobby, got %+v", same)
+	}
+
+	if same := sameNameCandidates(testCandidates(), "alice"); len(same) != 0 {
+		t.Errorf("Expected a partial name not to clash, got %+v", same)
+	}
+}
@@ -0,0 +1,351 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"whatsmeow-go/cmd/wavy/storage"
+)
+
+var broadcastCmd = &cobra.Command{
+	Use:   "broadcast",
+	Short: "Manage broadcast lists",
+	Long: `Manage broadcast lists stored in the data directory. A message sent to a list is sent to
+every member individually, so members receive it as a normal message and replies come back
+to you privately. Members can be phone numbers or group IDs.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var broadcastCreateCmd = &cobra.Command{
+	Use:   "create [name] [members...]",
+	Short: "Create a broadcast list",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			cmd.Help()
+			os.Exit(1)
+		}
+
+		runBroadcastCreate(args[0], args[1:])
+	},
+}
+
+var broadcastAddCmd = &cobra.Command{
+	Use:   "add [name] [members...]",
+	Short: "Add members to a broadcast list",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 2 {
+			cmd.Help()
+			os.Exit(1)
+		}
+
+		runBroadcastAdd(args[0], args[1:])
+	},
+}
+
+var broadcastRemoveCmd = &cobra.Command{
+	Use:   "remove [name] [members...]",
+	Short: "Remove members from a broadcast list",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 2 {
+			cmd.Help()
+			os.Exit(1)
+		}
+
+		runBroadcastRemove(args[0], args[1:])
+	},
+}
+
+var broadcastListCmd = &cobra.Command{
+	Use:   "list [name]",
+	Short: "List broadcast lists, or the members of one list",
+	Long: `Without a name, list all broadcast lists. With a name, list the members of that list
+with the outcome of the last message sent to them.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) > 0 {
+			runBroadcastMembers(args[0])
+			return
+		}
+		runBroadcastLists()
+	},
+}
+
+var broadcastDeleteCmd = &cobra.Command{
+	Use:   "delete [name]",
+	Short: "Delete a broadcast list",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			cmd.Help()
+			os.Exit(1)
+		}
+
+		runBroadcastDelete(args[0])
+	},
+}
+
+var broadcastSendCmd = &cobra.Command{
+	Use:   "send [name] [message]",
+	Short: "Send a message to every member of a broadcast list",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) >= 2 && msg == "" {
+			msg = args[1]
+		}
+
+		if len(args) < 1 || msg == "" {
+			cmd.Help()
+			os.Exit(1)
+		}
+
+		runBroadcastSend(args[0])
+	},
+}
+
+func init() {
+	broadcastSendCmd.Flags().StringVarP(&msg, "msg", "m", "", "Message text to send")
+	broadcastSendCmd.Flags().BoolVarP(&debug, "debug", "d", false, "Enable verbose debugging")
+	broadcastSendCmd.Flags().IntVarP(&wait, "wait", "w", 5, "Seconds to wait for message confirmation")
+	broadcastSendCmd.Flags().BoolVar(&noPreview, "no-preview", false, "Do not generate a preview for links in the message")
+	broadcastSendCmd.Flags().BoolVar(&typing, "typing", false, "Show a typing indicator before sending, as a person would")
+
+	broadcastCmd.AddCommand(broadcastCreateCmd)
+	broadcastCmd.AddCommand(broadcastAddCmd)
+	broadcastCmd.AddCommand(broadcastRemoveCmd)
+	broadcastCmd.AddCommand(broadcastListCmd)
+	broadcastCmd.AddCommand(broadcastDeleteCmd)
+	broadcastCmd.AddCommand(broadcastSendCmd)
+}
+
+// parseBroadcastMembers validates the members given on the command line and returns them in a canonical form.
+// Phone numbers are stored with a leading plus sign, so the same number is never added twice.
+// Aliases are replaced with the recipients they stand for.
+func parseBroadcastMembers(values []string) ([]string, error) {
+	values, err := parseRecipientList(values)
+	if err != nil {
+		return nil, err
+	}
+
+	var members []string
+	for _, value := range values {
+		switch {
+		case strings.Contains(value, "@g.us"):
+			jid, err := parseGroupJID(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", value, err)
+			}
+			members = append(members, jid.String())
+		case strings.Contains(value, "@"):
+			return nil, fmt.Errorf("%s: members must be phone numbers or group IDs", value)
+		default:
+			phone := normalizePhoneNumber(value)
+			if phone == "" || strings.Trim(phone, "0123456789") != "" {
+				return nil, fmt.Errorf("%s: invalid phone number", value)
+			}
+			members = append(members, "+"+phone)
+		}
+	}
+
+	// Normalizing may turn different inputs into the same member
+	return splitRecipients(members), nil
+}
+
+// getBroadcastList returns the broadcast list with the given name, exiting the program if it does not exist
+func getBroadcastList(db *storage.DB, name string) *storage.BroadcastList {
+	list, err := db.GetBroadcastList(name)
+	if errors.Is(err, storage.ErrNotFound) {
+		fmt.Fprintf(os.Stderr, "Error: broadcast list %q not found\n", name)
+		os.Exit(1)
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return list
+}
+
+func runBroadcastCreate(name string, values []string) {
+	members, err := parseBroadcastMembers(values)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	db := openStorage()
+	defer db.Close()
+
+	if _, err := db.CreateBroadcastList(name, members, time.Now()); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Broadcast list %q created with %d members\n", name, len(members))
+}
+
+func runBroadcastAdd(name string, values []string) {
+	members, err := parseBroadcastMembers(values)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	db := openStorage()
+	defer db.Close()
+
+	list := getBroadcastList(db, name)
+	added, err := db.AddBroadcastMembers(list.ID, members, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Added %d members to %q\n", added, name)
+	if skipped := int64(len(members)) - added; skipped > 0 {
+		fmt.Printf("%d were already members\n", skipped)
+	}
+}
+
+func runBroadcastRemove(name string, values []string) {
+	members, err := parseBroadcastMembers(values)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	db := openStorage()
+	defer db.Close()
+
+	list := getBroadcastList(db, name)
+	removed, err := db.RemoveBroadcastMembers(list.ID, members)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Removed %d members from %q\n", removed, name)
+}
+
+func runBroadcastLists() {
+	db := openStorage()
+	defer db.Close()
+
+	lists, err := db.ListBroadcastLists()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(lists) == 0 {
+		fmt.Println("No broadcast lists")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tMEMBERS\tCREATED")
+	for _, list := range lists {
+		fmt.Fprintf(w, "%s\t%d\t%s\n", list.Name, list.Members, list.CreatedAt.Format("2006-01-02 15:04"))
+	}
+	w.Flush()
+}
+
+func runBroadcastMembers(name string) {
+	db := openStorage()
+	defer db.Close()
+
+	list := getBroadcastList(db, name)
+	members, err := db.GetBroadcastMembers(list.ID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(members) == 0 {
+		fmt.Printf("Broadcast list %q has no members\n", name)
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "MEMBER\tLAST STATUS\tSENT\tMESSAGE ID / ERROR")
+	for _, member := range members {
+		status, sent, detail := "-", "-", "-"
+		if member.Status != "" {
+			status = member.Status
+			sent = member.SentAt.Format("2006-01-02 15:04")
+			detail = member.MessageID
+			if member.Error != "" {
+				detail = member.Error
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", member.Recipient, status, sent, detail)
+	}
+	w.Flush()
+}
+
+func runBroadcastDelete(name string) {
+	db := openStorage()
+	defer db.Close()
+
+	list := getBroadcastList(db, name)
+	if err := db.DeleteBroadcastList(list.ID); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Broadcast list %q deleted\n", name)
+}
+
+func runBroadcastSend(name string) {
+	db := openStorage()
+	defer db.Close()
+
+	list := getBroadcastList(db, name)
+	members, err := db.GetBroadcastMembers(list.ID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(members) == 0 {
+		fmt.Fprintf(os.Stderr, "Error: broadcast list %q has no members\n", name)
+		os.Exit(1)
+	}
+
+	inputs := make([]string, len(members))
+	for i, member := range members {
+		inputs[i] = member.Recipient
+	}
+
+	client := connectClient(debug)
+
+	message, upload := buildTextMessage(msg, !noPreview)
+
+	fmt.Printf("Sending to %d members of %q...\n", len(members), name)
+	results, err := sendToAll(client, resolveRecipients(client, inputs), message, upload)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Disconnect client after sending
+	client.Disconnect()
+
+	for _, result := range results {
+		status, messageID, errMsg := storage.StatusSent, result.Response.ID, ""
+		if err := result.err(); err != nil {
+			status, messageID, errMsg = storage.StatusFailed, "", err.Error()
+		}
+
+		if err := db.SetBroadcastMemberStatus(list.ID, result.Recipient.Input, status, messageID, errMsg, time.Now()); err != nil {
+			fmt.Printf("Warning: could not record status of %s: %v\n", result.Recipient.Input, err)
+		}
+	}
+
+	fmt.Println()
+	if failed := printSendResults(os.Stdout, results); failed > 0 {
+		os.Exit(1)
+	}
+}
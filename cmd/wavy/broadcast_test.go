@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+func TestParseBroadcastMembers(t *testing.T) {
+	members, err := parseBroadcastMembers([]string{"+15550100,15550101", "123456789@g.us", "15550100"})
+	if err != nil {
+		t.Fatalf("parseBroadcastMembers returned error: %v", err)
+	}
+
+	want := []string{"+15550100", "+15550101", "123456789@g.us"}
+	if len(members) != len(want) {
+		t.Fatalf("parseBroadcastMembers() = %v, want %v", members, want)
+	}
+	for i := range want {
+		if members[i] != want[i] {
+			t.Errorf("parseBroadcastMembers()[%d] = %q, want %q", i, members[i], want[i])
+		}
+	}
+
+	for _, invalid := range []string{"12ab", "1712345678@broadcast", "15550100@s.whatsapp.net", "1@2@g.us"} {
+		if _, err := parseBroadcastMembers([]string{invalid}); err == nil {
+			t.Errorf("Expected error for %q, got nil", invalid)
+		}
+	}
+}
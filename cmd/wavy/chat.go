@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var chatCmd = &cobra.Command{
+	Use:   "chat",
+	Short: "Manage chat settings",
+	Long:  `Change the settings of a chat with a contact or group.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var chatDisappearingCmd = &cobra.Command{
+	Use:   "disappearing [chat] [duration]",
+	Short: "Set the disappearing messages timer of a chat",
+	Long: `Turn disappearing messages on or off for a chat.
+The duration must be one of 24h, 7d, 90d or off.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 2 {
+			cmd.Help()
+			os.Exit(1)
+		}
+
+		runChatDisappearing(args[0], args[1])
+	},
+}
+
+func init() {
+	chatDisappearingCmd.Flags().BoolVarP(&debug, "debug", "d", false, "Enable verbose debugging")
+
+	chatCmd.AddCommand(chatDisappearingCmd)
+}
+
+func runChatDisappearing(chat, duration string) {
+	timer, err := parseDisappearingTimer(duration)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	client := connectClient(debug)
+
+	chatJID, err := parseRecipient(client, chat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Changing the timer posts a message in the chat, so it counts against the rate limit
+	if err := throttle(client, chatJID); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := client.SetDisappearingTimer(chatJID, timer); err != nil {
+		fmt.Fprintf(os.Stderr, "Error setting disappearing timer: %v\n", err)
+		os.Exit(1)
+	}
+
+	if timer == 0 {
+		fmt.Printf("Disappearing messages turned off for %s\n", chatJID.String())
+	} else {
+		fmt.Printf("Disappearing messages set to %s for %s\n", duration, chatJID.String())
+	}
+
+	client.Disconnect()
+}
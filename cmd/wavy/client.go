@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types/events"
+
+	"whatsmeow-go/cmd/wavy/common"
+)
+
+// errNoSession is returned when wavy has not been linked to a WhatsApp account yet
+var errNoSession = errors.New("no WhatsApp session found. Please run 'wavy setup' first")
+
+// newClient creates a WhatsApp client from the stored session without connecting it.
+// Use this instead of connectClient when event handlers must be registered before connecting.
+// It exits the program if there is no session.
+func newClient(debug bool) *whatsmeow.Client {
+	client, err := loadClient(debug)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return client
+}
+
+// connectClient creates a WhatsApp client from the stored session and connects it.
+// It exits the program if there is no session or the connection fails.
+func connectClient(debug bool) *whatsmeow.Client {
+	client := newClient(debug)
+	connect(client, debug)
+	return client
+}
+
+// connect connects a client created by newClient.
+// It exits the program if the connection fails.
+func connect(client *whatsmeow.Client, debug bool) {
+	if err := dial(client, debug); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// openClient creates a WhatsApp client from the stored session and connects it.
+// Unlike connectClient it returns errors, so long-running workers can retry later.
+func openClient(debug bool) (*whatsmeow.Client, error) {
+	client, err := loadClient(debug)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := dial(client, debug); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// loadClient creates a WhatsApp client from the stored session
+func loadClient(debug bool) (*whatsmeow.Client, error) {
+	client, needsSetup, err := common.CreateWAClient(debug)
+	if err != nil {
+		return nil, fmt.Errorf("error creating client: %w", err)
+	}
+
+	if needsSetup {
+		return nil, errNoSession
+	}
+
+	return client, nil
+}
+
+// dial connects the client to WhatsApp
+func dial(client *whatsmeow.Client, debug bool) error {
+	// Sending too much too fast gets the account banned for a while
+	client.AddEventHandler(func(evt interface{}) {
+		if ban, ok := evt.(*events.TemporaryBan); ok {
+			fmt.Fprintf(os.Stderr, "Warning: %s. Consider lowering the rate limits in config.yaml.\n", ban)
+		}
+	})
+
+	if err := client.Connect(); err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+
+	// Print own ID for debugging
+	if debug {
+		fmt.Printf("Connected as JID: %s\n", client.Store.ID)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Aliases maps lowercase alias names to the recipients they stand for, from aliases.yaml in the config directory
+type Aliases map[string]AliasTargets
+
+// AliasTargets are the recipients of an alias: phone numbers, group IDs, names or other aliases.
+// In the file, a single recipient is written as a string and several as a list.
+type AliasTargets []string
+
+// UnmarshalYAML accepts a single recipient or a list of recipients
+func (t *AliasTargets) UnmarshalYAML(value *yaml.Node) error {
+	switch value.Kind {
+	case yaml.ScalarNode:
+		*t = AliasTargets{value.Value}
+		return nil
+	case yaml.SequenceNode:
+		var targets []string
+		if err := value.Decode(&targets); err != nil {
+			return err
+		}
+		*t = targets
+		return nil
+	default:
+		return fmt.Errorf("line %d: an alias must be a recipient or a list of recipients", value.Line)
+	}
+}
+
+// MarshalYAML writes a single recipient as a string
+func (t AliasTargets) MarshalYAML() (interface{}, error) {
+	if len(t) == 1 {
+		return t[0], nil
+	}
+	return []string(t), nil
+}
+
+// GetAliasesFilePath returns the path to the aliases file
+func GetAliasesFilePath() (string, error) {
+	configPath, err := GetConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configPath, "aliases.yaml"), nil
+}
+
+// LoadAliases reads the aliases file. A missing file has no aliases.
+func LoadAliases() (Aliases, error) {
+	path, err := GetAliasesFilePath()
+	if err != nil {
+		return nil, err
+	}
+	return loadAliasesFile(path)
+}
+
+// SaveAliases writes the aliases file
+func SaveAliases(aliases Aliases) error {
+	if err := EnsureDirectories(); err != nil {
+		return err
+	}
+
+	path, err := GetAliasesFilePath()
+	if err != nil {
+		return err
+	}
+	return saveAliasesFile(path, aliases)
+}
+
+func loadAliasesFile(path string) (Aliases, error) {
+	aliases := make(Aliases)
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return aliases, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read aliases file: %w", err)
+	}
+
+	var decoded Aliases
+	if err := yaml.Unmarshal(data, &decoded); err != nil {
+		return nil, fmt.Errorf("invalid aliases file %s: %w", path, err)
+	}
+
+	// Alias names are not case-sensitive
+	for name, targets := range decoded {
+		aliases[strings.ToLower(name)] = targets
+	}
+	return aliases, nil
+}
+
+func saveAliasesFile(path string, aliases Aliases) error {
+	data, err := yaml.Marshal(aliases)
+	if err != nil {
+		return fmt.Errorf("failed to encode aliases: %w", err)
+	}
+
+	// Replace the file in one step, so it is never left half written
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write aliases file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write aliases file: %w", err)
+	}
+	return nil
+}
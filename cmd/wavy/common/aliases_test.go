@@ -0,0 +1,81 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadAliasesFile(t *testing.T) {
+	dir := t.TempDir()
+
+	// A missing file has no aliases
+	aliases, err := loadAliasesFile(filepath.Join(dir, "missing.yaml"))
+	if err != nil {
+		t.Fatalf("loadAliasesFile() failed: %v", err)
+	}
+	if len(aliases) != 0 {
+		t.Errorf("Expected no aliases, got %v", aliases)
+	}
+
+	path := filepath.Join(dir, "aliases.yaml")
+	data := `OnCall: "+15550100"
+team: 123456789@g.us
+leads:
+  - "+15550101"
+  - "+15550102"
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+
+	aliases, err = loadAliasesFile(path)
+	if err != nil {
+		t.Fatalf("loadAliasesFile() failed: %v", err)
+	}
+	want := Aliases{
+		"oncall": {"+15550100"},
+		"team":   {"123456789@g.us"},
+		"leads":  {"+15550101", "+15550102"},
+	}
+	if !reflect.DeepEqual(aliases, want) {
+		t.Errorf("loadAliasesFile() = %v, want %v", aliases, want)
+	}
+
+	if err := os.WriteFile(path, []byte("oncall:\n  phone: \"+15550100\"\n"), 0644); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+	if _, err := loadAliasesFile(path); err == nil {
+		t.Error("loadAliasesFile() should reject an alias that is not a recipient or a list")
+	}
+}
+
+func TestSaveAliasesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "aliases.yaml")
+	aliases := Aliases{
+		"oncall": {"+15550100"},
+		"leads":  {"+15550101", "+15550102"},
+	}
+
+	if err := saveAliasesFile(path, aliases); err != nil {
+		t.Fatalf("saveAliasesFile() failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() failed: %v", err)
+	}
+	if !strings.Contains(string(data), `oncall: "+15550100"`) {
+		t.Errorf("Expected a single recipient to be written as a string, got:\n%s", data)
+	}
+
+	loaded, err := loadAliasesFile(path)
+	if err != nil {
+		t.Fatalf("loadAliasesFile() failed: %v", err)
+	}
+	if !reflect.DeepEqual(loaded, aliases) {
+		t.Errorf("Expected the saved aliases back, got %v", loaded)
+	}
+}
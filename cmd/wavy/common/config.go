@@ -37,6 +37,16 @@ func GetDBPath() (string, error) {
 	return filepath.Join(dataPath, "client.db"), nil
 }
 
+// GetStoragePath returns the path to the wavy database file.
+// It holds wavy's own data and is kept separate from the WhatsApp session in client.db
+func GetStoragePath() (string, error) {
+	dataPath, err := GetDataPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataPath, "wavy.db"), nil
+}
+
 // expandHomeDir expands the tilde in paths to the user's home directory
 func expandHomeDir(path string) (string, error) {
 	if len(path) > 0 && path[0] == '~' {
@@ -126,3 +126,23 @@ func TestGetDBPath(t *testing.T) {
 		t.Errorf("GetDBPath() directory = %q, want %q", filepath.Dir(path), dataPath)
 	}
 }
+
+func TestGetStoragePath(t *testing.T) {
+	path, err := GetStoragePath()
+	if err != nil {
+		t.Fatalf("GetStoragePath() failed: %v", err)
+	}
+
+	if filepath.Base(path) != "wavy.db" {
+		t.Errorf("GetStoragePath() should end with 'wavy.db', got: %q", path)
+	}
+
+	dbPath, err := GetDBPath()
+	if err != nil {
+		t.Fatalf("GetDBPath() failed: %v", err)
+	}
+
+	if filepath.Dir(path) != filepath.Dir(dbPath) {
+		t.Errorf("GetStoragePath() directory = %q, want %q", filepath.Dir(path), filepath.Dir(dbPath))
+	}
+}
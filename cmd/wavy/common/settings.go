@@ -0,0 +1,62 @@
+package common
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+
+	"whatsmeow-go/cmd/wavy/ratelimit"
+)
+
+// Config holds the user settings from config.yaml in the config directory
+type Config struct {
+	RateLimit ratelimit.Config `yaml:"rate_limit"`
+}
+
+// DefaultConfig returns the settings used when config.yaml does not set them
+func DefaultConfig() *Config {
+	return &Config{RateLimit: ratelimit.DefaultConfig()}
+}
+
+// GetConfigFilePath returns the path to the config file
+func GetConfigFilePath() (string, error) {
+	configPath, err := GetConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configPath, "config.yaml"), nil
+}
+
+// LoadConfig reads the config file. Settings missing from the file, or a missing file, use the defaults.
+func LoadConfig() (*Config, error) {
+	path, err := GetConfigFilePath()
+	if err != nil {
+		return nil, err
+	}
+	return loadConfigFile(path)
+}
+
+func loadConfigFile(path string) (*Config, error) {
+	config := DefaultConfig()
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return config, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	// Decoding into the defaults keeps the values the file does not mention
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(config); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	return config, nil
+}
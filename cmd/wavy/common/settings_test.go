@@ -0,0 +1,53 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"whatsmeow-go/cmd/wavy/ratelimit"
+)
+
+func TestLoadConfigFile(t *testing.T) {
+	dir := t.TempDir()
+
+	// A missing file uses the defaults
+	config, err := loadConfigFile(filepath.Join(dir, "missing.yaml"))
+	if err != nil {
+		t.Fatalf("loadConfigFile() failed: %v", err)
+	}
+	if config.RateLimit != ratelimit.DefaultConfig() {
+		t.Errorf("Expected default rate limits, got %+v", config.RateLimit)
+	}
+
+	path := filepath.Join(dir, "config.yaml")
+	data := `rate_limit:
+  new:
+    per_hour: 10
+  jitter: 500ms
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+
+	config, err = loadConfigFile(path)
+	if err != nil {
+		t.Fatalf("loadConfigFile() failed: %v", err)
+	}
+
+	want := ratelimit.DefaultConfig()
+	want.New.PerHour = 10
+	want.Jitter = 500 * time.Millisecond
+	if config.RateLimit != want {
+		t.Errorf("loadConfigFile() = %+v, want %+v", config.RateLimit, want)
+	}
+
+	// Misspelled settings are reported instead of silently ignored
+	if err := os.WriteFile(path, []byte("rate_limit:\n  jiter: 1s\n"), 0644); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+	if _, err := loadConfigFile(path); err == nil {
+		t.Error("loadConfigFile() should reject unknown settings")
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	//nolint:staticcheck // Using deprecated package for compatibility
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"google.golang.org/protobuf/proto"
+
+	"whatsmeow-go/cmd/wavy/vcard"
+)
+
+// loadContactCards reads the cards from the given vCard files and adds a generated
+// card for the name and phone shorthand, if set
+func loadContactCards(files []string, name, phone string) ([]vcard.Card, error) {
+	var cards []vcard.Card
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read contact file: %w", err)
+		}
+
+		fileCards, err := vcard.Parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid contact file %s: %w", file, err)
+		}
+		cards = append(cards, fileCards...)
+	}
+
+	if name != "" || phone != "" {
+		if name == "" || phone == "" {
+			return nil, errors.New("--contact-name and --contact-phone must be used together")
+		}
+
+		card, err := vcard.New(name, phone)
+		if err != nil {
+			return nil, err
+		}
+		cards = append(cards, card)
+	}
+
+	return cards, nil
+}
+
+// buildContactMessage builds a contact message for a single card, or a contacts array for several cards
+func buildContactMessage(cards []vcard.Card) *waProto.Message {
+	contacts := make([]*waProto.ContactMessage, len(cards))
+	for i, card := range cards {
+		contacts[i] = &waProto.ContactMessage{
+			DisplayName: proto.String(card.FullName),
+			Vcard:       proto.String(card.Raw),
+		}
+	}
+
+	if len(contacts) == 1 {
+		return &waProto.Message{ContactMessage: contacts[0]}
+	}
+
+	return &waProto.Message{
+		ContactsArrayMessage: &waProto.ContactsArrayMessage{
+			DisplayName: proto.String(fmt.Sprintf("%d contacts", len(contacts))),
+			Contacts:    contacts,
+		},
+	}
+}
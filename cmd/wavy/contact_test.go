@@ -0,0 +1,44 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadContactCards(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "oncall.vcf")
+	data := "BEGIN:VCARD\nVERSION:3.0\nFN:Jane Doe\nTEL:+15550100\nEND:VCARD\n"
+	if err := os.WriteFile(file, []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to write vCard file: %v", err)
+	}
+
+	cards, err := loadContactCards([]string{file}, "Ops Desk", "+15550199")
+	if err != nil {
+		t.Fatalf("loadContactCards returned error: %v", err)
+	}
+	if len(cards) != 2 || cards[0].FullName != "Jane Doe" || cards[1].FullName != "Ops Desk" {
+		t.Errorf("Unexpected cards: %+v", cards)
+	}
+
+	if _, err := loadContactCards(nil, "Ops Desk", ""); err == nil {
+		t.Error("Expected error when --contact-phone is missing")
+	}
+}
+
+func TestBuildContactMessage(t *testing.T) {
+	cards, err := loadContactCards(nil, "Ops Desk", "+15550199")
+	if err != nil {
+		t.Fatalf("loadContactCards returned error: %v", err)
+	}
+
+	single := buildContactMessage(cards)
+	if single.GetContactMessage().GetDisplayName() != "Ops Desk" {
+		t.Errorf("Expected a single contact message, got %v", single)
+	}
+
+	multiple := buildContactMessage(append(cards, cards[0]))
+	if len(multiple.GetContactsArrayMessage().GetContacts()) != 2 {
+		t.Errorf("Expected a contacts array with 2 contacts, got %v", multiple)
+	}
+}
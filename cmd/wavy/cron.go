@@ -0,0 +1,464 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"text/template"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.mau.fi/whatsmeow"
+
+	"whatsmeow-go/cmd/wavy/cron"
+	"whatsmeow-go/cmd/wavy/storage"
+)
+
+var (
+	cronTemplateFile string
+	cronTimezone     string
+	cronLimit        int
+	cronGrace        time.Duration
+	cronOnce         bool
+)
+
+var cronCmd = &cobra.Command{
+	Use:   "cron",
+	Short: "Send recurring messages on a cron schedule",
+	Long: `Manage recurring messages defined by cron expressions. Jobs are stored in the data directory
+and sent by 'wavy cron run'.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var cronAddCmd = &cobra.Command{
+	Use:   "add [expression]",
+	Short: "Add a recurring message",
+	Long: `Add a recurring message. The expression has the standard five fields
+(minute hour day-of-month month day-of-week), for example "0 9 * * MON", or is one of
+@hourly, @daily, @weekly, @monthly and @yearly.
+
+The message is a Go text/template, given with --msg or read from --template. It can use:
+  {{date "Monday, Jan 2"}}   the run time in the given layout
+  {{now}}                    the run time, for use with the helpers below
+  {{format "15:04" (addDays 7 now)}}
+  {{weekday now}}  {{isoWeek now}}  {{upper "text"}}  {{lower "TEXT"}}`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 || to == "" || (msg == "" && cronTemplateFile == "") {
+			cmd.Help()
+			os.Exit(1)
+		}
+
+		runCronAdd(args[0])
+	},
+}
+
+var cronListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List recurring messages",
+	Run: func(cmd *cobra.Command, args []string) {
+		runCronList()
+	},
+}
+
+var cronRemoveCmd = &cobra.Command{
+	Use:   "rm [id]",
+	Short: "Remove a recurring message",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			cmd.Help()
+			os.Exit(1)
+		}
+
+		runCronRemove(args[0])
+	},
+}
+
+var cronHistoryCmd = &cobra.Command{
+	Use:   "history [id]",
+	Short: "Show the outcome of past runs",
+	Run: func(cmd *cobra.Command, args []string) {
+		id := ""
+		if len(args) > 0 {
+			id = args[0]
+		}
+		runCronHistory(id)
+	},
+}
+
+var cronRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Run recurring messages until stopped",
+	Long: `Check every minute for due jobs and send them. WhatsApp is only connected while jobs are due.
+Runs that are more than the grace period late, for example because the runner was not running,
+are recorded as skipped instead of being sent.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runCronRun()
+	},
+}
+
+func init() {
+	cronAddCmd.Flags().StringVarP(&to, "to", "t", "", "Recipient (phone number, group ID, name or alias)")
+	cronAddCmd.Flags().BoolVar(&exactNames, "exact", false, "Require a recipient name to match a contact or group name exactly")
+	cronAddCmd.Flags().StringVarP(&msg, "msg", "m", "", "Message template")
+	cronAddCmd.Flags().StringVar(&cronTemplateFile, "template", "", "File containing the message template")
+	cronAddCmd.Flags().StringVar(&cronTimezone, "tz", "Local", "Time zone of the expression, such as Europe/Berlin")
+	cronAddCmd.Flags().BoolVarP(&debug, "debug", "d", false, "Enable verbose debugging")
+
+	cronHistoryCmd.Flags().IntVarP(&cronLimit, "limit", "n", 20, "Number of runs to show")
+
+	cronRunCmd.Flags().DurationVar(&cronGrace, "grace", 5*time.Minute, "How late a run can be before it is skipped")
+	cronRunCmd.Flags().BoolVar(&cronOnce, "once", false, "Send the due jobs and exit")
+	cronRunCmd.Flags().BoolVarP(&debug, "debug", "d", false, "Enable verbose debugging")
+	cronRunCmd.Flags().IntVarP(&wait, "wait", "w", 5, "Seconds to wait for message confirmation")
+
+	cronCmd.AddCommand(cronAddCmd)
+	cronCmd.AddCommand(cronListCmd)
+	cronCmd.AddCommand(cronRemoveCmd)
+	cronCmd.AddCommand(cronHistoryCmd)
+	cronCmd.AddCommand(cronRunCmd)
+}
+
+// parseCronJob parses a job's expression and time zone
+func parseCronJob(spec, timezone string) (*cron.Schedule, *time.Location, error) {
+	schedule, err := cron.Parse(spec)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := schedule.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("%q: %w", spec, err)
+	}
+
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unknown time zone %q", timezone)
+	}
+	return schedule, loc, nil
+}
+
+// renderMessageTemplate renders a message template for a run at the given time
+func renderMessageTemplate(text string, at time.Time) (string, error) {
+	funcs := template.FuncMap{
+		"now":  func() time.Time { return at },
+		"date": func(layout string) string { return at.Format(layout) },
+		"format": func(layout string, t time.Time) string {
+			return t.Format(layout)
+		},
+		"addDays": func(days int, t time.Time) time.Time { return t.AddDate(0, 0, days) },
+		"weekday": func(t time.Time) string { return t.Weekday().String() },
+		"isoWeek": func(t time.Time) int {
+			_, week := t.ISOWeek()
+			return week
+		},
+		"upper": strings.ToUpper,
+		"lower": strings.ToLower,
+	}
+
+	tmpl, err := template.New("message").Funcs(funcs).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid template: %w", err)
+	}
+
+	var out strings.Builder
+	if err := tmpl.Execute(&out, nil); err != nil {
+		return "", fmt.Errorf("failed to render template: %w", err)
+	}
+
+	rendered := strings.TrimSpace(out.String())
+	if rendered == "" {
+		return "", errors.New("template rendered an empty message")
+	}
+	return rendered, nil
+}
+
+func runCronAdd(spec string) {
+	schedule, loc, err := parseCronJob(spec, cronTimezone)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Aliases and names are resolved now, so later changes to them do not redirect the message
+	recipient, err := resolveAlias(to)
+	if err == nil {
+		recipient, err = resolveNameNow(recipient)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	text := msg
+	if cronTemplateFile != "" {
+		data, err := os.ReadFile(cronTemplateFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading template: %v\n", err)
+			os.Exit(1)
+		}
+		text = string(data)
+	}
+
+	// Catch template mistakes now rather than at the first run
+	next := schedule.Next(time.Now().In(loc))
+	preview, err := renderMessageTemplate(text, next)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	db := openStorage()
+	defer db.Close()
+
+	id, err := db.AddCronJob(storage.CronJob{
+		Spec:      spec,
+		Timezone:  cronTimezone,
+		Recipient: recipient,
+		Template:  text,
+		CreatedAt: time.Now(),
+		NextRunAt: next,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Recurring message %d added. Next run: %s\n", id, next.Format(time.RFC1123))
+	fmt.Printf("Preview:\n%s\n", preview)
+	fmt.Println("\nMake sure 'wavy cron run' is running to deliver it.")
+}
+
+func runCronList() {
+	db := openStorage()
+	defer db.Close()
+
+	jobs, err := db.ListCronJobs()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(jobs) == 0 {
+		fmt.Println("No recurring messages")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSCHEDULE\tTIME ZONE\tTO\tNEXT RUN\tLAST RESULT")
+	for _, job := range jobs {
+		last := "-"
+		runs, err := db.GetCronRuns(job.ID, 1)
+		if err == nil && len(runs) > 0 {
+			last = runs[0].Status + " " + runs[0].RanAt.Format("2006-01-02 15:04")
+		}
+
+		next := job.NextRunAt
+		if loc, err := time.LoadLocation(job.Timezone); err == nil {
+			next = next.In(loc)
+		}
+		nextRun := next.Format("2006-01-02 15:04")
+		if !job.DisabledAt.IsZero() {
+			nextRun = "disabled"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", job.ID, job.Spec, job.Timezone, job.Recipient, nextRun, last)
+	}
+	w.Flush()
+}
+
+func runCronRemove(value string) {
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid ID %q\n", value)
+		os.Exit(1)
+	}
+
+	db := openStorage()
+	defer db.Close()
+
+	err = db.DeleteCronJob(id)
+	if errors.Is(err, storage.ErrNotFound) {
+		fmt.Fprintf(os.Stderr, "No recurring message with ID %d\n", id)
+		os.Exit(1)
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Recurring message %d removed\n", id)
+}
+
+func runCronHistory(value string) {
+	var id int64
+	if value != "" {
+		var err error
+		if id, err = strconv.ParseInt(value, 10, 64); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid ID %q\n", value)
+			os.Exit(1)
+		}
+	}
+
+	db := openStorage()
+	defer db.Close()
+
+	runs, err := db.GetCronRuns(id, cronLimit)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(runs) == 0 {
+		fmt.Println("No runs recorded")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "JOB\tSCHEDULED\tRAN\tSTATUS\tMESSAGE ID\tERROR")
+	for _, run := range runs {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", run.JobID, run.ScheduledAt.Format("2006-01-02 15:04"),
+			run.RanAt.Format("2006-01-02 15:04:05"), run.Status, run.MessageID, run.Error)
+	}
+	w.Flush()
+}
+
+func runCronRun() {
+	db := openStorage()
+	defer db.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if !cronOnce {
+		fmt.Println("Running recurring messages. Press Ctrl+C to stop.")
+	}
+
+	for {
+		if err := runDueCronJobs(db, time.Now()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			if cronOnce {
+				os.Exit(1)
+			}
+		}
+
+		if cronOnce {
+			return
+		}
+
+		// Wake up at the start of the next minute, the resolution of cron expressions
+		now := time.Now()
+		select {
+		case <-ctx.Done():
+			fmt.Println("\nStopping cron runner...")
+			return
+		case <-time.After(now.Truncate(time.Minute).Add(time.Minute).Sub(now)):
+		}
+	}
+}
+
+// runDueCronJobs sends all due jobs and records their outcome, connecting to WhatsApp only when needed
+func runDueCronJobs(db *storage.DB, now time.Time) error {
+	due, err := db.DueCronJobs(now)
+	if err != nil || len(due) == 0 {
+		return err
+	}
+
+	var client *whatsmeow.Client
+	defer func() {
+		if client != nil {
+			client.Disconnect()
+		}
+	}()
+
+	for _, job := range due {
+		schedule, loc, err := parseCronJob(job.Spec, job.Timezone)
+		if err != nil {
+			// It would never run again, for example after its time zone was removed from the system
+			if err := disableCronJob(db, job, err); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Connect before claiming a run that will be sent. If WhatsApp is unreachable the run stays due
+		// and is retried on the next check, until it is more than --grace late.
+		missed := now.Sub(job.NextRunAt) > cronGrace
+		if !missed && client == nil {
+			client, err = openClient(debug)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Claim the run by moving the job to its next run. Missed runs in between are not repeated.
+		err = db.AdvanceCronJob(job.ID, job.NextRunAt, schedule.Next(now.In(loc)))
+		if errors.Is(err, storage.ErrNotFound) {
+			// Another runner claimed it, or the job was removed
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		run := storage.CronRun{JobID: job.ID, ScheduledAt: job.NextRunAt}
+		switch {
+		case missed:
+			run.Status = storage.StatusSkipped
+			run.Error = "missed run"
+			fmt.Printf("Skipping recurring message %d, it was due at %s\n", job.ID, job.NextRunAt.Format(time.RFC1123))
+		default:
+			run.MessageID, err = sendCronJob(client, job, job.NextRunAt.In(loc))
+			if err != nil {
+				run.Status = storage.StatusFailed
+				run.Error = err.Error()
+				fmt.Fprintf(os.Stderr, "Failed to send recurring message %d: %v\n", job.ID, err)
+			} else {
+				run.Status = storage.StatusSent
+				fmt.Printf("Sent recurring message %d to %s (ID: %s)\n", job.ID, job.Recipient, run.MessageID)
+			}
+		}
+
+		run.RanAt = time.Now()
+		if err := db.AddCronRun(run); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// disableCronJob disables a job that can no longer run and records the reason as a failed run
+func disableCronJob(db *storage.DB, job storage.CronJob, reason error) error {
+	err := db.DisableCronJob(job.ID, job.NextRunAt)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Disabling recurring message %d: %v\n", job.ID, reason)
+	return db.AddCronRun(storage.CronRun{
+		JobID:       job.ID,
+		ScheduledAt: job.NextRunAt,
+		RanAt:       time.Now(),
+		Status:      storage.StatusFailed,
+		Error:       "disabled: " + reason.Error(),
+	})
+}
+
+// sendCronJob renders and sends a single run of a job
+func sendCronJob(client *whatsmeow.Client, job storage.CronJob, at time.Time) (string, error) {
+	text, err := renderMessageTemplate(job.Template, at)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := deliverText(client, job.Recipient, text)
+	if err != nil {
+		return "", err
+	}
+	return resp.ID, nil
+}
@@ -0,0 +1,222 @@
+// Package cron parses standard five-field cron expressions and computes their next run times.
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+
+	// A day matches if it matches the day of month or the day of week, unless one of them is '*'
+	domStar, dowStar bool
+}
+
+// field describes the valid range and names of a cron field
+type field struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minuteField = field{name: "minute", min: 0, max: 59}
+	hourField   = field{name: "hour", min: 0, max: 23}
+	domField    = field{name: "day of month", min: 1, max: 31}
+	monthField  = field{name: "month", min: 1, max: 12, names: map[string]int{
+		"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+		"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
+	}}
+	// Both 0 and 7 are Sunday
+	dowField = field{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6,
+	}}
+)
+
+// macros are the supported shorthand expressions
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse parses a cron expression with the fields minute, hour, day of month, month and day of week.
+// Fields support '*', lists (1,15), ranges (1-5), steps (*/15, 0-30/10) and month and weekday names.
+// The macros @yearly, @monthly, @weekly, @daily and @hourly are also accepted.
+func Parse(spec string) (*Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if expanded, ok := macros[strings.ToLower(spec)]; ok {
+		spec = expanded
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression %q: expected 5 fields, got %d", spec, len(fields))
+	}
+
+	s := &Schedule{}
+	var err error
+	if s.minute, err = parseField(fields[0], minuteField); err != nil {
+		return nil, err
+	}
+	if s.hour, err = parseField(fields[1], hourField); err != nil {
+		return nil, err
+	}
+	if s.dom, err = parseField(fields[2], domField); err != nil {
+		return nil, err
+	}
+	if s.month, err = parseField(fields[3], monthField); err != nil {
+		return nil, err
+	}
+	if s.dow, err = parseField(fields[4], dowField); err != nil {
+		return nil, err
+	}
+
+	// Fold Sunday as 7 into 0
+	if s.dow&(1<<7) != 0 {
+		s.dow = s.dow&^(1<<7) | 1
+	}
+
+	s.domStar = fields[2] == "*" || fields[2] == "?"
+	s.dowStar = fields[4] == "*" || fields[4] == "?"
+	return s, nil
+}
+
+// parseField parses a single field into a bit set of the allowed values
+func parseField(value string, f field) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(value, ",") {
+		if part == "" {
+			return 0, fmt.Errorf("invalid %s %q: empty list item", f.name, value)
+		}
+
+		rangePart, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			rangePart = part[:i]
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid %s step in %q", f.name, part)
+			}
+			step = n
+		}
+
+		var start, end int
+		switch {
+		case rangePart == "*" || rangePart == "?":
+			start, end = f.min, f.max
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if start, err = parseValue(bounds[0], f); err != nil {
+				return 0, err
+			}
+			if end, err = parseValue(bounds[1], f); err != nil {
+				return 0, err
+			}
+			if start > end {
+				return 0, fmt.Errorf("invalid %s range %q", f.name, rangePart)
+			}
+		default:
+			var err error
+			if start, err = parseValue(rangePart, f); err != nil {
+				return 0, err
+			}
+			end = start
+			// A step on a single value runs until the end of the range, as in 5/15
+			if step > 1 {
+				end = f.max
+			}
+		}
+
+		for v := start; v <= end; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// parseValue parses a number or name and checks it against the field's range
+func parseValue(value string, f field) (int, error) {
+	if n, ok := f.names[strings.ToUpper(value)]; ok {
+		return n, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q", f.name, value)
+	}
+	if n < f.min || n > f.max {
+		return 0, fmt.Errorf("%s %d out of range (%d-%d)", f.name, n, f.min, f.max)
+	}
+	return n, nil
+}
+
+// errNoMatch is returned by Next for expressions that never match, such as 30 February
+var errNoMatch = errors.New("cron expression never matches")
+
+// Next returns the first time after t that matches the schedule, evaluated in t's location.
+// It returns the zero time if the expression can never match.
+func (s *Schedule) Next(t time.Time) time.Time {
+	next, err := s.next(t)
+	if err != nil {
+		return time.Time{}
+	}
+	return next
+}
+
+func (s *Schedule) next(t time.Time) (time.Time, error) {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+
+	// Give up after five years, which covers every valid leap-day expression
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			next := time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			// Around daylight saving changes the next wall-clock hour may not be later
+			if !next.After(t) {
+				next = t.Add(time.Hour).Truncate(time.Minute)
+			}
+			t = next
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t, nil
+	}
+	return time.Time{}, errNoMatch
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// Validate reports an error if the schedule can never run
+func (s *Schedule) Validate() error {
+	_, err := s.next(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC))
+	return err
+}
@@ -0,0 +1,112 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseErrors(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"* * * * FOO",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"1,,2 * * * *",
+	} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Parse(%q) should have failed", spec)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	// Wednesday
+	from := time.Date(2025, 6, 11, 10, 17, 30, 0, time.UTC)
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2025, 6, 11, 10, 18, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2025, 6, 11, 10, 30, 0, 0, time.UTC)},
+		{"0 9 * * MON", time.Date(2025, 6, 16, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * 1-5", time.Date(2025, 6, 12, 9, 0, 0, 0, time.UTC)},
+		{"30 8 1 * *", time.Date(2025, 7, 1, 8, 30, 0, 0, time.UTC)},
+		{"0 0 1 JAN *", time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 12 * * 7", time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2025, 6, 12, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2025, 6, 11, 11, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"5/20 10 * * *", time.Date(2025, 6, 11, 10, 25, 0, 0, time.UTC)},
+		// Day of month and day of week are combined with OR when both are restricted
+		{"0 0 13 * FRI", time.Date(2025, 6, 13, 0, 0, 0, 0, time.UTC)},
+		{"0 0 20 * MON", time.Date(2025, 6, 16, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		s, err := Parse(tt.spec)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", tt.spec, err)
+			continue
+		}
+		if got := s.Next(from); !got.Equal(tt.want) {
+			t.Errorf("Next(%q) = %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestNextTimeZone(t *testing.T) {
+	loc, err := time.LoadLocation("America/Sao_Paulo")
+	if err != nil {
+		t.Skipf("time zone database not available: %v", err)
+	}
+
+	s, err := Parse("0 9 * * *")
+	if err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+
+	// 09:00 in São Paulo is 12:00 UTC
+	from := time.Date(2025, 6, 11, 10, 0, 0, 0, time.UTC).In(loc)
+	want := time.Date(2025, 6, 11, 12, 0, 0, 0, time.UTC)
+	if got := s.Next(from); !got.Equal(want) {
+		t.Errorf("Next() = %v, want %v", got, want)
+	}
+}
+
+func TestNextDaylightSaving(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone database not available: %v", err)
+	}
+
+	// 02:30 does not exist on 30 March 2025 in Berlin, so the next run is on the following day
+	s, err := Parse("30 2 * * *")
+	if err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+	from := time.Date(2025, 3, 30, 0, 0, 0, 0, loc)
+	want := time.Date(2025, 3, 31, 2, 30, 0, 0, loc)
+	if got := s.Next(from); !got.Equal(want) {
+		t.Errorf("Next() = %v, want %v", got, want)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	s, err := Parse("0 0 30 2 *")
+	if err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+	if err := s.Validate(); err == nil {
+		t.Error("Validate() should fail for 30 February")
+	}
+	if !s.Next(time.Now()).IsZero() {
+		t.Error("Next() should return the zero time for 30 February")
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRenderMessageTemplate(t *testing.T) {
+	// Monday
+	at := time.Date(2025, 6, 16, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{"Stand-up time!", "Stand-up time!"},
+		{`Stand-up for {{date "Monday, Jan 2"}}`, "Stand-up for Monday, Jun 16"},
+		{`Week {{isoWeek now}}, sprint ends {{format "Jan 2" (addDays 4 now)}}`, "Week 25, sprint ends Jun 20"},
+		{`{{upper (weekday now)}}`, "MONDAY"},
+		{"\n  Trailing whitespace is trimmed  \n", "Trailing whitespace is trimmed"},
+	}
+
+	for _, tt := range tests {
+		got, err := renderMessageTemplate(tt.template, at)
+		if err != nil {
+			t.Errorf("renderMessageTemplate(%q) returned error: %v", tt.template, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("renderMessageTemplate(%q) = %q, want %q", tt.template, got, tt.want)
+		}
+	}
+
+	for _, tmpl := range []string{"{{date", "{{unknown}}", "  ", `{{if false}}x{{end}}`} {
+		if _, err := renderMessageTemplate(tmpl, at); err == nil {
+			t.Errorf("renderMessageTemplate(%q) should have failed", tmpl)
+		}
+	}
+}
+
+func TestParseCronJob(t *testing.T) {
+	if _, _, err := parseCronJob("0 9 * * MON", "UTC"); err != nil {
+		t.Errorf("parseCronJob() returned error: %v", err)
+	}
+
+	if _, _, err := parseCronJob("0 9 * *", "UTC"); err == nil {
+		t.Error("parseCronJob() should reject an invalid expression")
+	}
+
+	if _, _, err := parseCronJob("0 0 31 2 *", "UTC"); err == nil {
+		t.Error("parseCronJob() should reject an expression that never runs")
+	}
+
+	if _, _, err := parseCronJob("0 9 * * *", "Not/AZone"); err == nil {
+		t.Error("parseCronJob() should reject an unknown time zone")
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"whatsmeow-go/cmd/wavy/common"
+	"whatsmeow-go/cmd/wavy/storage"
+)
+
+// openStorage opens the wavy database in the data directory.
+// It exits the program if the database cannot be opened.
+func openStorage() *storage.DB {
+	db, err := loadStorage()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return db
+}
+
+// loadStorage opens the wavy database in the data directory.
+// Unlike openStorage it returns errors, for code that also runs in long-running workers.
+func loadStorage() (*storage.DB, error) {
+	if err := common.EnsureDirectories(); err != nil {
+		return nil, fmt.Errorf("failed to create directories: %w", err)
+	}
+
+	path, err := common.GetStoragePath()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database path: %w", err)
+	}
+
+	db, err := storage.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open wavy database: %w", err)
+	}
+	return db, nil
+}
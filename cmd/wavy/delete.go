@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.mau.fi/whatsmeow/appstate"
+	"go.mau.fi/whatsmeow/proto/waSyncAction"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	deleteForEveryone bool
+	deleteSender      string
+	deleteSentAt      string
+)
+
+var deleteCmd = &cobra.Command{
+	Use:   "delete [chat] [messageID]",
+	Short: "Delete a WhatsApp message",
+	Long: `Delete a message. The message ID is printed by 'wavy send'.
+By default the message is only deleted for you. Use --for-everyone to revoke it for all chat members.
+
+Deleting for you also needs the time the message was sent, which wavy does not store. Give it with
+--sent-at; without it, the current time is used, and your other devices may not find the message.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 2 {
+			cmd.Help()
+			os.Exit(1)
+		}
+
+		runDelete(args[0], args[1])
+	},
+}
+
+func init() {
+	deleteCmd.Flags().BoolVar(&deleteForEveryone, "for-everyone", false, "Revoke the message for everyone in the chat")
+	deleteCmd.Flags().StringVarP(&deleteSender, "sender", "s", "", "Author of the message (phone number, JID or alias), defaults to you")
+	deleteCmd.Flags().StringVar(&deleteSentAt, "sent-at", "", "When the message was sent, as an RFC 3339 timestamp or Unix seconds, defaults to now")
+	deleteCmd.Flags().BoolVarP(&debug, "debug", "d", false, "Enable verbose debugging")
+	deleteCmd.Flags().IntVarP(&wait, "wait", "w", 5, "Seconds to wait for message confirmation")
+}
+
+// parseSentAt parses the time a message was sent, given as an RFC 3339 timestamp or Unix seconds
+func parseSentAt(value string) (time.Time, error) {
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+	sentAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --sent-at %q, use an RFC 3339 timestamp or Unix seconds", value)
+	}
+	return sentAt, nil
+}
+
+func runDelete(chat, messageID string) {
+	if deleteSentAt != "" && deleteForEveryone {
+		fmt.Fprintf(os.Stderr, "Error: --sent-at cannot be used with --for-everyone\n")
+		os.Exit(1)
+	}
+
+	sentAt := time.Now()
+	if deleteSentAt != "" {
+		var err error
+		sentAt, err = parseSentAt(deleteSentAt)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	client := connectClient(debug)
+
+	chatJID, err := parseRecipient(client, chat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// An empty sender means the message was sent by us
+	sender := types.EmptyJID
+	if deleteSender != "" {
+		sender, err = parseUserAlias(deleteSender)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid sender: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if deleteForEveryone {
+		// Revoking someone else's message only works for group admins
+		resp, err := sendMessage(client, chatJID, client.BuildRevoke(chatJID, sender, messageID))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error revoking message: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Message %s deleted for everyone (ID: %s)\n", messageID, resp.ID)
+	} else {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(wait)*time.Second)
+		defer cancel()
+
+		fromMe := sender.IsEmpty() || sender.User == client.Store.ID.User
+		err = client.SendAppState(ctx, buildDeleteForMe(chatJID, sender, messageID, fromMe, sentAt))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error deleting message: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Message %s deleted for you\n", messageID)
+	}
+
+	client.Disconnect()
+}
+
+// buildDeleteForMe builds an app state patch that deletes a message only on your own devices.
+// whatsmeow has no builder for this mutation, so it mirrors the layout of appstate.BuildStar.
+func buildDeleteForMe(chat, sender types.JID, messageID types.MessageID, fromMe bool, timestamp time.Time) appstate.PatchInfo {
+	isFromMe := "0"
+	if fromMe {
+		isFromMe = "1"
+	}
+	participant := "0"
+	if !fromMe && chat.Server == types.GroupServer {
+		participant = sender.ToNonAD().String()
+	}
+
+	return appstate.PatchInfo{
+		Type: appstate.WAPatchRegularHigh,
+		Mutations: []appstate.MutationInfo{{
+			Index:   []string{appstate.IndexDeleteMessageForMe, chat.String(), messageID, isFromMe, participant},
+			Version: 3,
+			Value: &waSyncAction.SyncActionValue{
+				DeleteMessageForMeAction: &waSyncAction.DeleteMessageForMeAction{
+					DeleteMedia:      proto.Bool(true),
+					MessageTimestamp: proto.Int64(timestamp.Unix()),
+				},
+			},
+		}},
+	}
+}
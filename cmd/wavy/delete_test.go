@@ -0,0 +1,86 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow/appstate"
+	"go.mau.fi/whatsmeow/types"
+)
+
+func TestBuildDeleteForMe(t *testing.T) {
+	group := types.NewJID("123456789", types.GroupServer)
+	sender := types.NewJID("1234567890", types.DefaultUserServer)
+	now := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name            string
+		chat            types.JID
+		fromMe          bool
+		wantFromMe      string
+		wantParticipant string
+	}{
+		{
+			name:            "Own message",
+			chat:            sender,
+			fromMe:          true,
+			wantFromMe:      "1",
+			wantParticipant: "0",
+		},
+		{
+			name:            "Other participant in group",
+			chat:            group,
+			fromMe:          false,
+			wantFromMe:      "0",
+			wantParticipant: sender.String(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch := buildDeleteForMe(tt.chat, sender, "MSGID", tt.fromMe, now)
+
+			if patch.Type != appstate.WAPatchRegularHigh {
+				t.Errorf("Expected patch type %q, got %q", appstate.WAPatchRegularHigh, patch.Type)
+			}
+			if len(patch.Mutations) != 1 {
+				t.Fatalf("Expected 1 mutation, got %d", len(patch.Mutations))
+			}
+
+			index := patch.Mutations[0].Index
+			if index[0] != appstate.IndexDeleteMessageForMe || index[1] != tt.chat.String() || index[2] != "MSGID" {
+				t.Errorf("Unexpected mutation index: %v", index)
+			}
+			if index[3] != tt.wantFromMe {
+				t.Errorf("Expected fromMe %q, got %q", tt.wantFromMe, index[3])
+			}
+			if index[4] != tt.wantParticipant {
+				t.Errorf("Expected participant %q, got %q", tt.wantParticipant, index[4])
+			}
+
+			action := patch.Mutations[0].Value.GetDeleteMessageForMeAction()
+			if action.GetMessageTimestamp() != now.Unix() {
+				t.Errorf("Expected timestamp %d, got %d", now.Unix(), action.GetMessageTimestamp())
+			}
+		})
+	}
+}
+
+func TestParseSentAt(t *testing.T) {
+	want := time.Unix(1700000000, 0)
+
+	for _, value := range []string{"1700000000", want.UTC().Format(time.RFC3339)} {
+		got, err := parseSentAt(value)
+		if err != nil {
+			t.Errorf("parseSentAt(%q) returned error: %v", value, err)
+			continue
+		}
+		if !got.Equal(want) {
+			t.Errorf("parseSentAt(%q) = %s, want %s", value, got, want)
+		}
+	}
+
+	if _, err := parseSentAt("yesterday"); err == nil {
+		t.Error("Expected error for an unsupported time")
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	//nolint:staticcheck // Using deprecated package for compatibility
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// deliverText resolves the recipient and sends a text message with the default send options.
+// It is used by background workers, so it returns errors instead of exiting the program.
+func deliverText(client *whatsmeow.Client, to, text string) (whatsmeow.SendResponse, error) {
+	recipient, err := parseRecipient(client, to)
+	if err != nil {
+		return whatsmeow.SendResponse{}, err
+	}
+
+	message, upload := buildTextMessage(text, true)
+	return deliverMessage(client, recipient, message, upload)
+}
+
+// deliverMessage uploads the media of a message, if any, and sends it
+func deliverMessage(client *whatsmeow.Client, recipient types.JID, message *waProto.Message, upload *mediaUpload) (whatsmeow.SendResponse, error) {
+	if upload != nil {
+		if err := uploadMedia(client, message, upload); err != nil {
+			return whatsmeow.SendResponse{}, err
+		}
+	}
+
+	resp, err := sendMessage(client, recipient, message)
+	if err != nil {
+		return resp, fmt.Errorf("error sending message to %s: %w", recipient.String(), err)
+	}
+	return resp, nil
+}
+
+// sendMessage sends a message, waiting for the rate limiter first unless --ignore-rate-limit is set.
+// All commands that send messages go through it. The --wait timeout only applies to the send itself.
+func sendMessage(client *whatsmeow.Client, recipient types.JID, message *waProto.Message) (whatsmeow.SendResponse, error) {
+	if err := throttle(client, recipient); err != nil {
+		return whatsmeow.SendResponse{}, err
+	}
+	return sendThrottled(client, recipient, message)
+}
+
+// sendThrottled sends a message that already passed the rate limiter
+func sendThrottled(client *whatsmeow.Client, recipient types.JID, message *waProto.Message) (whatsmeow.SendResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(wait)*time.Second)
+	defer cancel()
+
+	resp, err := client.SendMessage(ctx, recipient, message)
+	if err != nil {
+		return resp, err
+	}
+
+	markContacted(recipient)
+	return resp, nil
+}
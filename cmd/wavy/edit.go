@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"go.mau.fi/whatsmeow"
+	//nolint:staticcheck // Using deprecated package for compatibility
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+)
+
+var editCmd = &cobra.Command{
+	Use:   "edit [chat] [messageID] [text]",
+	Short: "Edit a sent WhatsApp message",
+	Long: `Replace the text of a message you sent. The message ID is printed by 'wavy send'.
+WhatsApp only allows edits within 20 minutes of sending.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 3 {
+			cmd.Help()
+			os.Exit(1)
+		}
+
+		runEdit(args[0], args[1], args[2])
+	},
+}
+
+func init() {
+	editCmd.Flags().BoolVarP(&debug, "debug", "d", false, "Enable verbose debugging")
+	editCmd.Flags().IntVarP(&wait, "wait", "w", 5, "Seconds to wait for message confirmation")
+}
+
+func runEdit(chat, messageID, text string) {
+	client := connectClient(debug)
+
+	chatJID, err := parseRecipient(client, chat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	message := client.BuildEdit(chatJID, messageID, &waProto.Message{
+		Conversation: &text,
+	})
+	resp, err := sendMessage(client, chatJID, message)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error editing message: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Note: messages can only be edited within %s of sending.\n", whatsmeow.EditWindow)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Message %s edited successfully (ID: %s)\n", messageID, resp.ID)
+
+	client.Disconnect()
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	//nolint:staticcheck // Using deprecated package for compatibility
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// parseDisappearingTimer parses the durations supported by WhatsApp: 24h, 7d, 90d or off
+func parseDisappearingTimer(value string) (time.Duration, error) {
+	timer, ok := whatsmeow.ParseDisappearingTimerString(value)
+	if !ok {
+		return 0, fmt.Errorf("invalid duration %q. Should be 24h, 7d, 90d or off", value)
+	}
+	return timer, nil
+}
+
+// applyEphemeral makes a message disappear after the given duration
+func applyEphemeral(message *waProto.Message, expiration time.Duration) error {
+	info, err := contextInfo(message)
+	if err != nil {
+		return err
+	}
+
+	info.Expiration = proto.Uint32(uint32(expiration.Seconds()))
+	info.EphemeralSettingTimestamp = proto.Int64(time.Now().Unix())
+	return nil
+}
+
+// applyViewOnce wraps a media message so it can only be viewed once
+func applyViewOnce(message *waProto.Message) (*waProto.Message, error) {
+	switch {
+	case message.ImageMessage != nil:
+		message.ImageMessage.ViewOnce = proto.Bool(true)
+	case message.VideoMessage != nil:
+		message.VideoMessage.ViewOnce = proto.Bool(true)
+	case message.AudioMessage != nil:
+		// Voice notes use their own wrapper
+		message.AudioMessage.ViewOnce = proto.Bool(true)
+		return &waProto.Message{
+			ViewOnceMessageV2Extension: &waProto.FutureProofMessage{Message: message},
+		}, nil
+	default:
+		return nil, errors.New("view once is only supported for images, videos and voice notes")
+	}
+
+	return &waProto.Message{
+		ViewOnceMessageV2: &waProto.FutureProofMessage{Message: message},
+	}, nil
+}
+
+// contextInfo returns the context info of a message, creating it if needed.
+// Plain text messages are turned into extended text messages, since they have no context info.
+func contextInfo(message *waProto.Message) (*waProto.ContextInfo, error) {
+	if message.Conversation != nil {
+		message.ExtendedTextMessage = &waProto.ExtendedTextMessage{Text: message.Conversation}
+		message.Conversation = nil
+	}
+
+	var info **waProto.ContextInfo
+	switch {
+	case message.ExtendedTextMessage != nil:
+		info = &message.ExtendedTextMessage.ContextInfo
+	case message.ImageMessage != nil:
+		info = &message.ImageMessage.ContextInfo
+	case message.VideoMessage != nil:
+		info = &message.VideoMessage.ContextInfo
+	case message.AudioMessage != nil:
+		info = &message.AudioMessage.ContextInfo
+	case message.DocumentMessage != nil:
+		info = &message.DocumentMessage.ContextInfo
+	case message.StickerMessage != nil:
+		info = &message.StickerMessage.ContextInfo
+	case message.LocationMessage != nil:
+		info = &message.LocationMessage.ContextInfo
+	case message.LiveLocationMessage != nil:
+		info = &message.LiveLocationMessage.ContextInfo
+	case message.ContactMessage != nil:
+		info = &message.ContactMessage.ContextInfo
+	case message.ContactsArrayMessage != nil:
+		info = &message.ContactsArrayMessage.ContextInfo
+	default:
+		return nil, errors.New("message type does not support context info")
+	}
+
+	if *info == nil {
+		*info = &waProto.ContextInfo{}
+	}
+	return *info, nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	//nolint:staticcheck // Using deprecated package for compatibility
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestParseDisappearingTimer(t *testing.T) {
+	tests := map[string]time.Duration{
+		"24h": 24 * time.Hour,
+		"7d":  7 * 24 * time.Hour,
+		"90d": 90 * 24 * time.Hour,
+		"off": 0,
+	}
+	for value, want := range tests {
+		got, err := parseDisappearingTimer(value)
+		if err != nil || got != want {
+			t.Errorf("parseDisappearingTimer(%q) = %v, %v, want %v", value, got, err, want)
+		}
+	}
+
+	if _, err := parseDisappearingTimer("5m"); err == nil {
+		t.Error("Expected error for unsupported duration")
+	}
+}
+
+func TestApplyEphemeral(t *testing.T) {
+	message := &waProto.Message{Conversation: proto.String("One-time code: 123456")}
+	if err := applyEphemeral(message, 24*time.Hour); err != nil {
+		t.Fatalf("applyEphemeral returned error: %v", err)
+	}
+
+	if message.Conversation != nil {
+		t.Error("Expected plain text to be converted to extended text")
+	}
+	extended := message.GetExtendedTextMessage()
+	if extended.GetText() != "One-time code: 123456" {
+		t.Errorf("Expected text to be preserved, got %q", extended.GetText())
+	}
+	if extended.GetContextInfo().GetExpiration() != 86400 {
+		t.Errorf("Expected expiration 86400, got %d", extended.GetContextInfo().GetExpiration())
+	}
+
+	poll := &waProto.Message{PollCreationMessage: &waProto.PollCreationMessage{}}
+	if err := applyEphemeral(poll, 24*time.Hour); err == nil {
+		t.Error("Expected error for unsupported message type")
+	}
+}
+
+func TestApplyViewOnce(t *testing.T) {
+	voiceNote := &waProto.Message{AudioMessage: &waProto.AudioMessage{PTT: proto.Bool(true)}}
+	wrapped, err := applyViewOnce(voiceNote)
+	if err != nil {
+		t.Fatalf("applyViewOnce returned error: %v", err)
+	}
+	if !wrapped.GetViewOnceMessageV2Extension().GetMessage().GetAudioMessage().GetViewOnce() {
+		t.Errorf("Expected view once voice note, got %v", wrapped)
+	}
+
+	image := &waProto.Message{ImageMessage: &waProto.ImageMessage{}}
+	wrapped, err = applyViewOnce(image)
+	if err != nil {
+		t.Fatalf("applyViewOnce returned error: %v", err)
+	}
+	if !wrapped.GetViewOnceMessageV2().GetMessage().GetImageMessage().GetViewOnce() {
+		t.Errorf("Expected view once image, got %v", wrapped)
+	}
+
+	text := &waProto.Message{Conversation: proto.String("Hello")}
+	if _, err := applyViewOnce(text); err == nil {
+		t.Error("Expected error for text message")
+	}
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// Roles of group participants
+const (
+	roleSuperAdmin = "superadmin"
+	roleAdmin      = "admin"
+	roleMember     = "member"
+)
+
+var groupInfoCSV string
+
+var groupsInfoCmd = &cobra.Command{
+	Use:   "info [group-id|name]",
+	Short: "Show the details and participants of a group",
+	Long: `Show the description, owner, creation time and settings of a group with a table of its
+participants. The group is given by its ID, its exact name or an alias.
+
+With --csv, the participants are also written to a CSV file, or to standard output if the file is "-".`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			cmd.Help()
+			os.Exit(1)
+		}
+
+		runGroupsInfo(args[0])
+	},
+}
+
+func init() {
+	groupsInfoCmd.Flags().StringVar(&groupInfoCSV, "csv", "", "Write the participants to this CSV file, or to standard output if \"-\"")
+	groupsInfoCmd.Flags().BoolVarP(&debug, "debug", "d", false, "Enable verbose debugging")
+	groupsCmd.AddCommand(groupsInfoCmd)
+}
+
+// groupMember is a participant of a group with the names known for it
+type groupMember struct {
+	Phone    string
+	JID      string
+	Name     string
+	PushName string
+	Role     string
+}
+
+// participantRole returns the role of a participant
+func participantRole(participant types.GroupParticipant) string {
+	switch {
+	case participant.IsSuperAdmin:
+		return roleSuperAdmin
+	case participant.IsAdmin:
+		return roleAdmin
+	default:
+		return roleMember
+	}
+}
+
+// groupMembersOf returns the participants of a group with their names from the contact store.
+// Admins are listed first.
+func groupMembersOf(info *types.GroupInfo, contact func(jid types.JID) (types.ContactInfo, bool)) []groupMember {
+	members := make([]groupMember, 0, len(info.Participants))
+	for _, participant := range info.Participants {
+		member := groupMember{JID: participant.JID.String(), Role: participantRole(participant)}
+		// Groups that address participants by LID may not share their phone numbers
+		if !participant.PhoneNumber.IsEmpty() || participant.JID.Server == types.DefaultUserServer {
+			member.Phone = "+" + participantPhone(participant)
+		}
+
+		// Contacts may be stored under the phone number or the LID
+		for _, jid := range []types.JID{participant.PhoneNumber, participant.JID} {
+			if jid.IsEmpty() {
+				continue
+			}
+			if found, ok := contact(jid); ok {
+				member.Name = found.FullName
+				if member.Name == "" {
+					member.Name = found.FirstName
+				}
+				member.PushName = found.PushName
+				break
+			}
+		}
+		members = append(members, member)
+	}
+
+	rank := map[string]int{roleSuperAdmin: 0, roleAdmin: 1, roleMember: 2}
+	sort.SliceStable(members, func(i, j int) bool { return rank[members[i].Role] < rank[members[j].Role] })
+	return members
+}
+
+// formatDisappearingTimer formats a disappearing messages timer the way it is given to --ephemeral
+func formatDisappearingTimer(seconds uint32) string {
+	switch timer := time.Duration(seconds) * time.Second; timer {
+	case 24 * time.Hour:
+		return "24h"
+	case 7 * 24 * time.Hour:
+		return "7d"
+	case 90 * 24 * time.Hour:
+		return "90d"
+	default:
+		return timer.String()
+	}
+}
+
+// printGroupInfo prints the details of a group and its participant table
+func printGroupInfo(out io.Writer, info *types.GroupInfo, members []groupMember) {
+	fmt.Fprintf(out, "Group Name: %s\n", info.Name)
+	fmt.Fprintf(out, "Group ID: %s\n", info.JID.String())
+	if info.Topic != "" {
+		fmt.Fprintf(out, "Description: %s\n", info.Topic)
+	}
+	if owner := inviteCreator(info); owner != "" {
+		fmt.Fprintf(out, "Owner: %s\n", owner)
+	}
+	if !info.GroupCreated.IsZero() {
+		fmt.Fprintf(out, "Created: %s\n", info.GroupCreated.Format("2006-01-02 15:04"))
+	}
+
+	ephemeral := "off"
+	if info.IsEphemeral {
+		ephemeral = formatDisappearingTimer(info.DisappearingTimer)
+	}
+	fmt.Fprintln(out, "\nSettings:")
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "  Only admins can send messages (announce):\t%s\n", formatOnOff(info.IsAnnounce))
+	fmt.Fprintf(w, "  Only admins can edit group info (locked):\t%s\n", formatOnOff(info.IsLocked))
+	fmt.Fprintf(w, "  Disappearing messages:\t%s\n", ephemeral)
+	fmt.Fprintf(w, "  Admins approve new members (join approval):\t%s\n", formatOnOff(info.IsJoinApprovalRequired))
+	w.Flush()
+
+	fmt.Fprintf(out, "\nParticipants (%d):\n", len(members))
+	w = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PHONE\tNAME\tPUSH NAME\tROLE")
+	for _, member := range members {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", orDash(member.Phone), orDash(member.Name), orDash(member.PushName), member.Role)
+	}
+	w.Flush()
+}
+
+// orDash returns value, or a dash if it is empty, for table cells
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// writeGroupMembersCSV writes the participants of a group as CSV
+func writeGroupMembersCSV(out io.Writer, members []groupMember) error {
+	w := csv.NewWriter(out)
+	if err := w.Write([]string{"phone", "jid", "name", "push_name", "role"}); err != nil {
+		return err
+	}
+	for _, member := range members {
+		if err := w.Write([]string{member.Phone, member.JID, member.Name, member.PushName, member.Role}); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// resolveGroup returns the info of a group given by its ID, its exact name or an alias for either
+func resolveGroup(client *whatsmeow.Client, value string) (*types.GroupInfo, error) {
+	groupJID, err := resolveGroupJID(client, value)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := client.GetGroupInfo(groupJID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group info: %w", err)
+	}
+	return info, nil
+}
+
+// resolveGroupJID returns the ID of a group given by its ID, its exact name or an alias for either
+func resolveGroupJID(client *whatsmeow.Client, value string) (types.JID, error) {
+	value, err := resolveAlias(value)
+	if err != nil {
+		return types.EmptyJID, err
+	}
+
+	if strings.Contains(value, "@") {
+		return parseGroupJID(value)
+	}
+	return findGroupByName(client, value)
+}
+
+func runGroupsInfo(group string) {
+	client := connectClient(debug)
+
+	info, err := resolveGroup(client, group)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	members := groupMembersOf(info, func(jid types.JID) (types.ContactInfo, bool) {
+		contact, err := client.Store.Contacts.GetContact(context.Background(), jid)
+		return contact, err == nil && contact.Found
+	})
+	client.Disconnect()
+
+	if groupInfoCSV == "-" {
+		if err := writeGroupMembersCSV(os.Stdout, members); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing CSV: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	printGroupInfo(os.Stdout, info, members)
+
+	if groupInfoCSV != "" {
+		file, err := os.Create(groupInfoCSV)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating CSV file: %v\n", err)
+			os.Exit(1)
+		}
+		err = writeGroupMembersCSV(file, members)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing CSV: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("\nParticipants written to %s\n", groupInfoCSV)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+func testGroupInfo() *types.GroupInfo {
+	return &types.GroupInfo{
+		JID:            types.NewJID("123456789", types.GroupServer),
+		OwnerJID:       types.NewJID("15550100", types.DefaultUserServer),
+		GroupName:      types.GroupName{Name: "Phoenix"},
+		GroupTopic:     types.GroupTopic{Topic: "Release planning"},
+		GroupAnnounce:  types.GroupAnnounce{IsAnnounce: true},
+		GroupEphemeral: types.GroupEphemeral{IsEphemeral: true, DisappearingTimer: 7 * 24 * 60 * 60},
+		GroupCreated:   time.Date(2024, 3, 1, 9, 30, 0, 0, time.Local),
+		Participants: []types.GroupParticipant{
+			{JID: types.NewJID("15550101", types.DefaultUserServer)},
+			{JID: types.NewJID("42", types.HiddenUserServer), PhoneNumber: types.NewJID("15550102", types.DefaultUserServer), IsAdmin: true},
+			{JID: types.NewJID("43", types.HiddenUserServer)},
+			{JID: types.NewJID("15550100", types.DefaultUserServer), IsAdmin: true, IsSuperAdmin: true},
+		},
+	}
+}
+
+func TestGroupMembersOf(t *testing.T) {
+	contacts := map[types.JID]types.ContactInfo{
+		types.NewJID("15550100", types.DefaultUserServer): {Found: true, FullName: "Ana Lima", PushName: "Ana"},
+		types.NewJID("15550102", types.DefaultUserServer): {Found: true, PushName: "Bruno"},
+	}
+	members := groupMembersOf(testGroupInfo(), func(jid types.JID) (types.ContactInfo, bool) {
+		contact, ok := contacts[jid]
+		return contact, ok
+	})
+
+	if len(members) != 4 {
+		t.Fatalf("Expected 4 members, got %d", len(members))
+	}
+
+	// Admins come first, otherwise the order is kept
+	want := []groupMember{
+		{Phone: "+15550100", JID: "15550100@s.whatsapp.net", Name: "Ana Lima", PushName: "Ana", Role: roleSuperAdmin},
+		{Phone: "+15550102", JID: "42@lid", PushName: "Bruno", Role: roleAdmin},
+		{Phone: "+15550101", JID: "15550101@s.whatsapp.net", Role: roleMember},
+		{JID: "43@lid", Role: roleMember},
+	}
+	for i := range want {
+		if members[i] != want[i] {
+			t.Errorf("members[%d] = %+v, want %+v", i, members[i], want[i])
+		}
+	}
+}
+
+func TestPrintGroupInfo(t *testing.T) {
+	info := testGroupInfo()
+	var out bytes.Buffer
+	printGroupInfo(&out, info, groupMembersOf(info, func(types.JID) (types.ContactInfo, bool) { return types.ContactInfo{}, false }))
+
+	for _, want := range []string{"Description: Release planning", "Owner: +15550100", "Created: 2024-03-01 09:30", "Disappearing messages:", "7d", "Participants (4):"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestWriteGroupMembersCSV(t *testing.T) {
+	members := []groupMember{{Phone: "+15550100", JID: "15550100@s.whatsapp.net", Name: "Lima, Ana", Role: roleAdmin}}
+
+	var out bytes.Buffer
+	if err := writeGroupMembersCSV(&out, members); err != nil {
+		t.Fatalf("writeGroupMembersCSV returned error: %v", err)
+	}
+
+	records, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatalf("Output is not valid CSV: %v", err)
+	}
+	if len(records) != 2 || records[0][0] != "phone" || records[1][2] != "Lima, Ana" || records[1][4] != roleAdmin {
+		t.Errorf("Unexpected CSV records %v", records)
+	}
+}
+
+func TestFormatDisappearingTimer(t *testing.T) {
+	for seconds, want := range map[uint32]string{86400: "24h", 604800: "7d", 7776000: "90d", 3600: "1h0m0s"} {
+		if got := formatDisappearingTimer(seconds); got != want {
+			t.Errorf("formatDisappearingTimer(%d) = %q, want %q", seconds, got, want)
+		}
+	}
+}
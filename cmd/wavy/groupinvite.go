@@ -0,0 +1,224 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/skip2/go-qrcode"
+	"github.com/spf13/cobra"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+)
+
+var (
+	groupInviteReset  bool
+	groupInviteQR     string
+	groupInviteQRSize int
+)
+
+var groupsInviteCmd = &cobra.Command{
+	Use:   "invite [group]",
+	Short: "Show the invite link of a group",
+	Long: `Show the invite link of a group. Only group admins can get it.
+
+With --reset, the current link is revoked and a new one is created. With --qr, the link is also
+written as a QR code PNG for printing, or shown in the terminal if the file is "-".`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			cmd.Help()
+			os.Exit(1)
+		}
+
+		runGroupsInvite(args[0])
+	},
+}
+
+var groupsInviteInfoCmd = &cobra.Command{
+	Use:   "invite-info [link]",
+	Short: "Show the group an invite link leads to, without joining it",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			cmd.Help()
+			os.Exit(1)
+		}
+
+		runGroupsInviteInfo(args[0])
+	},
+}
+
+var groupsJoinCmd = &cobra.Command{
+	Use:   "join [link]",
+	Short: "Join a group with an invite link",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			cmd.Help()
+			os.Exit(1)
+		}
+
+		runGroupsJoin(args[0])
+	},
+}
+
+func init() {
+	groupsInviteCmd.Flags().BoolVar(&groupInviteReset, "reset", false, "Revoke the current link and create a new one")
+	groupsInviteCmd.Flags().StringVar(&groupInviteQR, "qr", "", "Write the link as a QR code PNG to this file, or to the terminal if \"-\"")
+	groupsInviteCmd.Flags().IntVar(&groupInviteQRSize, "qr-size", 512, "Width and height of the QR code PNG in pixels")
+	groupsInviteCmd.Flags().BoolVarP(&debug, "debug", "d", false, "Enable verbose debugging")
+
+	groupsInviteInfoCmd.Flags().BoolVarP(&debug, "debug", "d", false, "Enable verbose debugging")
+	groupsJoinCmd.Flags().BoolVarP(&debug, "debug", "d", false, "Enable verbose debugging")
+
+	groupsCmd.AddCommand(groupsInviteCmd)
+	groupsCmd.AddCommand(groupsInviteInfoCmd)
+	groupsCmd.AddCommand(groupsJoinCmd)
+}
+
+// parseInviteCode extracts the code from an invite link. Links without scheme and bare codes are accepted.
+func parseInviteCode(link string) (string, error) {
+	code := strings.TrimSpace(link)
+	code = strings.TrimPrefix(code, "https://")
+	code = strings.TrimPrefix(code, "http://")
+	code = strings.TrimPrefix(code, "chat.whatsapp.com/")
+	code = strings.TrimPrefix(code, "invite/")
+	code = strings.TrimSuffix(code, "/")
+
+	if code == "" || strings.ContainsAny(code, "/?#@ ") {
+		return "", fmt.Errorf("invalid invite link %q, use %sCODE", link, whatsmeow.InviteLinkPrefix)
+	}
+	return code, nil
+}
+
+// writeInviteQR writes a link as a QR code PNG, or prints it to the terminal if path is "-"
+func writeInviteQR(link, path string, size int) error {
+	if path == "-" {
+		qr, err := qrcode.New(link, qrcode.Medium)
+		if err != nil {
+			return fmt.Errorf("failed to generate QR code: %w", err)
+		}
+		fmt.Print(qr.ToSmallString(false))
+		return nil
+	}
+
+	if err := qrcode.WriteFile(link, qrcode.Medium, size, path); err != nil {
+		return fmt.Errorf("failed to write QR code: %w", err)
+	}
+	fmt.Printf("QR code saved to %s\n", path)
+	return nil
+}
+
+// describeInviteError explains the errors WhatsApp returns for invite links
+func describeInviteError(err error) error {
+	switch {
+	case errors.Is(err, whatsmeow.ErrInviteLinkRevoked):
+		return errors.New("the invite link was revoked")
+	case errors.Is(err, whatsmeow.ErrInviteLinkInvalid):
+		return errors.New("the invite link is invalid")
+	}
+	return err
+}
+
+func runGroupsInvite(group string) {
+	client := connectClient(debug)
+
+	groupJID, err := resolveGroupJID(client, group)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	link, err := client.GetGroupInviteLink(groupJID, groupInviteReset)
+	client.Disconnect()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error getting invite link: %v\n", err)
+		os.Exit(1)
+	}
+
+	if groupInviteReset {
+		fmt.Println("The previous invite link was revoked")
+	}
+	fmt.Println(link)
+
+	if groupInviteQR != "" {
+		if err := writeInviteQR(link, groupInviteQR, groupInviteQRSize); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+}
+
+func runGroupsInviteInfo(link string) {
+	code, err := parseInviteCode(link)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	client := connectClient(debug)
+
+	info, err := client.GetGroupInfoFromLink(code)
+	client.Disconnect()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", describeInviteError(err))
+		os.Exit(1)
+	}
+
+	fmt.Printf("Group Name: %s\n", info.Name)
+	fmt.Printf("Group ID: %s\n", info.JID.String())
+	fmt.Printf("Member Count: %d\n", len(info.Participants))
+	if creator := inviteCreator(info); creator != "" {
+		fmt.Printf("Creator: %s\n", creator)
+	}
+	if !info.GroupCreated.IsZero() {
+		fmt.Printf("Created: %s\n", info.GroupCreated.Format("2006-01-02 15:04"))
+	}
+	if info.Topic != "" {
+		fmt.Printf("Description: %s\n", info.Topic)
+	}
+	if info.IsJoinApprovalRequired {
+		fmt.Println("Joining requires the approval of an admin")
+	}
+}
+
+// inviteCreator returns the phone number of the creator of a group, if WhatsApp shares it
+func inviteCreator(info *types.GroupInfo) string {
+	if !info.OwnerPN.IsEmpty() {
+		return "+" + info.OwnerPN.User
+	}
+	if info.OwnerJID.Server == types.DefaultUserServer {
+		return "+" + info.OwnerJID.User
+	}
+	return ""
+}
+
+func runGroupsJoin(link string) {
+	code, err := parseInviteCode(link)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	client := connectClient(debug)
+
+	// The answer to joining is the same whether or not an admin must approve it first
+	info, err := client.GetGroupInfoFromLink(code)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", describeInviteError(err))
+		os.Exit(1)
+	}
+
+	groupJID, err := client.JoinGroupWithLink(code)
+	client.Disconnect()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error joining group: %v\n", describeInviteError(err))
+		os.Exit(1)
+	}
+	forgetCachedGroups()
+
+	if info.IsJoinApprovalRequired {
+		fmt.Printf("Requested to join %q (%s), an admin must approve the request\n", info.Name, groupJID.String())
+		return
+	}
+	fmt.Printf("Joined group %q (%s)\n", info.Name, groupJID.String())
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+func TestParseInviteCode(t *testing.T) {
+	for _, link := range []string{
+		"https://chat.whatsapp.com/AbCdEf123",
+		"chat.whatsapp.com/AbCdEf123/",
+		" AbCdEf123 ",
+	} {
+		code, err := parseInviteCode(link)
+		if err != nil || code != "AbCdEf123" {
+			t.Errorf("parseInviteCode(%q) = %q, %v", link, code, err)
+		}
+	}
+
+	for _, invalid := range []string{"", "https://example.com/join?code=1", "https://chat.whatsapp.com/"} {
+		if _, err := parseInviteCode(invalid); err == nil {
+			t.Errorf("Expected error for %q", invalid)
+		}
+	}
+}
+
+func TestWriteInviteQR(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "invite.png")
+	if err := writeInviteQR("https://chat.whatsapp.com/AbCdEf123", path, 256); err != nil {
+		t.Fatalf("writeInviteQR returned error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil || len(data) < 8 || string(data[1:4]) != "PNG" {
+		t.Errorf("Expected a PNG file, got %d bytes, %v", len(data), err)
+	}
+}
+
+func TestInviteCreator(t *testing.T) {
+	info := &types.GroupInfo{
+		OwnerJID: types.NewJID("42", types.HiddenUserServer),
+		OwnerPN:  types.NewJID("15550100", types.DefaultUserServer),
+	}
+	if creator := inviteCreator(info); creator != "+15550100" {
+		t.Errorf("Expected the creator's phone number, got %q", creator)
+	}
+
+	// Only a LID is not a phone number
+	info.OwnerPN = types.EmptyJID
+	if creator := inviteCreator(info); creator != "" {
+		t.Errorf("Expected no creator, got %q", creator)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"go.mau.fi/whatsmeow/types"
+)
+
+var groupLeaveYes bool
+
+var groupsLeaveCmd = &cobra.Command{
+	Use:   "leave [groups...]",
+	Short: "Leave groups",
+	Long: `Leave one or more groups, given by their ID, exact name or an alias. The groups are listed and
+you are asked for confirmation first, unless --yes is given.
+
+You cannot rejoin a group without being added again or an invite link.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			cmd.Help()
+			os.Exit(1)
+		}
+
+		runGroupsLeave(args)
+	},
+}
+
+func init() {
+	groupsLeaveCmd.Flags().BoolVarP(&groupLeaveYes, "yes", "y", false, "Leave without asking for confirmation")
+	groupsLeaveCmd.Flags().BoolVarP(&debug, "debug", "d", false, "Enable verbose debugging")
+	groupsCmd.AddCommand(groupsLeaveCmd)
+}
+
+func runGroupsLeave(values []string) {
+	client := connectClient(debug)
+
+	var groups []*types.GroupInfo
+	seen := make(map[types.JID]bool)
+	for _, value := range values {
+		info, err := resolveGroup(client, value)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s: %v\n", value, err)
+			os.Exit(1)
+		}
+		if !seen[info.JID] {
+			seen[info.JID] = true
+			groups = append(groups, info)
+		}
+	}
+
+	fmt.Println("Groups to leave:")
+	for _, group := range groups {
+		fmt.Printf("  %s (%s, %d members)\n", group.Name, group.JID.String(), len(group.Participants))
+	}
+	fmt.Println()
+
+	if !groupLeaveYes && !confirm(fmt.Sprintf("Leave %d groups?", len(groups))) {
+		fmt.Println("No groups were left")
+		client.Disconnect()
+		return
+	}
+
+	failed := 0
+	for _, group := range groups {
+		if err := client.LeaveGroup(group.JID); err != nil {
+			fmt.Fprintf(os.Stderr, "Error leaving %q: %v\n", group.Name, err)
+			failed++
+			continue
+		}
+		fmt.Printf("Left group %q\n", group.Name)
+	}
+	client.Disconnect()
+	forgetCachedGroups()
+
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,372 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+	"go.mau.fi/whatsmeow"
+	//nolint:staticcheck // Using deprecated package for compatibility
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+// maxGroupNameLength is the longest group name WhatsApp accepts, in characters
+const maxGroupNameLength = 25
+
+// Outcomes of a participant change
+const (
+	participantAdded          = "added"
+	participantRemoved        = "removed"
+	participantPromoted       = "promoted"
+	participantDemoted        = "demoted"
+	participantInviteRequired = "invite_required"
+	participantInvited        = "invited"
+	participantFailed         = "failed"
+)
+
+var (
+	groupMembers     []string
+	groupJSON        bool
+	groupSendInvites bool
+)
+
+var groupsCreateCmd = &cobra.Command{
+	Use:   "create [name]",
+	Short: "Create a group",
+	Long: `Create a group with the given name and members. You are added as admin automatically.
+
+Members whose privacy settings do not allow them to be added are reported as invite_required.
+With --send-invites, they are sent an invitation to join instead.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			cmd.Help()
+			os.Exit(1)
+		}
+
+		runGroupsCreate(args[0])
+	},
+}
+
+// participantChanges are the group subcommands that change participants
+var participantChanges = []struct {
+	use     string
+	short   string
+	action  whatsmeow.ParticipantChange
+	success string
+}{
+	{use: "add", short: "Add participants to a group", action: whatsmeow.ParticipantChangeAdd, success: participantAdded},
+	{use: "remove", short: "Remove participants from a group", action: whatsmeow.ParticipantChangeRemove, success: participantRemoved},
+	{use: "promote", short: "Make participants group admins", action: whatsmeow.ParticipantChangePromote, success: participantPromoted},
+	{use: "demote", short: "Revoke the admin rights of participants", action: whatsmeow.ParticipantChangeDemote, success: participantDemoted},
+}
+
+func init() {
+	groupsCreateCmd.Flags().StringArrayVar(&groupMembers, "member", nil, "Phone number of a member, can be repeated or comma-separated")
+	groupsCreateCmd.Flags().BoolVar(&groupSendInvites, "send-invites", false, "Send an invitation to members that cannot be added directly")
+	groupsCreateCmd.Flags().BoolVar(&groupJSON, "json", false, "Print the result as JSON")
+	groupsCreateCmd.Flags().BoolVarP(&debug, "debug", "d", false, "Enable verbose debugging")
+	groupsCmd.AddCommand(groupsCreateCmd)
+
+	for _, change := range participantChanges {
+		cmd := &cobra.Command{
+			Use:   change.use + " [group] [phones...]",
+			Short: change.short,
+			Run: func(cmd *cobra.Command, args []string) {
+				if len(args) < 2 {
+					cmd.Help()
+					os.Exit(1)
+				}
+
+				runGroupsParticipants(args[0], args[1:], change.action, change.success)
+			},
+		}
+		if change.action == whatsmeow.ParticipantChangeAdd {
+			cmd.Flags().BoolVar(&groupSendInvites, "send-invites", false, "Send an invitation to participants that cannot be added directly")
+		}
+		cmd.Flags().BoolVar(&groupJSON, "json", false, "Print the result as JSON")
+		cmd.Flags().BoolVarP(&debug, "debug", "d", false, "Enable verbose debugging")
+		groupsCmd.AddCommand(cmd)
+	}
+}
+
+// participantResult is the outcome of a change for a single participant
+type participantResult struct {
+	Input            string     `json:"input"`
+	JID              string     `json:"jid,omitempty"`
+	Status           string     `json:"status"`
+	Code             int        `json:"code,omitempty"`
+	Error            string     `json:"error,omitempty"`
+	InviteCode       string     `json:"invite_code,omitempty"`
+	InviteExpiration *time.Time `json:"invite_expiration,omitempty"`
+}
+
+// groupChangeResult is the outcome of creating a group or changing its participants
+type groupChangeResult struct {
+	Group        string              `json:"group"`
+	Name         string              `json:"name,omitempty"`
+	Action       string              `json:"action"`
+	Participants []participantResult `json:"participants"`
+}
+
+// failed returns the number of participants the change did not apply to
+func (r groupChangeResult) failed() int {
+	failed := 0
+	for _, participant := range r.Participants {
+		if participant.Status == participantFailed || participant.Status == participantInviteRequired {
+			failed++
+		}
+	}
+	return failed
+}
+
+// describeParticipantError explains the error codes WhatsApp returns for participant changes
+func describeParticipantError(code int) string {
+	switch code {
+	case 401:
+		return "not authorized"
+	case 403:
+		return "not allowed by their privacy settings"
+	case 404:
+		return "not a participant or not on WhatsApp"
+	case 408:
+		return "recently left the group"
+	case 409:
+		return "already in the group"
+	default:
+		return fmt.Sprintf("error %d", code)
+	}
+}
+
+// parseParticipantPhones splits the phone numbers of participants and expands aliases, rejecting anything else
+func parseParticipantPhones(values []string) ([]string, error) {
+	phones, err := parseRecipientList(values)
+	if err != nil {
+		return nil, err
+	}
+	// Names are not looked up, so a typo never adds the wrong person to a group
+	for _, phone := range phones {
+		if !isPhoneNumber(phone) {
+			return nil, fmt.Errorf("%s: participants must be phone numbers", phone)
+		}
+	}
+	return phones, nil
+}
+
+// participantResults matches the participants returned by WhatsApp to the requested ones
+func participantResults(success string, requested []resolvedRecipient, changed []types.GroupParticipant) []participantResult {
+	results := make([]participantResult, len(requested))
+	for i, recipient := range requested {
+		results[i] = participantResult{Input: recipient.Input}
+		if recipient.Err != nil {
+			results[i].Status = participantFailed
+			results[i].Error = recipient.Err.Error()
+			continue
+		}
+		results[i].JID = recipient.JID.String()
+
+		participant, ok := findParticipant(changed, recipient.JID)
+		switch {
+		case !ok:
+			results[i].Status = participantFailed
+			results[i].Error = "not in the response from WhatsApp"
+		case participant.AddRequest != nil:
+			// Their privacy settings only allow joining through an invitation
+			expiration := participant.AddRequest.Expiration
+			results[i].Status = participantInviteRequired
+			results[i].Code = participant.Error
+			results[i].InviteCode = participant.AddRequest.Code
+			results[i].InviteExpiration = &expiration
+		case participant.Error != 0:
+			results[i].Status = participantFailed
+			results[i].Code = participant.Error
+			results[i].Error = describeParticipantError(participant.Error)
+		default:
+			results[i].Status = success
+		}
+	}
+	return results
+}
+
+// findParticipant finds a participant by phone number, whichever of its JIDs WhatsApp returned it with
+func findParticipant(participants []types.GroupParticipant, jid types.JID) (types.GroupParticipant, bool) {
+	for _, participant := range participants {
+		if participant.JID.User == jid.User || participant.PhoneNumber.User == jid.User {
+			return participant, true
+		}
+	}
+	return types.GroupParticipant{}, false
+}
+
+// sendAddInvites sends an invitation to the participants that could only be added with one
+func sendAddInvites(client *whatsmeow.Client, group types.JID, name string, results []participantResult) {
+	for i, result := range results {
+		if result.Status != participantInviteRequired {
+			continue
+		}
+
+		jid, err := types.ParseJID(result.JID)
+		if err != nil {
+			continue
+		}
+
+		message := &waProto.Message{GroupInviteMessage: &waProto.GroupInviteMessage{
+			GroupJID:         proto.String(group.String()),
+			InviteCode:       proto.String(result.InviteCode),
+			InviteExpiration: proto.Int64(result.InviteExpiration.Unix()),
+			GroupName:        proto.String(name),
+			Caption:          proto.String("Invitation to join my WhatsApp group"),
+		}}
+		if _, err := sendMessage(client, jid, message); err != nil {
+			results[i].Error = fmt.Sprintf("failed to send invitation: %v", err)
+			continue
+		}
+		results[i].Status = participantInvited
+	}
+}
+
+// printGroupChange prints the outcome of a group change as a table or as JSON
+func printGroupChange(out io.Writer, result groupChangeResult, asJSON bool) error {
+	if asJSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PARTICIPANT\tJID\tSTATUS\tDETAIL")
+	for _, participant := range result.Participants {
+		jid := participant.JID
+		if jid == "" {
+			jid = "-"
+		}
+
+		detail := participant.Error
+		if participant.Status == participantInviteRequired {
+			detail = "not allowed by their privacy settings, use --send-invites to invite them"
+		}
+		if detail == "" {
+			detail = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", participant.Input, jid, participant.Status, detail)
+	}
+	return w.Flush()
+}
+
+// finishGroupChange prints the outcome of a group change and exits with an error if it did not apply to everyone
+func finishGroupChange(result groupChangeResult) {
+	if err := printGroupChange(os.Stdout, result, groupJSON); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if result.failed() > 0 {
+		os.Exit(1)
+	}
+}
+
+func runGroupsCreate(name string) {
+	if utf8.RuneCountInString(name) > maxGroupNameLength {
+		fmt.Fprintf(os.Stderr, "Error: group names are limited to %d characters\n", maxGroupNameLength)
+		os.Exit(1)
+	}
+
+	phones, err := parseParticipantPhones(groupMembers)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	client := connectClient(debug)
+
+	members := resolveRecipients(client, phones)
+	var participants []types.JID
+	for _, member := range members {
+		if member.Err == nil {
+			participants = append(participants, member.JID)
+		}
+	}
+
+	info, err := client.CreateGroup(whatsmeow.ReqCreateGroup{Name: name, Participants: participants})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating group: %v\n", err)
+		os.Exit(1)
+	}
+
+	result := groupChangeResult{
+		Group:        info.JID.String(),
+		Name:         info.Name,
+		Action:       "create",
+		Participants: participantResults(participantAdded, members, info.Participants),
+	}
+	if groupSendInvites {
+		sendAddInvites(client, info.JID, info.Name, result.Participants)
+	}
+
+	client.Disconnect()
+	forgetCachedGroups()
+
+	if !groupJSON {
+		fmt.Printf("Group %q created with ID %s\n\n", info.Name, info.JID.String())
+	}
+	finishGroupChange(result)
+}
+
+func runGroupsParticipants(group string, values []string, action whatsmeow.ParticipantChange, success string) {
+	phones, err := parseParticipantPhones(values)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	client := connectClient(debug)
+
+	groupJID, err := resolveGroupJID(client, group)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	requested := resolveRecipients(client, phones)
+	var participants []types.JID
+	for _, participant := range requested {
+		if participant.Err == nil {
+			participants = append(participants, participant.JID)
+		}
+	}
+
+	var changed []types.GroupParticipant
+	if len(participants) > 0 {
+		changed, err = client.UpdateGroupParticipants(groupJID, participants, action)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error updating participants: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	result := groupChangeResult{
+		Group:        groupJID.String(),
+		Action:       string(action),
+		Participants: participantResults(success, requested, changed),
+	}
+
+	if groupSendInvites && result.failed() > 0 {
+		info, err := client.GetGroupInfo(groupJID)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error getting group info: %v\n", err)
+			os.Exit(1)
+		}
+		result.Name = info.Name
+		sendAddInvites(client, groupJID, info.Name, result.Participants)
+	}
+
+	client.Disconnect()
+	forgetCachedGroups()
+	finishGroupChange(result)
+}
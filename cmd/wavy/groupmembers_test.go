@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+func TestParticipantResults(t *testing.T) {
+	requested := []resolvedRecipient{
+		{Input: "+15550100", JID: types.NewJID("15550100", types.DefaultUserServer)},
+		{Input: "+15550101", JID: types.NewJID("15550101", types.DefaultUserServer)},
+		{Input: "+15550102", JID: types.NewJID("15550102", types.DefaultUserServer)},
+		{Input: "+15550103", Err: errors.New("phone number 15550103 not found on WhatsApp")},
+		{Input: "+15550104", JID: types.NewJID("15550104", types.DefaultUserServer)},
+	}
+	expiration := time.Unix(1750000000, 0)
+	changed := []types.GroupParticipant{
+		// WhatsApp may answer with the LID and the phone number separately
+		{JID: types.NewJID("987654", types.HiddenUserServer), PhoneNumber: types.NewJID("15550100", types.DefaultUserServer)},
+		{JID: types.NewJID("15550101", types.DefaultUserServer), Error: 403, AddRequest: &types.GroupParticipantAddRequest{Code: "ABC", Expiration: expiration}},
+		{JID: types.NewJID("15550102", types.DefaultUserServer), Error: 409},
+	}
+
+	results := participantResults(participantAdded, requested, changed)
+
+	want := []string{participantAdded, participantInviteRequired, participantFailed, participantFailed, participantFailed}
+	for i, status := range want {
+		if results[i].Status != status {
+			t.Errorf("results[%d].Status = %q, want %q", i, results[i].Status, status)
+		}
+	}
+
+	if results[1].InviteCode != "ABC" || !results[1].InviteExpiration.Equal(expiration) {
+		t.Errorf("Expected invite details, got %+v", results[1])
+	}
+	if results[2].Code != 409 || results[2].Error != "already in the group" {
+		t.Errorf("Expected error 409 to be described, got %+v", results[2])
+	}
+	if results[3].JID != "" || !strings.Contains(results[3].Error, "not found") {
+		t.Errorf("Expected the resolve error, got %+v", results[3])
+	}
+
+	result := groupChangeResult{Group: "123456789@g.us", Action: "add", Participants: results}
+	if result.failed() != 4 {
+		t.Errorf("Expected 4 failed participants, got %d", result.failed())
+	}
+}
+
+func TestParseParticipantPhones(t *testing.T) {
+	phones, err := parseParticipantPhones([]string{"+15550100,+15550101", "+15550100"})
+	if err != nil || len(phones) != 2 {
+		t.Errorf("parseParticipantPhones() = %v, %v", phones, err)
+	}
+
+	if _, err := parseParticipantPhones([]string{"123456789@g.us"}); err == nil {
+		t.Error("Expected error for a group ID")
+	}
+	if _, err := parseParticipantPhones([]string{"Alice"}); err == nil {
+		t.Error("Expected error for a contact name")
+	}
+}
+
+func TestPrintGroupChangeJSON(t *testing.T) {
+	result := groupChangeResult{
+		Group:  "123456789@g.us",
+		Name:   "Team",
+		Action: "create",
+		Participants: []participantResult{
+			{Input: "+15550100", JID: "15550100@s.whatsapp.net", Status: participantAdded},
+		},
+	}
+
+	var out bytes.Buffer
+	if err := printGroupChange(&out, result, true); err != nil {
+		t.Fatalf("printGroupChange returned error: %v", err)
+	}
+
+	var decoded groupChangeResult
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("Output is not valid JSON: %v\n%s", err, out.String())
+	}
+	if decoded.Group != result.Group || len(decoded.Participants) != 1 || decoded.Participants[0].Status != participantAdded {
+		t.Errorf("Unexpected decoded result %+v", decoded)
+	}
+	if strings.Contains(out.String(), "invite_code") {
+		t.Errorf("Expected empty fields to be omitted, got %s", out.String())
+	}
+}
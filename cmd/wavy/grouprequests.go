@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// Outcomes of answering a request to join a group
+const (
+	participantApproved = "approved"
+	participantRejected = "rejected"
+)
+
+// allRequests answers every pending request when given to --approve or --reject
+const allRequests = "all"
+
+var (
+	groupRequestsApprove []string
+	groupRequestsReject  []string
+)
+
+var groupsRequestsCmd = &cobra.Command{
+	Use:   "requests [group]",
+	Short: "List and answer requests to join a group",
+	Long: `List the pending requests to join a group, given by its ID, exact name or an alias. Only group
+admins can see them, and they are only made when admins must approve new members.
+
+Answer requests with --approve and --reject, giving the phone numbers or IDs shown in the list,
+or "all" for every pending request.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			cmd.Help()
+			os.Exit(1)
+		}
+
+		runGroupsRequests(args[0])
+	},
+}
+
+func init() {
+	groupsRequestsCmd.Flags().StringArrayVar(&groupRequestsApprove, "approve", nil, "Approve the requests of these requesters, or \"all\", can be repeated or comma-separated")
+	groupsRequestsCmd.Flags().StringArrayVar(&groupRequestsReject, "reject", nil, "Reject the requests of these requesters, or \"all\", can be repeated or comma-separated")
+	groupsRequestsCmd.Flags().BoolVar(&groupJSON, "json", false, "Print the result of answering requests as JSON")
+	groupsRequestsCmd.Flags().BoolVarP(&debug, "debug", "d", false, "Enable verbose debugging")
+	groupsCmd.AddCommand(groupsRequestsCmd)
+}
+
+// requesterID formats the requester of a join request the way it is given to --approve and --reject
+func requesterID(jid types.JID) string {
+	return formatRecipient(jid)
+}
+
+// selectJoinRequests matches the requesters given on the command line to the pending requests.
+// Requesters without a pending request are returned with an error.
+func selectJoinRequests(requests []types.GroupParticipantRequest, values []string) []resolvedRecipient {
+	inputs := splitRecipients(values)
+	for _, input := range inputs {
+		if strings.EqualFold(input, allRequests) {
+			selected := make([]resolvedRecipient, len(requests))
+			for i, request := range requests {
+				selected[i] = resolvedRecipient{Input: requesterID(request.JID), JID: request.JID}
+			}
+			return selected
+		}
+	}
+
+	selected := make([]resolvedRecipient, len(inputs))
+	for i, input := range inputs {
+		selected[i] = resolvedRecipient{Input: input}
+		for _, request := range requests {
+			if request.JID.String() == input || request.JID.User == normalizePhoneNumber(input) {
+				selected[i].JID = request.JID
+				break
+			}
+		}
+		if selected[i].JID.IsEmpty() {
+			selected[i].Err = fmt.Errorf("no pending request to join")
+		}
+	}
+	return selected
+}
+
+// printJoinRequests prints the pending requests to join a group, oldest first
+func printJoinRequests(out io.Writer, requests []types.GroupParticipantRequest, name func(jid types.JID) string) {
+	sort.SliceStable(requests, func(i, j int) bool {
+		return requests[i].RequestedAt.Before(requests[j].RequestedAt)
+	})
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "REQUESTER\tNAME\tREQUESTED")
+	for _, request := range requests {
+		requested := "-"
+		if !request.RequestedAt.IsZero() {
+			requested = request.RequestedAt.Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", requesterID(request.JID), orDash(name(request.JID)), requested)
+	}
+	w.Flush()
+}
+
+// answerJoinRequests approves or rejects the selected requests and returns the outcome for each requester
+func answerJoinRequests(client *whatsmeow.Client, group types.JID, selected []resolvedRecipient, action whatsmeow.ParticipantRequestChange, success string) ([]participantResult, error) {
+	var jids []types.JID
+	for _, requester := range selected {
+		if requester.Err == nil {
+			jids = append(jids, requester.JID)
+		}
+	}
+
+	var changed []types.GroupParticipant
+	if len(jids) > 0 {
+		var err error
+		changed, err = client.UpdateGroupRequestParticipants(group, jids, action)
+		if err != nil {
+			return nil, fmt.Errorf("failed to %s requests: %w", action, err)
+		}
+	}
+	return participantResults(success, selected, changed), nil
+}
+
+func runGroupsRequests(group string) {
+	client := connectClient(debug)
+
+	info, err := resolveGroup(client, group)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	requests, err := client.GetGroupRequestParticipants(info.JID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error getting requests to join: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(groupRequestsApprove) == 0 && len(groupRequestsReject) == 0 {
+		client.Disconnect()
+
+		if len(requests) == 0 {
+			fmt.Printf("No pending requests to join %q\n", info.Name)
+			return
+		}
+		fmt.Printf("Pending requests to join %q (%d):\n", info.Name, len(requests))
+		printJoinRequests(os.Stdout, requests, func(jid types.JID) string {
+			contact, err := client.Store.Contacts.GetContact(context.Background(), jid)
+			if err != nil || !contact.Found {
+				return ""
+			}
+			if contact.FullName != "" {
+				return contact.FullName
+			}
+			return contact.PushName
+		})
+		return
+	}
+
+	approve := selectJoinRequests(requests, groupRequestsApprove)
+	reject := selectJoinRequests(requests, groupRequestsReject)
+	for _, approved := range approve {
+		for _, rejected := range reject {
+			if approved.Err == nil && approved.JID == rejected.JID {
+				fmt.Fprintf(os.Stderr, "Error: %s cannot be both approved and rejected\n", approved.Input)
+				os.Exit(1)
+			}
+		}
+	}
+
+	result := groupChangeResult{Group: info.JID.String(), Name: info.Name, Action: "requests"}
+	answers := []struct {
+		selected []resolvedRecipient
+		action   whatsmeow.ParticipantRequestChange
+		success  string
+	}{
+		{selected: approve, action: whatsmeow.ParticipantChangeApprove, success: participantApproved},
+		{selected: reject, action: whatsmeow.ParticipantChangeReject, success: participantRejected},
+	}
+	for _, answer := range answers {
+		results, err := answerJoinRequests(client, info.JID, answer.selected, answer.action, answer.success)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		result.Participants = append(result.Participants, results...)
+	}
+
+	client.Disconnect()
+	forgetCachedGroups()
+	finishGroupChange(result)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+func testJoinRequests() []types.GroupParticipantRequest {
+	return []types.GroupParticipantRequest{
+		{JID: types.NewJID("15550101", types.DefaultUserServer), RequestedAt: time.Date(2025, 5, 2, 10, 0, 0, 0, time.Local)},
+		{JID: types.NewJID("15550100", types.DefaultUserServer), RequestedAt: time.Date(2025, 5, 1, 9, 30, 0, 0, time.Local)},
+		{JID: types.NewJID("987654", types.HiddenUserServer)},
+	}
+}
+
+func TestSelectJoinRequests(t *testing.T) {
+	selected := selectJoinRequests(testJoinRequests(), []string{"+15550100,15550101", "987654@lid", "+15550199"})
+	if len(selected) != 4 {
+		t.Fatalf("Expected 4 requesters, got %+v", selected)
+	}
+
+	for i, want := range []string{"15550100@s.whatsapp.net", "15550101@s.whatsapp.net", "987654@lid"} {
+		if selected[i].Err != nil || selected[i].JID.String() != want {
+			t.Errorf("selected[%d] = %+v, want %s", i, selected[i], want)
+		}
+	}
+	if selected[3].Err == nil {
+		t.Errorf("Expected a requester without a pending request to fail, got %+v", selected[3])
+	}
+}
+
+func TestSelectJoinRequestsAll(t *testing.T) {
+	selected := selectJoinRequests(testJoinRequests(), []string{"ALL"})
+	if len(selected) != 3 {
+		t.Fatalf("Expected every pending request, got %+v", selected)
+	}
+	if selected[0].Input != "+15550101" || selected[2].Input != "987654@lid" {
+		t.Errorf("Expected requesters to be shown as in the list, got %+v", selected)
+	}
+
+	if selected := selectJoinRequests(testJoinRequests(), nil); len(selected) != 0 {
+		t.Errorf("Expected no requesters without values, got %+v", selected)
+	}
+}
+
+func TestPrintJoinRequests(t *testing.T) {
+	var out bytes.Buffer
+	printJoinRequests(&out, testJoinRequests(), func(jid types.JID) string {
+		if jid.User == "15550100" {
+			return "Ana Lima"
+		}
+		return ""
+	})
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected a header and 3 requests, got:\n%s", out.String())
+	}
+	// The request without a time sorts first, then the oldest
+	if !strings.HasPrefix(lines[1], "987654@lid") || !strings.Contains(lines[2], "Ana Lima") || !strings.Contains(lines[2], "2025-05-01 09:30") {
+		t.Errorf("Unexpected output:\n%s", out.String())
+	}
+	if !strings.HasPrefix(lines[3], "+15550101") {
+		t.Errorf("Expected the newest request last, got %q", lines[3])
+	}
+}
@@ -1,73 +1,332 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
 
-	"whatsmeow-go/cmd/wavy/common"
+	"whatsmeow-go/cmd/wavy/storage"
+)
+
+// groupsCacheMaxAge is how long the cached groups are listed before they are fetched from WhatsApp again
+const groupsCacheMaxAge = time.Hour
+
+var (
+	groupsFilter     string
+	groupsAdminOnly  bool
+	groupsMinMembers int
+	groupsMaxMembers int
+	groupsSort       string
+	groupsCompact    bool
+	groupsRefresh    bool
 )
 
 var groupsCmd = &cobra.Command{
 	Use:   "groups",
 	Short: "List all your WhatsApp groups",
-	Long:  `Display information about all the WhatsApp groups you're a member of.`,
+	Long: `Display information about all the WhatsApp groups you're a member of.
+
+The groups are cached for an hour, use --refresh to fetch them from WhatsApp again.
+--filter matches the group name or ID case-insensitively. Enclose it in slashes, like /^team-/,
+to match a regular expression instead.
+
+Use the subcommands to create groups and manage their participants. They take a group by its ID,
+its exact name ignoring case, or an alias.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		runGroups()
+		filter, err := newGroupFilter(groupsFilter, groupsAdminOnly, groupsMinMembers, groupsMaxMembers)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := validateGroupSort(groupsSort); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		runGroups(filter)
 	},
 }
 
-func runGroups() {
-	// Create client
-	client, needsSetup, err := common.CreateWAClient(true)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error creating client: %v\n", err)
-		os.Exit(1)
+func init() {
+	groupsCmd.Flags().StringVar(&groupsFilter, "filter", "", "Only list groups whose name or ID contains this text, or matches this /regex/")
+	groupsCmd.Flags().BoolVar(&groupsAdminOnly, "admin-only", false, "Only list groups you are an admin of")
+	groupsCmd.Flags().IntVar(&groupsMinMembers, "min-members", 0, "Only list groups with at least this many members")
+	groupsCmd.Flags().IntVar(&groupsMaxMembers, "max-members", 0, "Only list groups with at most this many members")
+	groupsCmd.Flags().StringVar(&groupsSort, "sort", "", "Sort the groups by name, members or created")
+	groupsCmd.Flags().BoolVar(&groupsCompact, "compact", false, "Print one line per group")
+	groupsCmd.Flags().BoolVar(&groupsRefresh, "refresh", false, "Fetch the groups from WhatsApp instead of the cache")
+	groupsCmd.Flags().BoolVarP(&debug, "debug", "d", false, "Enable verbose debugging")
+}
+
+// groupFilter selects the groups to list
+type groupFilter struct {
+	// text is matched case-insensitively against the name and ID, unless pattern is set
+	text       string
+	pattern    *regexp.Regexp
+	adminOnly  bool
+	minMembers int
+	maxMembers int
+}
+
+// newGroupFilter validates the filter flags
+func newGroupFilter(value string, adminOnly bool, minMembers, maxMembers int) (groupFilter, error) {
+	filter := groupFilter{text: strings.ToLower(value), adminOnly: adminOnly, minMembers: minMembers, maxMembers: maxMembers}
+
+	if len(value) >= 2 && strings.HasPrefix(value, "/") && strings.HasSuffix(value, "/") {
+		pattern, err := regexp.Compile("(?i)" + value[1:len(value)-1])
+		if err != nil {
+			return filter, fmt.Errorf("invalid --filter regular expression: %w", err)
+		}
+		filter.pattern = pattern
 	}
 
-	if needsSetup {
-		fmt.Fprintf(os.Stderr, "No WhatsApp session found. Please run 'wavy setup' first.\n")
-		os.Exit(1)
+	if minMembers < 0 || maxMembers < 0 {
+		return filter, errors.New("--min-members and --max-members cannot be negative")
+	}
+	if maxMembers > 0 && minMembers > maxMembers {
+		return filter, errors.New("--min-members cannot be greater than --max-members")
 	}
+	return filter, nil
+}
 
-	// Connect to WhatsApp
-	fmt.Println("Connecting to WhatsApp...")
-	err = client.Connect()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to connect: %v\n", err)
-		os.Exit(1)
+// matches reports whether a group passes the filter. own holds the user parts of the account's phone number and LID.
+func (f groupFilter) matches(group *types.GroupInfo, own []string) bool {
+	switch {
+	case f.pattern != nil:
+		if !f.pattern.MatchString(group.Name) && !f.pattern.MatchString(group.JID.String()) {
+			return false
+		}
+	case f.text != "":
+		if !strings.Contains(strings.ToLower(group.Name), f.text) && !strings.Contains(group.JID.String(), f.text) {
+			return false
+		}
+	}
+
+	members := len(group.Participants)
+	if members < f.minMembers || (f.maxMembers > 0 && members > f.maxMembers) {
+		return false
 	}
 
-	// Get all joined groups
-	groups, err := client.GetJoinedGroups()
+	return !f.adminOnly || isGroupAdmin(group, own)
+}
+
+// isGroupAdmin reports whether the account, identified by the user parts of its JIDs, is an admin of a group
+func isGroupAdmin(group *types.GroupInfo, own []string) bool {
+	for _, participant := range group.Participants {
+		if !participant.IsAdmin {
+			continue
+		}
+		for _, user := range own {
+			if user != "" && (participant.JID.User == user || participant.PhoneNumber.User == user) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// filterGroups returns the groups that pass the filter
+func filterGroups(groups []*types.GroupInfo, filter groupFilter, own []string) []*types.GroupInfo {
+	var filtered []*types.GroupInfo
+	for _, group := range groups {
+		if filter.matches(group, own) {
+			filtered = append(filtered, group)
+		}
+	}
+	return filtered
+}
+
+// validateGroupSort checks the --sort flag
+func validateGroupSort(by string) error {
+	switch by {
+	case "", "name", "members", "created":
+		return nil
+	default:
+		return fmt.Errorf("invalid --sort %q, use name, members or created", by)
+	}
+}
+
+// sortGroups sorts groups by name, by member count with the largest first, or by creation time with the newest first.
+// Groups are sorted by name when they are otherwise equal.
+func sortGroups(groups []*types.GroupInfo, by string) {
+	byName := func(a, b *types.GroupInfo) bool {
+		return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		a, b := groups[i], groups[j]
+		switch by {
+		case "members":
+			if len(a.Participants) != len(b.Participants) {
+				return len(a.Participants) > len(b.Participants)
+			}
+		case "created":
+			if !a.GroupCreated.Equal(b.GroupCreated) {
+				return a.GroupCreated.After(b.GroupCreated)
+			}
+		}
+		return byName(a, b)
+	})
+}
+
+// cachedGroups returns the cached groups if they are recent enough
+func cachedGroups(db *storage.DB, now time.Time) ([]*types.GroupInfo, time.Time, error) {
+	cached, fetchedAt, err := db.ListCachedGroups()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to get groups: %v\n", err)
-		os.Exit(1)
+		return nil, time.Time{}, err
+	}
+	if now.Sub(fetchedAt) > groupsCacheMaxAge {
+		return nil, time.Time{}, storage.ErrNotFound
 	}
 
-	// Print the list of groups
-	if len(groups) == 0 {
-		fmt.Println("You are not a member of any groups")
-	} else {
-		fmt.Println("\n===== YOUR WHATSAPP GROUPS =====")
-		fmt.Println("Count:", len(groups))
-		fmt.Println("----------------------------------")
+	groups := make([]*types.GroupInfo, 0, len(cached))
+	for _, group := range cached {
+		var info types.GroupInfo
+		if err := json.Unmarshal(group.Info, &info); err != nil {
+			return nil, time.Time{}, fmt.Errorf("failed to decode cached group %s: %w", group.JID, err)
+		}
+		groups = append(groups, &info)
+	}
+	return groups, fetchedAt, nil
+}
 
-		for i, group := range groups {
-			fmt.Printf("%d. Group Name: %s\n", i+1, group.Name)
-			fmt.Printf("   Group ID: %s\n", group.JID.String())
-			fmt.Printf("   Member Count: %d\n", len(group.Participants))
-			fmt.Println("----------------------------------")
+// cacheGroups replaces the cached groups with the ones fetched from WhatsApp
+func cacheGroups(db *storage.DB, groups []*types.GroupInfo, fetchedAt time.Time) error {
+	cached := make([]storage.CachedGroup, 0, len(groups))
+	for _, group := range groups {
+		info, err := json.Marshal(group)
+		if err != nil {
+			return fmt.Errorf("failed to encode group %s: %w", group.JID, err)
 		}
+		cached = append(cached, storage.CachedGroup{JID: group.JID.String(), Info: info})
+	}
+	return db.ReplaceCachedGroups(cached, fetchedAt)
+}
+
+// forgetCachedGroups clears the group cache after a command changed the groups, so the next listing is up to date
+func forgetCachedGroups() {
+	db := openStorage()
+	defer db.Close()
+
+	if err := db.ClearCachedGroups(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
+
+// ownUsers returns the user parts of the account's phone number and LID
+func ownUsers(client *whatsmeow.Client) []string {
+	var own []string
+	if client.Store.ID != nil {
+		own = append(own, client.Store.ID.User)
+	}
+	if !client.Store.LID.IsEmpty() {
+		own = append(own, client.Store.LID.User)
+	}
+	return own
+}
 
-		fmt.Println("\nTo send a message to a group, use:")
-		fmt.Println("wavy send -to \"GROUP_ID\" -msg \"Hello group!\"")
-		fmt.Println("\nExample:")
-		if len(groups) > 0 {
-			fmt.Printf("wavy send -to \"%s\" -msg \"Hello group!\"\n", groups[0].JID.String())
+// printGroupsCompact prints one line per group
+func printGroupsCompact(out io.Writer, groups []*types.GroupInfo, own []string) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tID\tMEMBERS\tADMIN\tCREATED")
+	for _, group := range groups {
+		created := "-"
+		if !group.GroupCreated.IsZero() {
+			created = group.GroupCreated.Format("2006-01-02")
 		}
+		admin := "no"
+		if isGroupAdmin(group, own) {
+			admin = "yes"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n", group.Name, group.JID.String(), len(group.Participants), admin, created)
 	}
+	w.Flush()
+}
+
+// printGroups prints the groups with a hint on how to message them
+func printGroups(out io.Writer, groups []*types.GroupInfo) {
+	fmt.Fprintln(out, "\n===== YOUR WHATSAPP GROUPS =====")
+	fmt.Fprintln(out, "Count:", len(groups))
+	fmt.Fprintln(out, "----------------------------------")
+
+	for i, group := range groups {
+		fmt.Fprintf(out, "%d. Group Name: %s\n", i+1, group.Name)
+		fmt.Fprintf(out, "   Group ID: %s\n", group.JID.String())
+		fmt.Fprintf(out, "   Member Count: %d\n", len(group.Participants))
+		fmt.Fprintln(out, "----------------------------------")
+	}
+
+	fmt.Fprintln(out, "\nTo send a message to a group, use:")
+	fmt.Fprintln(out, "wavy send -to \"GROUP_ID\" -msg \"Hello group!\"")
+	fmt.Fprintln(out, "\nExample:")
+	fmt.Fprintf(out, "wavy send -to \"%s\" -msg \"Hello group!\"\n", groups[0].JID.String())
+}
+
+func runGroups(filter groupFilter) {
+	client := newClient(debug)
 
-	client.Disconnect()
+	db := openStorage()
+	defer db.Close()
+
+	now := time.Now()
+	groups, fetchedAt, err := cachedGroups(db, now)
+	if groupsRefresh || err != nil {
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+
+		fmt.Println("Connecting to WhatsApp...")
+		connect(client, debug)
+
+		groups, err = client.GetJoinedGroups()
+		client.Disconnect()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to get groups: %v\n", err)
+			os.Exit(1)
+		}
+
+		fetchedAt = now
+		if err := cacheGroups(db, groups, fetchedAt); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	} else {
+		fmt.Printf("Groups fetched %s ago, use --refresh to update them\n", now.Sub(fetchedAt).Round(time.Minute))
+	}
+
+	if len(groups) == 0 {
+		fmt.Println("You are not a member of any groups")
+		return
+	}
+
+	own := ownUsers(client)
+	total := len(groups)
+	groups = filterGroups(groups, filter, own)
+	if groupsSort != "" {
+		sortGroups(groups, groupsSort)
+	}
+
+	if len(groups) == 0 {
+		fmt.Printf("None of your %d groups match the filters\n", total)
+		return
+	}
+
+	if groupsCompact {
+		printGroupsCompact(os.Stdout, groups, own)
+		if len(groups) < total {
+			fmt.Printf("\n%d of %d groups\n", len(groups), total)
+		}
+		return
+	}
+	printGroups(os.Stdout, groups)
 }
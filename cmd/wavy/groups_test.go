@@ -1,10 +1,17 @@
 package main
 
 import (
+	"bytes"
+	"errors"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/spf13/cobra"
+	"go.mau.fi/whatsmeow/types"
+
+	"whatsmeow-go/cmd/wavy/storage"
 )
 
 func TestGroupsCmd(t *testing.T) {
@@ -45,3 +52,147 @@ func TestGroupsCommand(t *testing.T) {
 		t.Error("Expected groupsCmd.Run to be set, but it wasn't")
 	}
 }
+
+func testGroups() []*types.GroupInfo {
+	member := func(user string, admin bool) types.GroupParticipant {
+		return types.GroupParticipant{JID: types.NewJID(user, types.DefaultUserServer), IsAdmin: admin}
+	}
+	return []*types.GroupInfo{
+		{
+			JID:          types.NewJID("100", types.GroupServer),
+			GroupName:    types.GroupName{Name: "team-backend"},
+			GroupCreated: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+			Participants: []types.GroupParticipant{member("15550100", true), member("15550101", false), member("15550102", false)},
+		},
+		{
+			JID:          types.NewJID("200", types.GroupServer),
+			GroupName:    types.GroupName{Name: "Family"},
+			GroupCreated: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			Participants: []types.GroupParticipant{member("15550100", false), member("15550103", true)},
+		},
+		{
+			JID:          types.NewJID("300", types.GroupServer),
+			GroupName:    types.GroupName{Name: "Team-Frontend"},
+			GroupCreated: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+			Participants: []types.GroupParticipant{member("15550100", false), member("15550104", false), member("15550105", false), member("15550106", false)},
+		},
+	}
+}
+
+func groupNames(groups []*types.GroupInfo) string {
+	names := make([]string, len(groups))
+	for i, group := range groups {
+		names[i] = group.Name
+	}
+	return strings.Join(names, ",")
+}
+
+func TestFilterGroups(t *testing.T) {
+	own := []string{"15550100"}
+	tests := []struct {
+		name       string
+		filter     string
+		adminOnly  bool
+		minMembers int
+		maxMembers int
+		want       string
+	}{
+		{name: "no filter", want: "team-backend,Family,Team-Frontend"},
+		{name: "substring ignores case", filter: "TEAM", want: "team-backend,Team-Frontend"},
+		{name: "substring matches the ID", filter: "200@", want: "Family"},
+		{name: "regex", filter: "/^team-(back|middle)/", want: "team-backend"},
+		{name: "admin only", adminOnly: true, want: "team-backend"},
+		{name: "member range", minMembers: 3, maxMembers: 3, want: "team-backend"},
+		{name: "min members", minMembers: 3, want: "team-backend,Team-Frontend"},
+		{name: "no match", filter: "work", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := newGroupFilter(tt.filter, tt.adminOnly, tt.minMembers, tt.maxMembers)
+			if err != nil {
+				t.Fatalf("newGroupFilter() failed: %v", err)
+			}
+			if got := groupNames(filterGroups(testGroups(), filter, own)); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNewGroupFilterErrors(t *testing.T) {
+	if _, err := newGroupFilter("/[/", false, 0, 0); err == nil {
+		t.Error("Expected an error for an invalid regular expression")
+	}
+	if _, err := newGroupFilter("", false, 5, 2); err == nil {
+		t.Error("Expected an error when --min-members is greater than --max-members")
+	}
+	if _, err := newGroupFilter("", false, -1, 0); err == nil {
+		t.Error("Expected an error for a negative member count")
+	}
+}
+
+func TestSortGroups(t *testing.T) {
+	tests := map[string]string{
+		"name":    "Family,team-backend,Team-Frontend",
+		"members": "Team-Frontend,team-backend,Family",
+		"created": "Family,team-backend,Team-Frontend",
+	}
+	for by, want := range tests {
+		groups := testGroups()
+		sortGroups(groups, by)
+		if got := groupNames(groups); got != want {
+			t.Errorf("sortGroups(%q) = %q, want %q", by, got, want)
+		}
+	}
+
+	if err := validateGroupSort("size"); err == nil {
+		t.Error("Expected an error for an unknown sort order")
+	}
+}
+
+func TestPrintGroupsCompact(t *testing.T) {
+	var out bytes.Buffer
+	printGroupsCompact(&out, testGroups(), []string{"15550100"})
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected a header and 3 lines, got:\n%s", out.String())
+	}
+	if fields := strings.Fields(lines[1]); len(fields) != 5 || fields[0] != "team-backend" || fields[2] != "3" || fields[3] != "yes" || fields[4] != "2023-01-01" {
+		t.Errorf("Unexpected line %q", lines[1])
+	}
+	if fields := strings.Fields(lines[2]); fields[3] != "no" {
+		t.Errorf("Expected not to be admin of Family, got %q", lines[2])
+	}
+}
+
+func TestGroupsCache(t *testing.T) {
+	db, err := storage.Open(filepath.Join(t.TempDir(), "wavy.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	if err := cacheGroups(db, testGroups(), now.Add(-10*time.Minute)); err != nil {
+		t.Fatalf("cacheGroups() failed: %v", err)
+	}
+
+	groups, fetchedAt, err := cachedGroups(db, now)
+	if err != nil {
+		t.Fatalf("cachedGroups() failed: %v", err)
+	}
+	if len(groups) != 3 || fetchedAt.Unix() != now.Add(-10*time.Minute).Unix() {
+		t.Fatalf("Expected 3 groups fetched 10 minutes ago, got %d fetched at %v", len(groups), fetchedAt)
+	}
+	for _, group := range groups {
+		if group.JID.Server != types.GroupServer || len(group.Participants) == 0 || group.GroupCreated.IsZero() {
+			t.Errorf("Group did not survive the cache: %+v", group)
+		}
+	}
+
+	if _, _, err := cachedGroups(db, now.Add(groupsCacheMaxAge)); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected an expired cache to be ignored, got %v", err)
+	}
+}
@@ -0,0 +1,506 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	groupApplyPrune bool
+	groupApplyYes   bool
+)
+
+var groupsApplyCmd = &cobra.Command{
+	Use:   "apply [file]",
+	Short: "Bring groups to the state described in a YAML file",
+	Long: `Compare the groups described in a YAML file with their current state, print a plan of the
+changes and apply it after confirmation. Groups are matched by id, or by name if no id is given.
+
+  groups:
+    - id: 123456789@g.us
+      name: Project Phoenix
+      topic: Release planning
+      announce: false
+      locked: true
+      join_approval: false
+      photo: phoenix.png
+      admins: ["+1234567890"]
+      members: ["+1987654321", "+1555123456"]
+
+Settings that are not given are left unchanged. Admins are also members, and listed admins are
+the only admins: others are demoted. Members missing from the file are only removed with --prune.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			cmd.Help()
+			os.Exit(1)
+		}
+
+		runGroupsApply(args[0])
+	},
+}
+
+func init() {
+	groupsApplyCmd.Flags().BoolVar(&groupApplyPrune, "prune", false, "Remove members that are not in the file")
+	groupsApplyCmd.Flags().BoolVarP(&groupApplyYes, "yes", "y", false, "Apply the plan without asking for confirmation")
+	groupsApplyCmd.Flags().BoolVarP(&debug, "debug", "d", false, "Enable verbose debugging")
+	groupsCmd.AddCommand(groupsApplyCmd)
+}
+
+// groupsFile is the desired state of groups read by 'wavy groups apply'
+type groupsFile struct {
+	Groups []groupSpec `yaml:"groups"`
+}
+
+// groupSpec is the desired state of a single group
+type groupSpec struct {
+	ID           string   `yaml:"id"`
+	Name         *string  `yaml:"name"`
+	Topic        *string  `yaml:"topic"`
+	Announce     *bool    `yaml:"announce"`
+	Locked       *bool    `yaml:"locked"`
+	JoinApproval *bool    `yaml:"join_approval"`
+	Photo        string   `yaml:"photo"`
+	Admins       []string `yaml:"admins"`
+	Members      []string `yaml:"members"`
+}
+
+// settings returns the group settings of the spec
+func (s groupSpec) settings() groupSettings {
+	return groupSettings{
+		Name:         s.Name,
+		Topic:        s.Topic,
+		Announce:     s.Announce,
+		Locked:       s.Locked,
+		JoinApproval: s.JoinApproval,
+		Photo:        s.Photo,
+	}
+}
+
+// label describes the spec in error messages
+func (s groupSpec) label() string {
+	if s.ID != "" {
+		return s.ID
+	}
+	if s.Name != nil {
+		return fmt.Sprintf("%q", *s.Name)
+	}
+	return "group without id or name"
+}
+
+// parseGroupsFile reads the desired state of groups. Relative photo paths are resolved against dir.
+func parseGroupsFile(data []byte, dir string) ([]groupSpec, error) {
+	var file groupsFile
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	if len(file.Groups) == 0 {
+		return nil, errors.New("no groups defined")
+	}
+
+	for i := range file.Groups {
+		spec := &file.Groups[i]
+		if spec.ID == "" && spec.Name == nil {
+			return nil, fmt.Errorf("group %d: id or name is required", i+1)
+		}
+		if spec.ID != "" {
+			jid, err := parseGroupJID(spec.ID)
+			if err != nil {
+				return nil, fmt.Errorf("group %d: %w", i+1, err)
+			}
+			spec.ID = jid.String()
+		}
+		if err := spec.settings().validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", spec.label(), err)
+		}
+		if spec.Photo != "" && !filepath.IsAbs(spec.Photo) {
+			spec.Photo = filepath.Join(dir, spec.Photo)
+		}
+
+		var err error
+		if spec.Admins, err = parseSpecPhones(spec.Admins); err != nil {
+			return nil, fmt.Errorf("%s: admins: %w", spec.label(), err)
+		}
+		if spec.Members, err = parseSpecPhones(spec.Members); err != nil {
+			return nil, fmt.Errorf("%s: members: %w", spec.label(), err)
+		}
+	}
+
+	return file.Groups, nil
+}
+
+// parseSpecPhones validates the phone numbers of a group spec and normalizes them to digits
+func parseSpecPhones(values []string) ([]string, error) {
+	phones, err := parseParticipantPhones(values)
+	if err != nil {
+		return nil, err
+	}
+	for i, phone := range phones {
+		phones[i] = normalizePhoneNumber(phone)
+		if phones[i] == "" || strings.Trim(phones[i], "0123456789") != "" {
+			return nil, fmt.Errorf("invalid phone number %q", phone)
+		}
+	}
+	return phones, nil
+}
+
+// resolveSpecPhones replaces the phone numbers of the specs with the numbers WhatsApp knows them by, checking all
+// of them with a single lookup. The numbers written in a file can differ from those WhatsApp reports for the
+// group participants, so the plan would otherwise add current members again, or remove them with --prune.
+func resolveSpecPhones(specs []groupSpec, resolve func(phones []string) []resolvedRecipient) error {
+	var phones []string
+	for _, spec := range specs {
+		phones = append(phones, spec.Admins...)
+		phones = append(phones, spec.Members...)
+	}
+	if len(phones) == 0 {
+		return nil
+	}
+
+	resolved := make(map[string]string)
+	var failed []string
+	for _, recipient := range resolve(splitRecipients(phones)) {
+		if recipient.Err != nil {
+			failed = append(failed, recipient.Err.Error())
+			continue
+		}
+		resolved[recipient.Input] = recipient.JID.User
+	}
+	if len(failed) > 0 {
+		return errors.New(strings.Join(failed, "; "))
+	}
+
+	for i := range specs {
+		for _, list := range []*[]string{&specs[i].Admins, &specs[i].Members} {
+			for j, phone := range *list {
+				(*list)[j] = resolved[phone]
+			}
+			*list = splitRecipients(*list)
+		}
+	}
+	return nil
+}
+
+// findGroup finds the joined group a spec refers to
+func findGroup(groups []*types.GroupInfo, spec groupSpec) (*types.GroupInfo, error) {
+	var found *types.GroupInfo
+	for _, group := range groups {
+		if spec.ID != "" && group.JID.String() == spec.ID {
+			return group, nil
+		}
+		if spec.ID == "" && group.Name == *spec.Name {
+			if found != nil {
+				return nil, fmt.Errorf("%s: several groups have this name, add its id", spec.label())
+			}
+			found = group
+		}
+	}
+
+	if found == nil {
+		return nil, fmt.Errorf("%s: not found among your groups", spec.label())
+	}
+	return found, nil
+}
+
+// participantPhone returns the phone number of a participant, also in groups that address participants by LID
+func participantPhone(participant types.GroupParticipant) string {
+	if !participant.PhoneNumber.IsEmpty() {
+		return participant.PhoneNumber.User
+	}
+	return participant.JID.User
+}
+
+// groupPlan holds the changes that bring one group to its desired state.
+// Participants are identified by the phone number WhatsApp knows them by.
+type groupPlan struct {
+	Group    *types.GroupInfo
+	Settings []groupSettingChange
+	Add      []string
+	Promote  []string
+	Demote   []string
+	Remove   []string
+}
+
+// isEmpty reports whether the group is already in its desired state
+func (p groupPlan) isEmpty() bool {
+	return len(p.Settings) == 0 && len(p.Add) == 0 && len(p.Promote) == 0 && len(p.Demote) == 0 && len(p.Remove) == 0
+}
+
+// planGroup compares a group with its desired state. The own account is never demoted or removed.
+// The phone numbers of the spec must be resolved with resolveSpecPhones first.
+func planGroup(group *types.GroupInfo, spec groupSpec, photo []byte, own string, prune bool) groupPlan {
+	plan := groupPlan{Group: group, Settings: diffGroupSettings(group, spec.settings(), photo)}
+
+	current := make(map[string]types.GroupParticipant, len(group.Participants))
+	for _, participant := range group.Participants {
+		current[participantPhone(participant)] = participant
+	}
+
+	admins := make(map[string]bool, len(spec.Admins))
+	for _, phone := range spec.Admins {
+		admins[phone] = true
+	}
+
+	listed := make(map[string]bool)
+	for _, phone := range append(append([]string{}, spec.Admins...), spec.Members...) {
+		if listed[phone] {
+			continue
+		}
+		listed[phone] = true
+
+		participant, ok := current[phone]
+		if !ok {
+			plan.Add = append(plan.Add, phone)
+		}
+		if admins[phone] && (!ok || !participant.IsAdmin) {
+			plan.Promote = append(plan.Promote, phone)
+		}
+	}
+
+	for phone, participant := range current {
+		if phone == own {
+			continue
+		}
+		if prune && !listed[phone] {
+			plan.Remove = append(plan.Remove, phone)
+			continue
+		}
+		// Listed admins are the only admins, except the creator, who cannot lose its admin rights
+		if len(spec.Admins) > 0 && participant.IsAdmin && !participant.IsSuperAdmin && !admins[phone] {
+			plan.Demote = append(plan.Demote, phone)
+		}
+	}
+
+	sort.Strings(plan.Demote)
+	sort.Strings(plan.Remove)
+	return plan
+}
+
+// printGroupPlan prints the changes planned for a group
+func printGroupPlan(out io.Writer, plan groupPlan) {
+	fmt.Fprintf(out, "Group %q (%s):\n", plan.Group.Name, plan.Group.JID.String())
+	if plan.isEmpty() {
+		fmt.Fprintln(out, "  up to date")
+		return
+	}
+
+	printGroupSettingChanges(out, plan.Settings)
+	for _, step := range []struct {
+		symbol string
+		action string
+		phones []string
+	}{
+		{symbol: "+", action: "add", phones: plan.Add},
+		{symbol: "^", action: "promote", phones: plan.Promote},
+		{symbol: "v", action: "demote", phones: plan.Demote},
+		{symbol: "-", action: "remove", phones: plan.Remove},
+	} {
+		for _, phone := range step.phones {
+			fmt.Fprintf(out, "  %s %s +%s\n", step.symbol, step.action, phone)
+		}
+	}
+}
+
+// confirm asks a yes or no question on the terminal
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// applyGroupPlan applies the changes planned for a group and returns the number of changes that failed
+func applyGroupPlan(client *whatsmeow.Client, plan groupPlan) int {
+	group := plan.Group.JID
+	failed := applyGroupSettingChanges(client, group, plan.Settings)
+
+	jids := make(map[string]types.JID, len(plan.Group.Participants))
+	for _, participant := range plan.Group.Participants {
+		jids[participantPhone(participant)] = participant.JID
+	}
+
+	if len(plan.Add) > 0 {
+		// The phone numbers were resolved by resolveSpecPhones, so they are the users of the JIDs
+		var participants []resolvedRecipient
+		for _, phone := range plan.Add {
+			participants = append(participants, resolvedRecipient{Input: "+" + phone, JID: types.NewJID(phone, types.DefaultUserServer)})
+		}
+		added := applyParticipantChange(client, group, participants, whatsmeow.ParticipantChangeAdd, participantAdded)
+		for _, result := range added {
+			if result.Status != participantAdded {
+				failed++
+				continue
+			}
+			if jid, err := types.ParseJID(result.JID); err == nil {
+				jids[normalizePhoneNumber(result.Input)] = jid
+			}
+		}
+	}
+
+	for _, change := range []struct {
+		phones  []string
+		action  whatsmeow.ParticipantChange
+		success string
+	}{
+		{phones: plan.Promote, action: whatsmeow.ParticipantChangePromote, success: participantPromoted},
+		{phones: plan.Demote, action: whatsmeow.ParticipantChangeDemote, success: participantDemoted},
+		{phones: plan.Remove, action: whatsmeow.ParticipantChangeRemove, success: participantRemoved},
+	} {
+		var participants []resolvedRecipient
+		for _, phone := range change.phones {
+			jid, ok := jids[phone]
+			if !ok {
+				// Admins that could not be added cannot be promoted either
+				continue
+			}
+			participants = append(participants, resolvedRecipient{Input: "+" + phone, JID: jid})
+		}
+		if len(participants) == 0 {
+			continue
+		}
+
+		for _, result := range applyParticipantChange(client, group, participants, change.action, change.success) {
+			if result.Status != change.success {
+				failed++
+			}
+		}
+	}
+
+	return failed
+}
+
+// applyParticipantChange changes the participants of a group and prints the outcome for each
+func applyParticipantChange(client *whatsmeow.Client, group types.JID, participants []resolvedRecipient, action whatsmeow.ParticipantChange, success string) []participantResult {
+	var jids []types.JID
+	for _, participant := range participants {
+		if participant.Err == nil {
+			jids = append(jids, participant.JID)
+		}
+	}
+
+	var changed []types.GroupParticipant
+	if len(jids) > 0 {
+		var err error
+		changed, err = client.UpdateGroupParticipants(group, jids, action)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to %s participants: %v\n", action, err)
+		}
+	}
+
+	results := participantResults(success, participants, changed)
+	for _, result := range results {
+		if result.Status == success {
+			fmt.Printf("%s %s\n", strings.ToUpper(success[:1])+success[1:], result.Input)
+			continue
+		}
+
+		detail := result.Error
+		if result.Status == participantInviteRequired {
+			detail = "requires an invitation, use 'wavy groups add --send-invites'"
+		}
+		fmt.Fprintf(os.Stderr, "Error: could not %s %s: %s\n", action, result.Input, detail)
+	}
+	return results
+}
+
+func runGroupsApply(path string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+		os.Exit(1)
+	}
+
+	specs, err := parseGroupsFile(data, filepath.Dir(path))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid groups file %s: %v\n", path, err)
+		os.Exit(1)
+	}
+
+	photos := make([][]byte, len(specs))
+	for i, spec := range specs {
+		if photos[i], err = loadGroupPhoto(spec.settings()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s: %v\n", spec.label(), err)
+			os.Exit(1)
+		}
+	}
+
+	client := connectClient(debug)
+
+	err = resolveSpecPhones(specs, func(phones []string) []resolvedRecipient {
+		return resolveRecipients(client, phones)
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid groups file %s: %v\n", path, err)
+		os.Exit(1)
+	}
+
+	groups, err := client.GetJoinedGroups()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to get groups: %v\n", err)
+		os.Exit(1)
+	}
+
+	own := ""
+	if client.Store.ID != nil {
+		own = client.Store.ID.User
+	}
+
+	var plans []groupPlan
+	changes := false
+	for i, spec := range specs {
+		group, err := findGroup(groups, spec)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		plan := planGroup(group, spec, changedGroupPhoto(client, group.JID, photos[i]), own, groupApplyPrune)
+		printGroupPlan(os.Stdout, plan)
+		plans = append(plans, plan)
+		changes = changes || !plan.isEmpty()
+	}
+
+	if !changes {
+		fmt.Println("\nAll groups are up to date")
+		client.Disconnect()
+		return
+	}
+
+	fmt.Println()
+	if !groupApplyYes && !confirm("Apply these changes?") {
+		fmt.Println("No changes were made")
+		client.Disconnect()
+		return
+	}
+
+	failed := 0
+	for _, plan := range plans {
+		if plan.isEmpty() {
+			continue
+		}
+		fmt.Printf("\nUpdating %q...\n", plan.Group.Name)
+		failed += applyGroupPlan(client, plan)
+	}
+	client.Disconnect()
+	forgetCachedGroups()
+
+	if failed > 0 {
+		fmt.Fprintf(os.Stderr, "\n%d changes failed\n", failed)
+		os.Exit(1)
+	}
+	fmt.Println("\nAll changes applied")
+}
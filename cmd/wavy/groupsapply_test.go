@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+func TestParseGroupsFile(t *testing.T) {
+	data := []byte(`
+groups:
+  - id: 123456789@g.us
+    topic: Release planning
+    locked: true
+    photo: phoenix.png
+    admins: ["+15550100"]
+    members: ["+15550101", "15550102"]
+  - name: Book club
+`)
+
+	specs, err := parseGroupsFile(data, "/etc/wavy")
+	if err != nil {
+		t.Fatalf("parseGroupsFile returned error: %v", err)
+	}
+	if len(specs) != 2 {
+		t.Fatalf("Expected 2 groups, got %d", len(specs))
+	}
+
+	spec := specs[0]
+	if spec.Topic == nil || *spec.Topic != "Release planning" || spec.Locked == nil || !*spec.Locked || spec.Announce != nil {
+		t.Errorf("Unexpected settings %+v", spec)
+	}
+	if spec.Photo != filepath.Join("/etc/wavy", "phoenix.png") {
+		t.Errorf("Expected photo relative to the file, got %q", spec.Photo)
+	}
+	if !reflect.DeepEqual(spec.Members, []string{"15550101", "15550102"}) || !reflect.DeepEqual(spec.Admins, []string{"15550100"}) {
+		t.Errorf("Expected normalized phone numbers, got admins %v, members %v", spec.Admins, spec.Members)
+	}
+
+	invalid := map[string]string{
+		"unknown field": "groups:\n  - id: 123@g.us\n    owner: me\n",
+		"no id or name": "groups:\n  - topic: Hi\n",
+		"bad phone":     "groups:\n  - id: 123@g.us\n    members: [\"+1 call me\"]\n",
+		"no groups":     "groups: []\n",
+	}
+	for name, data := range invalid {
+		if _, err := parseGroupsFile([]byte(data), "."); err == nil {
+			t.Errorf("%s: expected error, got nil", name)
+		}
+	}
+}
+
+func TestPlanGroup(t *testing.T) {
+	user := func(phone string) types.JID { return types.NewJID(phone, types.DefaultUserServer) }
+	group := &types.GroupInfo{
+		JID:       types.NewJID("123456789", types.GroupServer),
+		GroupName: types.GroupName{Name: "Phoenix"},
+		Participants: []types.GroupParticipant{
+			{JID: user("15550000"), IsAdmin: true, IsSuperAdmin: true},
+			{JID: types.NewJID("42", types.HiddenUserServer), PhoneNumber: user("15550100")},
+			{JID: user("15550101"), IsAdmin: true},
+			{JID: user("15550103")},
+			{JID: user("15550104"), IsAdmin: true, IsSuperAdmin: true},
+		},
+	}
+	spec := groupSpec{ID: "123456789@g.us", Admins: []string{"15550100"}, Members: []string{"15550101", "15550102"}}
+
+	plan := planGroup(group, spec, nil, "15550000", false)
+	if !reflect.DeepEqual(plan.Add, []string{"15550102"}) {
+		t.Errorf("Add = %v", plan.Add)
+	}
+	if !reflect.DeepEqual(plan.Promote, []string{"15550100"}) {
+		t.Errorf("Promote = %v", plan.Promote)
+	}
+	// The own account and the creator keep their admin rights
+	if !reflect.DeepEqual(plan.Demote, []string{"15550101"}) {
+		t.Errorf("Demote = %v", plan.Demote)
+	}
+	if len(plan.Remove) != 0 {
+		t.Errorf("Expected no removals without prune, got %v", plan.Remove)
+	}
+
+	plan = planGroup(group, spec, nil, "15550000", true)
+	if !reflect.DeepEqual(plan.Remove, []string{"15550103", "15550104"}) {
+		t.Errorf("Remove = %v", plan.Remove)
+	}
+
+	var out bytes.Buffer
+	printGroupPlan(&out, plan)
+	for _, line := range []string{"+ add +15550102", "^ promote +15550100", "v demote +15550101", "- remove +15550103"} {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("Expected plan to contain %q, got:\n%s", line, out.String())
+		}
+	}
+
+	upToDate := planGroup(group, groupSpec{ID: "123456789@g.us", Members: []string{"15550100"}}, nil, "15550000", false)
+	if !upToDate.isEmpty() {
+		t.Errorf("Expected an empty plan, got %+v", upToDate)
+	}
+}
+
+func TestResolveSpecPhones(t *testing.T) {
+	specs := []groupSpec{
+		{ID: "1@g.us", Admins: []string{"5511987654321"}, Members: []string{"15550101"}},
+		{ID: "2@g.us", Members: []string{"15550101", "5511987654321"}},
+	}
+
+	calls := 0
+	resolve := func(phones []string) []resolvedRecipient {
+		calls++
+		results := make([]resolvedRecipient, len(phones))
+		for i, phone := range phones {
+			user := phone
+			// WhatsApp knows some Brazilian mobiles without the extra 9
+			if phone == "5511987654321" {
+				user = "551187654321"
+			}
+			results[i] = resolvedRecipient{Input: phone, JID: types.NewJID(user, types.DefaultUserServer)}
+		}
+		return results
+	}
+
+	if err := resolveSpecPhones(specs, resolve); err != nil {
+		t.Fatalf("resolveSpecPhones returned error: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected a single lookup, got %d", calls)
+	}
+	if !reflect.DeepEqual(specs[0].Admins, []string{"551187654321"}) || !reflect.DeepEqual(specs[1].Members, []string{"15550101", "551187654321"}) {
+		t.Errorf("Expected the numbers WhatsApp knows, got %+v", specs)
+	}
+
+	// A current member written with the extra 9 is neither added nor pruned
+	group := &types.GroupInfo{
+		JID:          types.NewJID("2", types.GroupServer),
+		Participants: []types.GroupParticipant{{JID: types.NewJID("551187654321", types.DefaultUserServer)}, {JID: types.NewJID("15550101", types.DefaultUserServer)}},
+	}
+	if plan := planGroup(group, specs[1], nil, "", true); !plan.isEmpty() {
+		t.Errorf("Expected an empty plan, got %+v", plan)
+	}
+
+	failing := func(phones []string) []resolvedRecipient {
+		return []resolvedRecipient{{Input: phones[0], Err: errors.New("phone number 15550199 not found on WhatsApp")}}
+	}
+	if err := resolveSpecPhones([]groupSpec{{Members: []string{"15550199"}}}, failing); err == nil {
+		t.Error("Expected an error for a number that is not on WhatsApp")
+	}
+}
+
+func TestFindGroup(t *testing.T) {
+	name := "Book club"
+	groups := []*types.GroupInfo{
+		{JID: types.NewJID("1", types.GroupServer), GroupName: types.GroupName{Name: "Book club"}},
+		{JID: types.NewJID("2", types.GroupServer), GroupName: types.GroupName{Name: "Phoenix"}},
+	}
+
+	if group, err := findGroup(groups, groupSpec{Name: &name}); err != nil || group.JID.User != "1" {
+		t.Errorf("findGroup by name = %v, %v", group, err)
+	}
+	if group, err := findGroup(groups, groupSpec{ID: "2@g.us"}); err != nil || group.Name != "Phoenix" {
+		t.Errorf("findGroup by id = %v, %v", group, err)
+	}
+	if _, err := findGroup(groups, groupSpec{ID: "3@g.us"}); err == nil {
+		t.Error("Expected error for unknown group")
+	}
+
+	groups = append(groups, &types.GroupInfo{JID: types.NewJID("3", types.GroupServer), GroupName: types.GroupName{Name: "Book club"}})
+	if _, err := findGroup(groups, groupSpec{Name: &name}); err == nil {
+		t.Error("Expected error for ambiguous name")
+	}
+}
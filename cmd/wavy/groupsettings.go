@@ -0,0 +1,396 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	"golang.org/x/image/draw"
+
+	"whatsmeow-go/cmd/wavy/storage"
+)
+
+// groupPhotoSize is the width and height of group photos
+const groupPhotoSize = 640
+
+var (
+	groupSetName         string
+	groupSetTopic        string
+	groupSetAnnounce     string
+	groupSetLocked       string
+	groupSetJoinApproval string
+	groupSetPhoto        string
+	groupSetDryRun       bool
+)
+
+var groupsSetCmd = &cobra.Command{
+	Use:   "set [group]",
+	Short: "Change the name, description and settings of a group",
+	Long: `Change the name, description and settings of a group. Only the given settings are changed.
+
+The differences between the current and the desired settings are printed before they are applied.
+Use --dry-run to only print them.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			cmd.Help()
+			os.Exit(1)
+		}
+
+		settings, err := groupSettingsFromFlags(cmd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if settings.isEmpty() {
+			cmd.Help()
+			os.Exit(1)
+		}
+
+		runGroupsSet(args[0], settings)
+	},
+}
+
+func init() {
+	groupsSetCmd.Flags().StringVar(&groupSetName, "name", "", "New group name")
+	groupsSetCmd.Flags().StringVar(&groupSetTopic, "topic", "", "New group description, empty to remove it")
+	groupsSetCmd.Flags().StringVar(&groupSetAnnounce, "announce", "", "Only admins can send messages: on or off")
+	groupsSetCmd.Flags().StringVar(&groupSetLocked, "locked", "", "Only admins can edit the group info: on or off")
+	groupsSetCmd.Flags().StringVar(&groupSetJoinApproval, "join-approval", "", "Admins must approve new members: on or off")
+	groupsSetCmd.Flags().StringVar(&groupSetPhoto, "photo", "", "JPEG or PNG image to use as group photo")
+	groupsSetCmd.Flags().BoolVar(&groupSetDryRun, "dry-run", false, "Show the changes without applying them")
+	groupsSetCmd.Flags().BoolVarP(&debug, "debug", "d", false, "Enable verbose debugging")
+	groupsCmd.AddCommand(groupsSetCmd)
+}
+
+// groupSettings is the desired state of a group. Nil fields are left unchanged.
+type groupSettings struct {
+	Name         *string
+	Topic        *string
+	Announce     *bool
+	Locked       *bool
+	JoinApproval *bool
+	// Photo is the path of the group photo
+	Photo string
+}
+
+// isEmpty reports whether no setting is given
+func (s groupSettings) isEmpty() bool {
+	return s.Name == nil && s.Topic == nil && s.Announce == nil && s.Locked == nil && s.JoinApproval == nil && s.Photo == ""
+}
+
+// validate checks the settings that WhatsApp would reject
+func (s groupSettings) validate() error {
+	if s.Name != nil {
+		if *s.Name == "" {
+			return fmt.Errorf("the group name cannot be empty")
+		}
+		if utf8.RuneCountInString(*s.Name) > maxGroupNameLength {
+			return fmt.Errorf("group names are limited to %d characters", maxGroupNameLength)
+		}
+	}
+	return nil
+}
+
+// groupSettingsFromFlags returns the settings given on the command line
+func groupSettingsFromFlags(cmd *cobra.Command) (groupSettings, error) {
+	var settings groupSettings
+	flags := cmd.Flags()
+
+	if flags.Changed("name") {
+		settings.Name = &groupSetName
+	}
+	if flags.Changed("topic") {
+		settings.Topic = &groupSetTopic
+	}
+
+	toggles := []struct {
+		flag  string
+		value string
+		field **bool
+	}{
+		{flag: "announce", value: groupSetAnnounce, field: &settings.Announce},
+		{flag: "locked", value: groupSetLocked, field: &settings.Locked},
+		{flag: "join-approval", value: groupSetJoinApproval, field: &settings.JoinApproval},
+	}
+	for _, toggle := range toggles {
+		if !flags.Changed(toggle.flag) {
+			continue
+		}
+		on, err := parseOnOff(toggle.value)
+		if err != nil {
+			return settings, fmt.Errorf("--%s: %w", toggle.flag, err)
+		}
+		*toggle.field = &on
+	}
+
+	settings.Photo = groupSetPhoto
+	return settings, settings.validate()
+}
+
+// parseOnOff parses an on or off switch
+func parseOnOff(value string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "on", "true", "yes":
+		return true, nil
+	case "off", "false", "no":
+		return false, nil
+	default:
+		return false, fmt.Errorf("invalid value %q, use on or off", value)
+	}
+}
+
+// formatOnOff formats a switch the way it is given on the command line
+func formatOnOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
+
+// groupSettingChange is a difference between the current and the desired state of a group
+type groupSettingChange struct {
+	Setting string
+	From    string
+	To      string
+	apply   func(client *whatsmeow.Client, group types.JID) error
+}
+
+// diffGroupSettings returns the changes needed to bring a group to the desired settings.
+// The photo is the prepared JPEG of the desired photo, or nil if the group already has it (see changedGroupPhoto).
+func diffGroupSettings(info *types.GroupInfo, desired groupSettings, photo []byte) []groupSettingChange {
+	var changes []groupSettingChange
+
+	if desired.Name != nil && *desired.Name != info.Name {
+		name := *desired.Name
+		changes = append(changes, groupSettingChange{
+			Setting: "name", From: fmt.Sprintf("%q", info.Name), To: fmt.Sprintf("%q", name),
+			apply: func(client *whatsmeow.Client, group types.JID) error {
+				return client.SetGroupName(group, name)
+			},
+		})
+	}
+
+	if desired.Topic != nil && *desired.Topic != info.Topic {
+		topic, previousID := *desired.Topic, info.TopicID
+		changes = append(changes, groupSettingChange{
+			Setting: "topic", From: fmt.Sprintf("%q", info.Topic), To: fmt.Sprintf("%q", topic),
+			apply: func(client *whatsmeow.Client, group types.JID) error {
+				return client.SetGroupTopic(group, previousID, "", topic)
+			},
+		})
+	}
+
+	toggles := []struct {
+		setting string
+		current bool
+		desired *bool
+		set     func(client *whatsmeow.Client, group types.JID, on bool) error
+	}{
+		{setting: "announce", current: info.IsAnnounce, desired: desired.Announce, set: (*whatsmeow.Client).SetGroupAnnounce},
+		{setting: "locked", current: info.IsLocked, desired: desired.Locked, set: (*whatsmeow.Client).SetGroupLocked},
+		{setting: "join-approval", current: info.IsJoinApprovalRequired, desired: desired.JoinApproval, set: (*whatsmeow.Client).SetGroupJoinApprovalMode},
+	}
+	for _, toggle := range toggles {
+		if toggle.desired == nil || *toggle.desired == toggle.current {
+			continue
+		}
+		on, set := *toggle.desired, toggle.set
+		changes = append(changes, groupSettingChange{
+			Setting: toggle.setting, From: formatOnOff(toggle.current), To: formatOnOff(on),
+			apply: func(client *whatsmeow.Client, group types.JID) error {
+				return set(client, group, on)
+			},
+		})
+	}
+
+	if photo != nil {
+		changes = append(changes, groupSettingChange{
+			Setting: "photo", From: "current photo", To: desired.Photo,
+			apply: func(client *whatsmeow.Client, group types.JID) error {
+				pictureID, err := client.SetGroupPhoto(group, photo)
+				if err != nil {
+					return err
+				}
+				recordGroupPhoto(group, photo, pictureID)
+				return nil
+			},
+		})
+	}
+
+	return changes
+}
+
+// printGroupSettingChanges prints the changes as a diff of the current and the desired settings
+func printGroupSettingChanges(out io.Writer, changes []groupSettingChange) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for _, change := range changes {
+		fmt.Fprintf(w, "  %s:\t%s\t->\t%s\n", change.Setting, change.From, change.To)
+	}
+	w.Flush()
+}
+
+// applyGroupSettingChanges applies the changes to a group and returns the number of changes that failed
+func applyGroupSettingChanges(client *whatsmeow.Client, group types.JID, changes []groupSettingChange) int {
+	failed := 0
+	for _, change := range changes {
+		if err := change.apply(client, group); err != nil {
+			fmt.Fprintf(os.Stderr, "Error changing %s: %v\n", change.Setting, err)
+			failed++
+			continue
+		}
+		fmt.Printf("Changed %s\n", change.Setting)
+	}
+	return failed
+}
+
+// prepareGroupPhoto crops an image to a square and scales it to the size WhatsApp uses for group photos
+func prepareGroupPhoto(data []byte) ([]byte, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode photo: %w", err)
+	}
+
+	// Crop the center square, as group photos are shown in a circle
+	bounds := img.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+	x := bounds.Min.X + (bounds.Dx()-side)/2
+	y := bounds.Min.Y + (bounds.Dy()-side)/2
+	square := image.Rect(x, y, x+side, y+side)
+
+	size := min(side, groupPhotoSize)
+	photo := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(photo, photo.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(photo, photo.Bounds(), img, square, draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, photo, &jpeg.Options{Quality: 90}); err != nil {
+		return nil, fmt.Errorf("failed to encode photo: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// loadGroupPhoto reads and prepares the photo of the settings, if any
+func loadGroupPhoto(settings groupSettings) ([]byte, error) {
+	if settings.Photo == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(settings.Photo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read photo: %w", err)
+	}
+	return prepareGroupPhoto(data)
+}
+
+// groupPhotoHash identifies a prepared group photo
+func groupPhotoHash(photo []byte) string {
+	sum := sha256.Sum256(photo)
+	return hex.EncodeToString(sum[:])
+}
+
+// isSameGroupPhoto reports whether a group still shows the photo wavy set last.
+// WhatsApp cannot return the photo for comparison, but its picture ID changes whenever the photo is replaced.
+func isSameGroupPhoto(recorded *storage.GroupPhoto, photo []byte, currentID string) bool {
+	return recorded != nil && currentID != "" && recorded.PictureID == currentID && recorded.PhotoHash == groupPhotoHash(photo)
+}
+
+// changedGroupPhoto returns the photo if it differs from the current photo of the group, or nil if the group
+// already has it. Photos that cannot be checked are treated as changed.
+func changedGroupPhoto(client *whatsmeow.Client, group types.JID, photo []byte) []byte {
+	if photo == nil {
+		return nil
+	}
+
+	db, err := loadStorage()
+	if err != nil {
+		return photo
+	}
+	defer db.Close()
+
+	recorded, err := db.GetGroupPhoto(group.String())
+	if err != nil {
+		return photo
+	}
+
+	info, err := client.GetProfilePictureInfo(group, &whatsmeow.GetProfilePictureParams{Preview: true})
+	if err != nil || info == nil {
+		return photo
+	}
+
+	if isSameGroupPhoto(recorded, photo, info.ID) {
+		return nil
+	}
+	return photo
+}
+
+// recordGroupPhoto remembers the photo set for a group, so it is not uploaded again while it is unchanged
+func recordGroupPhoto(group types.JID, photo []byte, pictureID string) {
+	db, err := loadStorage()
+	if err == nil {
+		defer db.Close()
+		err = db.SetGroupPhoto(storage.GroupPhoto{GroupJID: group.String(), PhotoHash: groupPhotoHash(photo), PictureID: pictureID, SetAt: time.Now()})
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
+
+func runGroupsSet(group string, settings groupSettings) {
+	photo, err := loadGroupPhoto(settings)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	client := connectClient(debug)
+
+	groupJID, err := resolveGroupJID(client, group)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	info, err := client.GetGroupInfo(groupJID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error getting group info: %v\n", err)
+		os.Exit(1)
+	}
+
+	changes := diffGroupSettings(info, settings, changedGroupPhoto(client, groupJID, photo))
+	if len(changes) == 0 {
+		fmt.Printf("Group %q is already up to date\n", info.Name)
+		client.Disconnect()
+		return
+	}
+
+	fmt.Printf("Changes to group %q:\n", info.Name)
+	printGroupSettingChanges(os.Stdout, changes)
+
+	if groupSetDryRun {
+		fmt.Println("\nDry run, no changes were made")
+		client.Disconnect()
+		return
+	}
+
+	fmt.Println()
+	failed := applyGroupSettingChanges(client, groupJID, changes)
+	client.Disconnect()
+	forgetCachedGroups()
+
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+
+	"go.mau.fi/whatsmeow/types"
+
+	"whatsmeow-go/cmd/wavy/storage"
+)
+
+func TestParseOnOff(t *testing.T) {
+	for value, want := range map[string]bool{"on": true, "ON": true, "yes": true, "off": false, "false": false} {
+		got, err := parseOnOff(value)
+		if err != nil || got != want {
+			t.Errorf("parseOnOff(%q) = %v, %v, want %v", value, got, err, want)
+		}
+	}
+
+	if _, err := parseOnOff("maybe"); err == nil {
+		t.Error("Expected error for invalid value")
+	}
+}
+
+func TestDiffGroupSettings(t *testing.T) {
+	info := &types.GroupInfo{
+		GroupName:     types.GroupName{Name: "Phoenix"},
+		GroupTopic:    types.GroupTopic{Topic: "Release planning"},
+		GroupLocked:   types.GroupLocked{IsLocked: true},
+		GroupAnnounce: types.GroupAnnounce{IsAnnounce: false},
+	}
+
+	name, topic := "Phoenix", "Release planning and QA"
+	on, off := true, false
+	desired := groupSettings{Name: &name, Topic: &topic, Announce: &on, Locked: &on, JoinApproval: &off}
+
+	changes := diffGroupSettings(info, desired, nil)
+	if len(changes) != 2 {
+		t.Fatalf("Expected 2 changes, got %+v", changes)
+	}
+	if changes[0].Setting != "topic" || changes[0].To != `"Release planning and QA"` {
+		t.Errorf("Unexpected topic change %+v", changes[0])
+	}
+	if changes[1].Setting != "announce" || changes[1].From != "off" || changes[1].To != "on" {
+		t.Errorf("Unexpected announce change %+v", changes[1])
+	}
+
+	// Photos cannot be compared, so they are always changed
+	changes = diffGroupSettings(info, groupSettings{Photo: "logo.png"}, []byte{1})
+	if len(changes) != 1 || changes[0].Setting != "photo" {
+		t.Errorf("Expected a photo change, got %+v", changes)
+	}
+
+	var out bytes.Buffer
+	printGroupSettingChanges(&out, diffGroupSettings(info, desired, nil))
+	if !strings.Contains(out.String(), "announce:") || !strings.Contains(out.String(), "->") {
+		t.Errorf("Unexpected diff output:\n%s", out.String())
+	}
+}
+
+func TestGroupSettingsValidate(t *testing.T) {
+	long := strings.Repeat("a", maxGroupNameLength+1)
+	if err := (groupSettings{Name: &long}).validate(); err == nil {
+		t.Error("Expected error for a long name")
+	}
+
+	empty := ""
+	if err := (groupSettings{Name: &empty}).validate(); err == nil {
+		t.Error("Expected error for an empty name")
+	}
+	if !(groupSettings{}).isEmpty() {
+		t.Error("Expected settings without values to be empty")
+	}
+}
+
+func TestPrepareGroupPhoto(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 1200, 800))); err != nil {
+		t.Fatal(err)
+	}
+
+	photo, err := prepareGroupPhoto(buf.Bytes())
+	if err != nil {
+		t.Fatalf("prepareGroupPhoto returned error: %v", err)
+	}
+
+	img, err := jpeg.Decode(bytes.NewReader(photo))
+	if err != nil {
+		t.Fatalf("Expected a JPEG photo: %v", err)
+	}
+	if img.Bounds().Dx() != groupPhotoSize || img.Bounds().Dy() != groupPhotoSize {
+		t.Errorf("Expected a %dx%d photo, got %v", groupPhotoSize, groupPhotoSize, img.Bounds())
+	}
+}
+
+func TestIsSameGroupPhoto(t *testing.T) {
+	photo := []byte("jpeg data")
+	recorded := &storage.GroupPhoto{GroupJID: "1@g.us", PhotoHash: groupPhotoHash(photo), PictureID: "1700000000"}
+
+	if !isSameGroupPhoto(recorded, photo, "1700000000") {
+		t.Error("Expected the photo wavy set last to be unchanged")
+	}
+	if isSameGroupPhoto(recorded, photo, "1700000999") {
+		t.Error("Expected a photo replaced since then to be changed")
+	}
+	if isSameGroupPhoto(recorded, []byte("other jpeg"), "1700000000") {
+		t.Error("Expected a different file to be changed")
+	}
+	if isSameGroupPhoto(nil, photo, "1700000000") {
+		t.Error("Expected a photo never set with wavy to be changed")
+	}
+}
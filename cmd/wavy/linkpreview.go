@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	//nolint:staticcheck // Using deprecated package for compatibility
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"google.golang.org/protobuf/proto"
+
+	"whatsmeow-go/cmd/wavy/preview"
+)
+
+// previewTimeout limits how long fetching a link preview may take
+const previewTimeout = 10 * time.Second
+
+// buildTextMessage builds a text message. If withPreview is set and the text contains a URL,
+// a link preview is added. The returned upload, if any, contains the preview thumbnail.
+func buildTextMessage(text string, withPreview bool) (*waProto.Message, *mediaUpload) {
+	plain := &waProto.Message{Conversation: proto.String(text)}
+	if !withPreview {
+		return plain, nil
+	}
+
+	link := preview.FindURL(text)
+	if link == "" {
+		return plain, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), previewTimeout)
+	defer cancel()
+
+	// A missing preview should never stop the message from being sent
+	linkPreview, err := preview.Fetch(ctx, http.DefaultClient, link)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not generate link preview: %v\n", err)
+		return plain, nil
+	}
+
+	return buildPreviewMessage(text, linkPreview)
+}
+
+// buildPreviewMessage builds an extended text message showing the link preview
+func buildPreviewMessage(text string, linkPreview *preview.Preview) (*waProto.Message, *mediaUpload) {
+	extended := &waProto.ExtendedTextMessage{
+		Text:          proto.String(text),
+		MatchedText:   proto.String(linkPreview.MatchedText),
+		Title:         proto.String(linkPreview.Title),
+		Description:   optionalString(linkPreview.Description),
+		JPEGThumbnail: linkPreview.Thumbnail,
+		PreviewType:   waProto.ExtendedTextMessage_NONE.Enum(),
+	}
+	message := &waProto.Message{ExtendedTextMessage: extended}
+
+	if len(linkPreview.Image) == 0 {
+		return message, nil
+	}
+
+	// The size describes the high quality thumbnail, which is only referenced once uploaded
+	extended.ThumbnailWidth = proto.Uint32(linkPreview.ImageWidth)
+	extended.ThumbnailHeight = proto.Uint32(linkPreview.ImageHeight)
+
+	upload := &mediaUpload{
+		Data:      linkPreview.Image,
+		MediaType: whatsmeow.MediaLinkThumbnail,
+		apply:     uploadTarget(message),
+	}
+	return message, upload
+}
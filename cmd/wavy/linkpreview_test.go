@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"go.mau.fi/whatsmeow"
+
+	"whatsmeow-go/cmd/wavy/preview"
+)
+
+func TestBuildTextMessageWithoutPreview(t *testing.T) {
+	message, upload := buildTextMessage("See https://example.com", false)
+	if message.GetConversation() != "See https://example.com" || upload != nil {
+		t.Errorf("Expected plain text message, got %v", message)
+	}
+
+	message, upload = buildTextMessage("No links here", true)
+	if message.GetConversation() != "No links here" || upload != nil {
+		t.Errorf("Expected plain text message, got %v", message)
+	}
+}
+
+func TestBuildPreviewMessage(t *testing.T) {
+	linkPreview := &preview.Preview{
+		MatchedText: "https://example.com",
+		Title:       "Example",
+		Thumbnail:   []byte{0xFF, 0xD8},
+	}
+
+	message, upload := buildPreviewMessage("See https://example.com", linkPreview)
+	extended := message.GetExtendedTextMessage()
+	if extended.GetMatchedText() != "https://example.com" || extended.GetTitle() != "Example" {
+		t.Errorf("Unexpected extended text message: %v", extended)
+	}
+	if extended.Description != nil {
+		t.Errorf("Expected empty description to be omitted, got %q", extended.GetDescription())
+	}
+	if upload != nil {
+		t.Error("Expected no upload without a preview image")
+	}
+
+	linkPreview.Image = []byte{0xFF, 0xD8}
+	linkPreview.ImageWidth, linkPreview.ImageHeight = 720, 360
+	_, upload = buildPreviewMessage("See https://example.com", linkPreview)
+	if upload == nil {
+		t.Fatal("Expected thumbnail upload for preview image")
+	}
+}
+
+func TestSkipLinkThumbnail(t *testing.T) {
+	linkPreview := &preview.Preview{
+		MatchedText: "https://example.com",
+		Title:       "Example",
+		Thumbnail:   []byte{0xFF, 0xD8},
+		Image:       []byte{0xFF, 0xD8},
+		ImageWidth:  720,
+		ImageHeight: 360,
+	}
+	message, upload := buildPreviewMessage("See https://example.com", linkPreview)
+
+	// A failed thumbnail upload still sends the message, with the inline thumbnail only
+	if err := skipLinkThumbnail(message, upload, errors.New("upload failed")); err != nil {
+		t.Fatalf("Expected the thumbnail to be skipped, got %v", err)
+	}
+	extended := message.GetExtendedTextMessage()
+	if extended.ThumbnailWidth != nil || extended.ThumbnailHeight != nil {
+		t.Errorf("Expected the large thumbnail to be dropped, got %v", extended)
+	}
+	if len(extended.GetJPEGThumbnail()) == 0 || extended.GetTitle() != "Example" {
+		t.Errorf("Expected the rest of the preview to be kept, got %v", extended)
+	}
+
+	// Other media cannot be sent without their upload
+	voiceNote := &mediaUpload{Data: []byte("ogg"), MediaType: whatsmeow.MediaAudio}
+	if err := skipLinkThumbnail(message, voiceNote, errors.New("upload failed")); err == nil {
+		t.Error("Expected the error of a failed media upload")
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	//nolint:staticcheck // Using deprecated package for compatibility
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// location is a point on the map with an optional label
+type location struct {
+	Latitude  float64
+	Longitude float64
+	Name      string
+	Address   string
+}
+
+// parseCoordinates parses a "lat,lng" pair and validates its ranges
+func parseCoordinates(value string) (float64, float64, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid location %q. Should be 'latitude,longitude'", value)
+	}
+
+	lat, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid latitude %q", parts[0])
+	}
+
+	lng, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid longitude %q", parts[1])
+	}
+
+	if err := validateCoordinates(lat, lng); err != nil {
+		return 0, 0, err
+	}
+	return lat, lng, nil
+}
+
+// validateCoordinates checks that latitude and longitude are within their valid ranges
+func validateCoordinates(lat, lng float64) error {
+	if math.IsNaN(lat) || lat < -90 || lat > 90 {
+		return fmt.Errorf("latitude %v out of range (-90 to 90)", lat)
+	}
+	if math.IsNaN(lng) || lng < -180 || lng > 180 {
+		return fmt.Errorf("longitude %v out of range (-180 to 180)", lng)
+	}
+	return nil
+}
+
+// geoJSONObject covers the parts of a GeoJSON Point geometry or Point Feature that wavy uses
+type geoJSONObject struct {
+	Type        string          `json:"type"`
+	Coordinates []float64       `json:"coordinates"`
+	Geometry    *geoJSONObject  `json:"geometry"`
+	Properties  json.RawMessage `json:"properties"`
+}
+
+// loadGeoJSONPoint reads a location from a GeoJSON file containing a Point geometry or a Feature with a Point.
+// The "name" and "address" properties of a Feature are used as labels.
+func loadGeoJSONPoint(path string) (*location, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read GeoJSON file: %w", err)
+	}
+	return parseGeoJSONPoint(data)
+}
+
+// parseGeoJSONPoint parses a GeoJSON Point geometry or a Feature with a Point
+func parseGeoJSONPoint(data []byte) (*location, error) {
+	var obj geoJSONObject
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, fmt.Errorf("invalid GeoJSON: %w", err)
+	}
+
+	loc := &location{}
+	point := &obj
+	if obj.Type == "Feature" {
+		if obj.Geometry == nil {
+			return nil, errors.New("GeoJSON feature has no geometry")
+		}
+		point = obj.Geometry
+
+		var props struct {
+			Name    string `json:"name"`
+			Address string `json:"address"`
+		}
+		if len(obj.Properties) > 0 && string(obj.Properties) != "null" {
+			if err := json.Unmarshal(obj.Properties, &props); err != nil {
+				return nil, fmt.Errorf("invalid GeoJSON properties: %w", err)
+			}
+		}
+		loc.Name = props.Name
+		loc.Address = props.Address
+	}
+
+	if point.Type != "Point" {
+		return nil, fmt.Errorf("unsupported GeoJSON type %q, expected a Point", point.Type)
+	}
+
+	// GeoJSON positions are longitude first
+	if len(point.Coordinates) < 2 {
+		return nil, errors.New("GeoJSON point needs longitude and latitude coordinates")
+	}
+	loc.Longitude = point.Coordinates[0]
+	loc.Latitude = point.Coordinates[1]
+
+	if err := validateCoordinates(loc.Latitude, loc.Longitude); err != nil {
+		return nil, err
+	}
+	return loc, nil
+}
+
+// buildLocationMessage builds a location message, or a live location snapshot if live is set.
+// The comment is shown below a regular location.
+func buildLocationMessage(loc *location, comment string, live bool) *waProto.Message {
+	if live {
+		caption := loc.Name
+		if comment != "" {
+			caption = comment
+		}
+		return &waProto.Message{
+			LiveLocationMessage: &waProto.LiveLocationMessage{
+				DegreesLatitude:  proto.Float64(loc.Latitude),
+				DegreesLongitude: proto.Float64(loc.Longitude),
+				Caption:          optionalString(caption),
+				SequenceNumber:   proto.Int64(1),
+			},
+		}
+	}
+
+	return &waProto.Message{
+		LocationMessage: &waProto.LocationMessage{
+			DegreesLatitude:  proto.Float64(loc.Latitude),
+			DegreesLongitude: proto.Float64(loc.Longitude),
+			Name:             optionalString(loc.Name),
+			Address:          optionalString(loc.Address),
+			Comment:          optionalString(comment),
+		},
+	}
+}
+
+// optionalString returns nil for empty strings so unset protobuf fields are omitted
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return proto.String(value)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestParseCoordinates(t *testing.T) {
+	tests := []struct {
+		input   string
+		lat     float64
+		lng     float64
+		wantErr bool
+	}{
+		{input: "-23.5505,-46.6333", lat: -23.5505, lng: -46.6333},
+		{input: " 40.7128 , -74.0060 ", lat: 40.7128, lng: -74.0060},
+		{input: "90,180", lat: 90, lng: 180},
+		{input: "91,0", wantErr: true},
+		{input: "0,-181", wantErr: true},
+		{input: "40.7128", wantErr: true},
+		{input: "abc,1", wantErr: true},
+		{input: "NaN,1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		lat, lng, err := parseCoordinates(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseCoordinates(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && (lat != tt.lat || lng != tt.lng) {
+			t.Errorf("parseCoordinates(%q) = %v,%v, want %v,%v", tt.input, lat, lng, tt.lat, tt.lng)
+		}
+	}
+}
+
+func TestParseGeoJSONPoint(t *testing.T) {
+	t.Run("Point geometry", func(t *testing.T) {
+		loc, err := parseGeoJSONPoint([]byte(`{"type": "Point", "coordinates": [-46.6333, -23.5505]}`))
+		if err != nil {
+			t.Fatalf("parseGeoJSONPoint returned error: %v", err)
+		}
+		if loc.Latitude != -23.5505 || loc.Longitude != -46.6333 {
+			t.Errorf("Expected -23.5505,-46.6333, got %v,%v", loc.Latitude, loc.Longitude)
+		}
+	})
+
+	t.Run("Feature with properties", func(t *testing.T) {
+		data := `{"type": "Feature", "geometry": {"type": "Point", "coordinates": [2.2945, 48.8584]},
+			"properties": {"name": "Site 12", "address": "Champ de Mars"}}`
+		loc, err := parseGeoJSONPoint([]byte(data))
+		if err != nil {
+			t.Fatalf("parseGeoJSONPoint returned error: %v", err)
+		}
+		if loc.Name != "Site 12" || loc.Address != "Champ de Mars" || loc.Latitude != 48.8584 {
+			t.Errorf("Unexpected location: %+v", loc)
+		}
+	})
+
+	invalid := map[string]string{
+		"Not a point":       `{"type": "LineString", "coordinates": [[0, 0], [1, 1]]}`,
+		"Missing geometry":  `{"type": "Feature", "properties": {}}`,
+		"Out of range":      `{"type": "Point", "coordinates": [0, 95]}`,
+		"Short coordinates": `{"type": "Point", "coordinates": [0]}`,
+		"Not JSON":          `not json`,
+	}
+	for name, data := range invalid {
+		t.Run(name, func(t *testing.T) {
+			if _, err := parseGeoJSONPoint([]byte(data)); err == nil {
+				t.Errorf("Expected error for %s", name)
+			}
+		})
+	}
+}
+
+func TestBuildLocationMessage(t *testing.T) {
+	loc := &location{Latitude: 1.5, Longitude: 2.5, Name: "Site 12"}
+
+	message := buildLocationMessage(loc, "", false)
+	if message.GetLocationMessage().GetName() != "Site 12" || message.GetLocationMessage().Address != nil {
+		t.Errorf("Unexpected location message: %v", message.GetLocationMessage())
+	}
+
+	live := buildLocationMessage(loc, "On my way", true)
+	if live.GetLiveLocationMessage().GetCaption() != "On my way" || live.GetLiveLocationMessage().GetDegreesLatitude() != 1.5 {
+		t.Errorf("Unexpected live location message: %v", live.GetLiveLocationMessage())
+	}
+}
@@ -35,10 +35,23 @@ func main() {
 }
 
 func init() {
+	rootCmd.PersistentFlags().BoolVar(&ignoreRateLimit, "ignore-rate-limit", false, "Send without waiting for the rate limiter")
+
 	// Add subcommands
 	rootCmd.AddCommand(setupCmd)
 	rootCmd.AddCommand(sendCmd)
 	rootCmd.AddCommand(checkCmd)
 	rootCmd.AddCommand(groupsCmd)
+	rootCmd.AddCommand(reactCmd)
+	rootCmd.AddCommand(editCmd)
+	rootCmd.AddCommand(deleteCmd)
+	rootCmd.AddCommand(pollCmd)
+	rootCmd.AddCommand(chatCmd)
+	rootCmd.AddCommand(scheduleCmd)
+	rootCmd.AddCommand(cronCmd)
+	rootCmd.AddCommand(outboxCmd)
+	rootCmd.AddCommand(broadcastCmd)
+	rootCmd.AddCommand(statusCmd)
+	rootCmd.AddCommand(aliasCmd)
 	rootCmd.AddCommand(versionCmd)
 }
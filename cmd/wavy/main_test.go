@@ -43,8 +43,53 @@ func TestCommandRegistration(t *testing.T) {
 		t.Errorf("Expected groupsCmd.Use to start with 'groups', got %q", groupsCmd.Use)
 	}
 
+	if !strings.HasPrefix(reactCmd.Use, "react") {
+		t.Errorf("Expected reactCmd.Use to start with 'react', got %q", reactCmd.Use)
+	}
+
+	if !strings.HasPrefix(editCmd.Use, "edit") {
+		t.Errorf("Expected editCmd.Use to start with 'edit', got %q", editCmd.Use)
+	}
+
+	if !strings.HasPrefix(deleteCmd.Use, "delete") {
+		t.Errorf("Expected deleteCmd.Use to start with 'delete', got %q", deleteCmd.Use)
+	}
+
+	if !strings.HasPrefix(pollCmd.Use, "poll") {
+		t.Errorf("Expected pollCmd.Use to start with 'poll', got %q", pollCmd.Use)
+	}
+
+	if !strings.HasPrefix(chatCmd.Use, "chat") {
+		t.Errorf("Expected chatCmd.Use to start with 'chat', got %q", chatCmd.Use)
+	}
+
+	if !strings.HasPrefix(scheduleCmd.Use, "schedule") {
+		t.Errorf("Expected scheduleCmd.Use to start with 'schedule', got %q", scheduleCmd.Use)
+	}
+
+	if !strings.HasPrefix(cronCmd.Use, "cron") {
+		t.Errorf("Expected cronCmd.Use to start with 'cron', got %q", cronCmd.Use)
+	}
+
+	if !strings.HasPrefix(outboxCmd.Use, "outbox") {
+		t.Errorf("Expected outboxCmd.Use to start with 'outbox', got %q", outboxCmd.Use)
+	}
+	if !strings.HasPrefix(broadcastCmd.Use, "broadcast") {
+		t.Errorf("Expected broadcastCmd.Use to start with 'broadcast', got %q", broadcastCmd.Use)
+	}
+	if !strings.HasPrefix(statusCmd.Use, "status") {
+		t.Errorf("Expected statusCmd.Use to start with 'status', got %q", statusCmd.Use)
+	}
+	if !strings.HasPrefix(aliasCmd.Use, "alias") {
+		t.Errorf("Expected aliasCmd.Use to start with 'alias', got %q", aliasCmd.Use)
+	}
+
+	if rootCmd.PersistentFlags().Lookup("ignore-rate-limit") == nil {
+		t.Error("Expected the --ignore-rate-limit flag to be available to all commands")
+	}
+
 	// Verify each command has a meaningful description
-	for _, cmd := range []*cobra.Command{setupCmd, sendCmd, checkCmd, groupsCmd} {
+	for _, cmd := range []*cobra.Command{setupCmd, sendCmd, checkCmd, groupsCmd, reactCmd, editCmd, deleteCmd, pollCmd, chatCmd, scheduleCmd, cronCmd, outboxCmd, broadcastCmd, statusCmd, aliasCmd} {
 		if cmd.Short == "" {
 			t.Errorf("Command %q is missing a Short description", cmd.Use)
 		}
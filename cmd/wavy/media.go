@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	//nolint:staticcheck // Using deprecated package for compatibility
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// mediaUpload is a file that must be uploaded to WhatsApp before its message can be sent
+type mediaUpload struct {
+	Data      []byte
+	MediaType whatsmeow.MediaType
+	// apply copies the upload details into the message
+	apply func(resp whatsmeow.UploadResponse)
+}
+
+// upload uploads the file and fills in the media fields of its message
+func (m *mediaUpload) upload(ctx context.Context, client *whatsmeow.Client) error {
+	resp, err := client.Upload(ctx, m.Data, m.MediaType)
+	if err != nil {
+		return fmt.Errorf("failed to upload media: %w", err)
+	}
+	m.apply(resp)
+	return nil
+}
+
+// uploadMedia uploads the media of a message. A link preview whose large thumbnail fails to upload
+// is sent without it, since a missing preview should never stop the message from being sent.
+func uploadMedia(client *whatsmeow.Client, message *waProto.Message, upload *mediaUpload) error {
+	return skipLinkThumbnail(message, upload, upload.upload(context.Background(), client))
+}
+
+// skipLinkThumbnail turns a failed upload of a link preview thumbnail into a warning and removes the
+// thumbnail from the message. The preview keeps its small inline thumbnail. Other errors are returned.
+func skipLinkThumbnail(message *waProto.Message, upload *mediaUpload, err error) error {
+	if err == nil || upload.MediaType != whatsmeow.MediaLinkThumbnail {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Warning: sending link preview without its large thumbnail: %v\n", err)
+	if extended := message.GetExtendedTextMessage(); extended != nil {
+		extended.ThumbnailWidth = nil
+		extended.ThumbnailHeight = nil
+	}
+	return nil
+}
+
+// uploadTarget returns a function that copies upload details into the media of a message,
+// looking inside view-once wrappers. It returns nil if the message has no uploadable media.
+func uploadTarget(message *waProto.Message) func(resp whatsmeow.UploadResponse) {
+	if inner := message.GetViewOnceMessageV2().GetMessage(); inner != nil {
+		return uploadTarget(inner)
+	}
+	if inner := message.GetViewOnceMessageV2Extension().GetMessage(); inner != nil {
+		return uploadTarget(inner)
+	}
+
+	switch {
+	case message.StickerMessage != nil:
+		sticker := message.StickerMessage
+		return func(resp whatsmeow.UploadResponse) {
+			sticker.URL = proto.String(resp.URL)
+			sticker.DirectPath = proto.String(resp.DirectPath)
+			sticker.MediaKey = resp.MediaKey
+			sticker.FileEncSHA256 = resp.FileEncSHA256
+			sticker.FileSHA256 = resp.FileSHA256
+			sticker.FileLength = proto.Uint64(resp.FileLength)
+		}
+	case message.ImageMessage != nil:
+		img := message.ImageMessage
+		return func(resp whatsmeow.UploadResponse) {
+			img.URL = proto.String(resp.URL)
+			img.DirectPath = proto.String(resp.DirectPath)
+			img.MediaKey = resp.MediaKey
+			img.FileEncSHA256 = resp.FileEncSHA256
+			img.FileSHA256 = resp.FileSHA256
+			img.FileLength = proto.Uint64(resp.FileLength)
+		}
+	case message.VideoMessage != nil:
+		video := message.VideoMessage
+		return func(resp whatsmeow.UploadResponse) {
+			video.URL = proto.String(resp.URL)
+			video.DirectPath = proto.String(resp.DirectPath)
+			video.MediaKey = resp.MediaKey
+			video.FileEncSHA256 = resp.FileEncSHA256
+			video.FileSHA256 = resp.FileSHA256
+			video.FileLength = proto.Uint64(resp.FileLength)
+		}
+	case message.AudioMessage != nil:
+		audio := message.AudioMessage
+		return func(resp whatsmeow.UploadResponse) {
+			audio.URL = proto.String(resp.URL)
+			audio.DirectPath = proto.String(resp.DirectPath)
+			audio.MediaKey = resp.MediaKey
+			audio.FileEncSHA256 = resp.FileEncSHA256
+			audio.FileSHA256 = resp.FileSHA256
+			audio.FileLength = proto.Uint64(resp.FileLength)
+		}
+	case message.ExtendedTextMessage != nil:
+		// Link preview thumbnail
+		extended := message.ExtendedTextMessage
+		return func(resp whatsmeow.UploadResponse) {
+			extended.ThumbnailDirectPath = proto.String(resp.DirectPath)
+			extended.ThumbnailSHA256 = resp.FileSHA256
+			extended.ThumbnailEncSHA256 = resp.FileEncSHA256
+			extended.MediaKey = resp.MediaKey
+			extended.MediaKeyTimestamp = proto.Int64(time.Now().Unix())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,410 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.mau.fi/whatsmeow"
+	//nolint:staticcheck // Using deprecated package for compatibility
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"google.golang.org/protobuf/proto"
+
+	"whatsmeow-go/cmd/wavy/common"
+	"whatsmeow-go/cmd/wavy/storage"
+)
+
+const (
+	// outboxBaseDelay is the wait before the first retry, doubled after every failed attempt
+	outboxBaseDelay = 30 * time.Second
+	// outboxMaxDelay caps the wait between attempts
+	outboxMaxDelay = time.Hour
+)
+
+var (
+	outboxStatus      string
+	outboxMaxAttempts int
+	outboxInterval    time.Duration
+	outboxRetryAll    bool
+	outboxOlderThan   string
+)
+
+var outboxCmd = &cobra.Command{
+	Use:   "outbox",
+	Short: "Manage and deliver queued messages",
+	Long: `Messages sent with 'wavy send --enqueue' are stored in an outbox in the data directory.
+They are delivered by 'wavy outbox flush' or 'wavy outbox watch', which retry failed
+attempts with exponential backoff.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var outboxFlushCmd = &cobra.Command{
+	Use:   "flush",
+	Short: "Deliver the queued messages that are due and exit",
+	Run: func(cmd *cobra.Command, args []string) {
+		runOutboxFlush()
+	},
+}
+
+var outboxWatchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Keep delivering queued messages until stopped",
+	Run: func(cmd *cobra.Command, args []string) {
+		runOutboxWatch()
+	},
+}
+
+var outboxListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List queued messages",
+	Run: func(cmd *cobra.Command, args []string) {
+		runOutboxList()
+	},
+}
+
+var outboxRetryCmd = &cobra.Command{
+	Use:   "retry [id]",
+	Short: "Queue a dead message for delivery again",
+	Long:  `Queue a dead message, or all dead messages with --all, for immediate delivery with a fresh set of attempts.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 && !outboxRetryAll {
+			cmd.Help()
+			os.Exit(1)
+		}
+
+		id := ""
+		if len(args) > 0 {
+			id = args[0]
+		}
+		runOutboxRetry(id)
+	},
+}
+
+var outboxPurgeCmd = &cobra.Command{
+	Use:   "purge",
+	Short: "Delete delivered or dead messages",
+	Run: func(cmd *cobra.Command, args []string) {
+		runOutboxPurge()
+	},
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{outboxFlushCmd, outboxWatchCmd} {
+		cmd.Flags().IntVar(&outboxMaxAttempts, "max-attempts", 8, "Attempts before a message is marked dead")
+		cmd.Flags().BoolVarP(&debug, "debug", "d", false, "Enable verbose debugging")
+		cmd.Flags().IntVarP(&wait, "wait", "w", 5, "Seconds to wait for message confirmation")
+	}
+	outboxWatchCmd.Flags().DurationVar(&outboxInterval, "interval", 30*time.Second, "How often to check for due messages")
+
+	outboxListCmd.Flags().StringVarP(&outboxStatus, "status", "s", "", "Only list messages with this status (pending, sending, sent, dead)")
+
+	outboxRetryCmd.Flags().BoolVarP(&outboxRetryAll, "all", "a", false, "Retry all dead messages")
+
+	outboxPurgeCmd.Flags().StringVarP(&outboxStatus, "status", "s", storage.StatusSent, "Messages to delete: sent, dead or all")
+	outboxPurgeCmd.Flags().StringVar(&outboxOlderThan, "older-than", "0s", "Only delete messages that finished longer ago, such as 7d")
+
+	outboxCmd.AddCommand(outboxFlushCmd)
+	outboxCmd.AddCommand(outboxWatchCmd)
+	outboxCmd.AddCommand(outboxListCmd)
+	outboxCmd.AddCommand(outboxRetryCmd)
+	outboxCmd.AddCommand(outboxPurgeCmd)
+}
+
+// enqueueMessage stores a message in the outbox instead of sending it
+func enqueueMessage(db *storage.DB, to string, message *waProto.Message, upload *mediaUpload, key string) (int64, bool, error) {
+	data, err := proto.Marshal(message)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to encode message: %w", err)
+	}
+
+	entry := storage.OutboxMessage{
+		Recipient:      to,
+		Message:        data,
+		IdempotencyKey: key,
+		CreatedAt:      time.Now(),
+	}
+	if upload != nil {
+		entry.Media = upload.Data
+		entry.MediaType = string(upload.MediaType)
+	}
+
+	return db.EnqueueOutboxMessage(entry)
+}
+
+// decodeOutboxMessage restores a queued message and the upload of its media
+func decodeOutboxMessage(entry storage.OutboxMessage) (*waProto.Message, *mediaUpload, error) {
+	message := &waProto.Message{}
+	if err := proto.Unmarshal(entry.Message, message); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode message: %w", err)
+	}
+
+	if len(entry.Media) == 0 {
+		return message, nil, nil
+	}
+
+	apply := uploadTarget(message)
+	if apply == nil {
+		return nil, nil, errors.New("queued media does not match the message")
+	}
+	return message, &mediaUpload{
+		Data:      entry.Media,
+		MediaType: whatsmeow.MediaType(entry.MediaType),
+		apply:     apply,
+	}, nil
+}
+
+// outboxBackoff returns the wait before the next attempt after the given number of failed attempts
+func outboxBackoff(attempts int) time.Duration {
+	delay := outboxBaseDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= outboxMaxDelay {
+			return outboxMaxDelay
+		}
+	}
+	return delay
+}
+
+func runOutboxFlush() {
+	db := openStorage()
+	defer db.Close()
+
+	sent, failed, err := flushOutbox(db, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Delivered %d message(s), %d failed\n", sent, failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+func runOutboxWatch() {
+	db := openStorage()
+	defer db.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Printf("Delivering queued messages every %s. Press Ctrl+C to stop.\n", outboxInterval)
+	for {
+		if _, _, err := flushOutbox(db, time.Now()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			fmt.Println("\nStopping outbox worker...")
+			return
+		case <-time.After(outboxInterval):
+		}
+	}
+}
+
+// flushOutbox delivers the due messages, connecting to WhatsApp only if there are any.
+// It returns the number of messages delivered and the number of failed attempts.
+func flushOutbox(db *storage.DB, now time.Time) (sent, failed int, err error) {
+	config, err := common.LoadConfig()
+	if err != nil {
+		return 0, 0, err
+	}
+	if _, err := db.RequeueStaleOutboxMessages(now.Add(-claimTimeout(config.RateLimit))); err != nil {
+		return 0, 0, err
+	}
+
+	due, err := db.DueOutboxMessages(now)
+	if err != nil || len(due) == 0 {
+		return 0, 0, err
+	}
+
+	// An unreachable WhatsApp counts as a failed attempt for every due message
+	client, connErr := openClient(debug)
+	if connErr == nil {
+		defer client.Disconnect()
+	}
+
+	for _, entry := range due {
+		// Another worker may have sent it in the meantime
+		if err := db.ClaimOutboxMessage(entry.ID); err != nil {
+			continue
+		}
+
+		messageID, permanent, err := deliverOutboxMessage(client, connErr, entry)
+		if err == nil {
+			sent++
+			fmt.Printf("Delivered queued message %d to %s (ID: %s)\n", entry.ID, entry.Recipient, messageID)
+			if err := db.CompleteOutboxMessage(entry.ID, messageID); err != nil {
+				return sent, failed, err
+			}
+			continue
+		}
+
+		failed++
+		attempts := entry.Attempts + 1
+		dead := permanent || attempts >= outboxMaxAttempts
+		next := time.Now().Add(outboxBackoff(attempts))
+		if dead {
+			fmt.Fprintf(os.Stderr, "Queued message %d is dead after %d attempt(s): %v\n", entry.ID, attempts, err)
+		} else {
+			fmt.Fprintf(os.Stderr, "Failed to deliver queued message %d (attempt %d), retrying at %s: %v\n",
+				entry.ID, attempts, next.Format(time.Kitchen), err)
+		}
+		if err := db.FailOutboxMessage(entry.ID, err.Error(), next, dead); err != nil {
+			return sent, failed, err
+		}
+	}
+
+	return sent, failed, nil
+}
+
+// deliverOutboxMessage sends a queued message. Errors that retrying cannot fix are reported as permanent.
+func deliverOutboxMessage(client *whatsmeow.Client, connErr error, entry storage.OutboxMessage) (messageID string, permanent bool, err error) {
+	message, upload, err := decodeOutboxMessage(entry)
+	if err != nil {
+		return "", true, err
+	}
+
+	if connErr != nil {
+		return "", false, connErr
+	}
+
+	// Aliases were expanded when the message was queued, so they are not looked up again.
+	// Only recipients that can never be resolved are permanent, lookups may fail during an outage.
+	resolved := resolveRecipients(client, []string{entry.Recipient})[0]
+	if resolved.Err != nil {
+		return "", isInvalidRecipient(resolved.Err), resolved.Err
+	}
+
+	resp, err := deliverMessage(client, resolved.JID, message, upload)
+	if err != nil {
+		return "", false, err
+	}
+	return resp.ID, false, nil
+}
+
+// describeMessage returns a short summary of a message for listings
+func describeMessage(message *waProto.Message) string {
+	if inner := message.GetViewOnceMessageV2().GetMessage(); inner != nil {
+		return describeMessage(inner) + " (view once)"
+	}
+	if inner := message.GetViewOnceMessageV2Extension().GetMessage(); inner != nil {
+		return describeMessage(inner) + " (view once)"
+	}
+
+	switch {
+	case message.Conversation != nil:
+		return message.GetConversation()
+	case message.ExtendedTextMessage != nil:
+		return message.GetExtendedTextMessage().GetText()
+	case message.LocationMessage != nil, message.LiveLocationMessage != nil:
+		return "[location]"
+	case message.ContactMessage != nil, message.ContactsArrayMessage != nil:
+		return "[contact]"
+	case message.StickerMessage != nil:
+		return "[sticker]"
+	case message.AudioMessage != nil:
+		return "[voice note]"
+	}
+	return "[message]"
+}
+
+func runOutboxList() {
+	db := openStorage()
+	defer db.Close()
+
+	messages, err := db.ListOutboxMessages(outboxStatus)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(messages) == 0 {
+		fmt.Println("The outbox is empty")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSTATUS\tTO\tATTEMPTS\tNEXT ATTEMPT\tKEY\tMESSAGE\tERROR")
+	for _, entry := range messages {
+		content := "[unreadable]"
+		message := &waProto.Message{}
+		if proto.Unmarshal(entry.Message, message) == nil {
+			content = describeMessage(message)
+		}
+
+		next := "-"
+		if entry.Status == storage.StatusPending {
+			next = entry.NextAttemptAt.Format("2006-01-02 15:04:05")
+		}
+
+		fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%s\t%s\t%s\t%s\n", entry.ID, entry.Status, entry.Recipient, entry.Attempts,
+			next, entry.IdempotencyKey, truncate(content, 30), truncate(entry.Error, 40))
+	}
+	w.Flush()
+}
+
+func runOutboxRetry(value string) {
+	var id int64
+	if value != "" {
+		var err error
+		if id, err = strconv.ParseInt(value, 10, 64); err != nil || id <= 0 {
+			fmt.Fprintf(os.Stderr, "Error: invalid ID %q\n", value)
+			os.Exit(1)
+		}
+	}
+
+	db := openStorage()
+	defer db.Close()
+
+	n, err := db.RetryOutboxMessage(id)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if id != 0 && n == 0 {
+		fmt.Fprintf(os.Stderr, "No dead or pending message with ID %d\n", id)
+		os.Exit(1)
+	}
+	fmt.Printf("%d message(s) queued for delivery\n", n)
+}
+
+func runOutboxPurge() {
+	var statuses []string
+	switch outboxStatus {
+	case storage.StatusSent, storage.StatusDead:
+		statuses = []string{outboxStatus}
+	case "all":
+		statuses = []string{storage.StatusSent, storage.StatusDead}
+	default:
+		fmt.Fprintf(os.Stderr, "Error: --status must be sent, dead or all\n")
+		os.Exit(1)
+	}
+
+	age, err := parseDuration(outboxOlderThan)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	db := openStorage()
+	defer db.Close()
+
+	n, err := db.PurgeOutboxMessages(statuses, time.Now().Add(-age))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Deleted %d message(s)\n", n)
+}
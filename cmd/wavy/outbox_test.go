@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	//nolint:staticcheck // Using deprecated package for compatibility
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"google.golang.org/protobuf/proto"
+
+	"whatsmeow-go/cmd/wavy/storage"
+)
+
+func TestOutboxBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{7, 32 * time.Minute},
+		{8, time.Hour},
+		{20, time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := outboxBackoff(tt.attempts); got != tt.want {
+			t.Errorf("outboxBackoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestEnqueueAndDecodeOutboxMessage(t *testing.T) {
+	db, err := storage.Open(filepath.Join(t.TempDir(), "wavy.db"))
+	if err != nil {
+		t.Fatalf("Open() failed: %v", err)
+	}
+	defer db.Close()
+
+	// A view-once voice note, so the upload must be restored inside the wrapper
+	message, err := applyViewOnce(&waProto.Message{AudioMessage: &waProto.AudioMessage{PTT: proto.Bool(true)}})
+	if err != nil {
+		t.Fatalf("applyViewOnce() failed: %v", err)
+	}
+	upload := &mediaUpload{Data: []byte("ogg"), MediaType: whatsmeow.MediaAudio}
+
+	id, queued, err := enqueueMessage(db, "+15550100", message, upload, "")
+	if err != nil || !queued {
+		t.Fatalf("enqueueMessage() = %d, %v, %v", id, queued, err)
+	}
+
+	entry, err := db.GetOutboxMessage(id)
+	if err != nil {
+		t.Fatalf("GetOutboxMessage() failed: %v", err)
+	}
+
+	decoded, restored, err := decodeOutboxMessage(*entry)
+	if err != nil {
+		t.Fatalf("decodeOutboxMessage() failed: %v", err)
+	}
+	if restored == nil || !bytes.Equal(restored.Data, []byte("ogg")) || restored.MediaType != whatsmeow.MediaAudio {
+		t.Fatalf("Expected the media upload to be restored, got %+v", restored)
+	}
+
+	restored.apply(whatsmeow.UploadResponse{URL: "https://mmg.whatsapp.net/x", DirectPath: "/x", FileLength: 3})
+	audio := decoded.GetViewOnceMessageV2Extension().GetMessage().GetAudioMessage()
+	if audio.GetDirectPath() != "/x" || audio.GetFileLength() != 3 || !audio.GetPTT() {
+		t.Errorf("Expected upload details in the decoded voice note, got %v", audio)
+	}
+}
+
+func TestDecodeOutboxMessageMismatchedMedia(t *testing.T) {
+	data, err := proto.Marshal(&waProto.Message{LocationMessage: &waProto.LocationMessage{}})
+	if err != nil {
+		t.Fatalf("Marshal() failed: %v", err)
+	}
+
+	_, _, err = decodeOutboxMessage(storage.OutboxMessage{Message: data, Media: []byte{1}})
+	if err == nil {
+		t.Error("decodeOutboxMessage() should fail when media has nowhere to go")
+	}
+}
+
+func TestDescribeMessage(t *testing.T) {
+	tests := []struct {
+		message *waProto.Message
+		want    string
+	}{
+		{&waProto.Message{Conversation: proto.String("Hello")}, "Hello"},
+		{&waProto.Message{ExtendedTextMessage: &waProto.ExtendedTextMessage{Text: proto.String("https://example.com")}}, "https://example.com"},
+		{&waProto.Message{LocationMessage: &waProto.LocationMessage{}}, "[location]"},
+		{&waProto.Message{ContactsArrayMessage: &waProto.ContactsArrayMessage{}}, "[contact]"},
+		{&waProto.Message{StickerMessage: &waProto.StickerMessage{}}, "[sticker]"},
+		{&waProto.Message{ViewOnceMessageV2Extension: &waProto.FutureProofMessage{
+			Message: &waProto.Message{AudioMessage: &waProto.AudioMessage{}},
+		}}, "[voice note] (view once)"},
+	}
+
+	for _, tt := range tests {
+		if got := describeMessage(tt.message); got != tt.want {
+			t.Errorf("describeMessage() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types/events"
+
+	"whatsmeow-go/cmd/wavy/storage"
+)
+
+// maxPollOptions is the maximum number of options WhatsApp allows in a poll
+const maxPollOptions = 12
+
+var (
+	pollOptions       []string
+	pollMaxSelections int
+	pollListen        int
+)
+
+var pollCmd = &cobra.Command{
+	Use:   "poll",
+	Short: "Create WhatsApp polls and collect their results",
+	Long:  `Create polls in chats or groups and tally the votes they receive.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var pollCreateCmd = &cobra.Command{
+	Use:   "create [chat] [question]",
+	Short: "Send a poll to a contact or group",
+	Long: `Send a poll with the given question and options. The poll is remembered locally,
+so its votes can later be tallied with 'wavy poll results'.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 2 {
+			cmd.Help()
+			os.Exit(1)
+		}
+
+		runPollCreate(args[0], args[1])
+	},
+}
+
+var pollResultsCmd = &cobra.Command{
+	Use:   "results [messageID]",
+	Short: "Show the votes of a poll",
+	Long: `Connect to WhatsApp, collect pending votes for polls created with wavy and print a tally
+per option for the given poll, including who voted for it.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			cmd.Help()
+			os.Exit(1)
+		}
+
+		runPollResults(args[0])
+	},
+}
+
+func init() {
+	pollCreateCmd.Flags().StringArrayVarP(&pollOptions, "option", "o", nil, "Poll option (repeat for each option)")
+	pollCreateCmd.Flags().IntVar(&pollMaxSelections, "max-selections", 1, "Maximum number of options a voter can select")
+	pollCreateCmd.Flags().BoolVarP(&debug, "debug", "d", false, "Enable verbose debugging")
+	pollCreateCmd.Flags().IntVarP(&wait, "wait", "w", 5, "Seconds to wait for message confirmation")
+
+	pollResultsCmd.Flags().IntVarP(&pollListen, "listen", "l", 10, "Seconds to listen for incoming votes")
+	pollResultsCmd.Flags().BoolVarP(&debug, "debug", "d", false, "Enable verbose debugging")
+
+	pollCmd.AddCommand(pollCreateCmd)
+	pollCmd.AddCommand(pollResultsCmd)
+}
+
+// validatePoll checks a poll definition against WhatsApp's limits
+func validatePoll(question string, options []string, maxSelections int) error {
+	if strings.TrimSpace(question) == "" {
+		return errors.New("poll question cannot be empty")
+	}
+
+	if len(options) < 2 {
+		return errors.New("a poll needs at least 2 options")
+	}
+
+	if len(options) > maxPollOptions {
+		return fmt.Errorf("a poll can have at most %d options", maxPollOptions)
+	}
+
+	// Votes reference options by the hash of their name, so names must be unique
+	seen := make(map[string]bool, len(options))
+	for _, option := range options {
+		if strings.TrimSpace(option) == "" {
+			return errors.New("poll options cannot be empty")
+		}
+		if seen[option] {
+			return fmt.Errorf("duplicate poll option %q", option)
+		}
+		seen[option] = true
+	}
+
+	if maxSelections < 1 || maxSelections > len(options) {
+		return fmt.Errorf("max selections must be between 1 and %d", len(options))
+	}
+
+	return nil
+}
+
+func runPollCreate(chat, question string) {
+	if err := validatePoll(question, pollOptions, pollMaxSelections); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid poll: %v\n", err)
+		os.Exit(1)
+	}
+
+	db := openStorage()
+	defer db.Close()
+
+	client := connectClient(debug)
+
+	chatJID, err := parseRecipient(client, chat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	message := client.BuildPollCreation(question, pollOptions, pollMaxSelections)
+	resp, err := sendMessage(client, chatJID, message)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error sending poll: %v\n", err)
+		os.Exit(1)
+	}
+
+	err = db.SavePoll(storage.Poll{
+		ID:            resp.ID,
+		Chat:          chatJID.String(),
+		Question:      question,
+		Options:       pollOptions,
+		MaxSelections: pollMaxSelections,
+		CreatedAt:     resp.Timestamp,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: poll was sent but could not be saved locally: %v\n", err)
+	}
+
+	fmt.Printf("Poll sent successfully to %s\n", chatJID.String())
+	fmt.Printf("Message ID: %s\n", resp.ID)
+	fmt.Printf("\nTo see the results, use:\nwavy poll results %s\n", resp.ID)
+
+	client.Disconnect()
+}
+
+func runPollResults(pollID string) {
+	db := openStorage()
+	defer db.Close()
+
+	poll, err := db.GetPoll(pollID)
+	if errors.Is(err, storage.ErrNotFound) {
+		fmt.Fprintf(os.Stderr, "Poll %s not found. Only polls created with 'wavy poll create' can be tallied.\n", pollID)
+		os.Exit(1)
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Votes are only delivered once, so the handler must be in place before connecting
+	client := newClient(debug)
+	client.AddEventHandler(func(evt interface{}) {
+		if message, ok := evt.(*events.Message); ok {
+			handlePollVote(client, db, message)
+		}
+	})
+
+	fmt.Println("Connecting to WhatsApp...")
+	connect(client, debug)
+
+	fmt.Printf("Collecting votes for %d seconds...\n", pollListen)
+	time.Sleep(time.Duration(pollListen) * time.Second)
+	client.Disconnect()
+
+	votes, err := db.GetPollVotes(poll.ID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	printPollResults(poll, tallyPoll(poll, votes))
+}
+
+// handlePollVote decrypts and stores a vote if the message is a vote on a poll created with wavy
+func handlePollVote(client *whatsmeow.Client, db *storage.DB, message *events.Message) {
+	update := message.Message.GetPollUpdateMessage()
+	if update == nil {
+		return
+	}
+
+	poll, err := db.GetPoll(update.GetPollCreationMessageKey().GetID())
+	if err != nil {
+		// Not one of our polls
+		return
+	}
+
+	vote, err := client.DecryptPollVote(context.Background(), message)
+	if err != nil {
+		if debug {
+			fmt.Fprintf(os.Stderr, "Failed to decrypt vote from %s: %v\n", message.Info.Sender, err)
+		}
+		return
+	}
+
+	err = db.SavePollVote(storage.PollVote{
+		PollID:  poll.ID,
+		Voter:   message.Info.Sender.ToNonAD().String(),
+		Options: pollOptionNames(poll.Options, vote.GetSelectedOptions()),
+		VotedAt: message.Info.Timestamp,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to save vote from %s: %v\n", message.Info.Sender, err)
+	}
+}
+
+// pollOptionNames maps the SHA-256 hashes in a vote back to option names
+func pollOptionNames(options []string, hashes [][]byte) []string {
+	byHash := make(map[[sha256.Size]byte]string, len(options))
+	for _, option := range options {
+		byHash[sha256.Sum256([]byte(option))] = option
+	}
+
+	names := make([]string, 0, len(hashes))
+	for _, hash := range hashes {
+		var key [sha256.Size]byte
+		copy(key[:], hash)
+		if name, ok := byHash[key]; ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// pollOptionResult holds the votes for a single poll option
+type pollOptionResult struct {
+	Option string
+	Voters []string
+}
+
+// tallyPoll counts the votes per option, keeping the options in their original order
+func tallyPoll(poll *storage.Poll, votes []storage.PollVote) []pollOptionResult {
+	results := make([]pollOptionResult, len(poll.Options))
+	index := make(map[string]int, len(poll.Options))
+	for i, option := range poll.Options {
+		results[i].Option = option
+		index[option] = i
+	}
+
+	for _, vote := range votes {
+		for _, option := range vote.Options {
+			if i, ok := index[option]; ok {
+				results[i].Voters = append(results[i].Voters, vote.Voter)
+			}
+		}
+	}
+
+	for i := range results {
+		sort.Strings(results[i].Voters)
+	}
+	return results
+}
+
+func printPollResults(poll *storage.Poll, results []pollOptionResult) {
+	fmt.Printf("\n===== POLL RESULTS =====\n")
+	fmt.Printf("Question: %s\n", poll.Question)
+	fmt.Printf("Chat: %s\n", poll.Chat)
+	fmt.Println("----------------------------------")
+
+	for i, result := range results {
+		fmt.Printf("%d. %s: %d vote(s)\n", i+1, result.Option, len(result.Voters))
+		for _, voter := range result.Voters {
+			fmt.Printf("   - %s\n", voter)
+		}
+	}
+	fmt.Println("----------------------------------")
+}
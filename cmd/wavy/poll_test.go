@@ -0,0 +1,68 @@
+package main
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"whatsmeow-go/cmd/wavy/storage"
+)
+
+func TestValidatePoll(t *testing.T) {
+	tests := []struct {
+		name          string
+		question      string
+		options       []string
+		maxSelections int
+		wantErr       bool
+	}{
+		{name: "Valid poll", question: "Lunch?", options: []string{"Pizza", "Sushi"}, maxSelections: 1},
+		{name: "Multiple selections", question: "Lunch?", options: []string{"Pizza", "Sushi", "Tacos"}, maxSelections: 3},
+		{name: "Empty question", question: " ", options: []string{"Pizza", "Sushi"}, maxSelections: 1, wantErr: true},
+		{name: "Single option", question: "Lunch?", options: []string{"Pizza"}, maxSelections: 1, wantErr: true},
+		{name: "Duplicate option", question: "Lunch?", options: []string{"Pizza", "Pizza"}, maxSelections: 1, wantErr: true},
+		{name: "Too many selections", question: "Lunch?", options: []string{"Pizza", "Sushi"}, maxSelections: 3, wantErr: true},
+		{name: "Zero selections", question: "Lunch?", options: []string{"Pizza", "Sushi"}, maxSelections: 0, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validatePoll(tt.question, tt.options, tt.maxSelections)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validatePoll() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPollOptionNames(t *testing.T) {
+	sushi := sha256.Sum256([]byte("Sushi"))
+	unknown := sha256.Sum256([]byte("Burgers"))
+
+	names := pollOptionNames([]string{"Pizza", "Sushi"}, [][]byte{sushi[:], unknown[:]})
+	if len(names) != 1 || names[0] != "Sushi" {
+		t.Errorf("Expected [Sushi], got %v", names)
+	}
+}
+
+func TestTallyPoll(t *testing.T) {
+	poll := &storage.Poll{Options: []string{"Pizza", "Sushi", "Tacos"}}
+	votes := []storage.PollVote{
+		{Voter: "222@s.whatsapp.net", Options: []string{"Pizza"}},
+		{Voter: "111@s.whatsapp.net", Options: []string{"Pizza", "Tacos"}},
+		{Voter: "333@s.whatsapp.net", Options: []string{}},
+	}
+
+	results := tallyPoll(poll, votes)
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(results))
+	}
+	if results[0].Option != "Pizza" || len(results[0].Voters) != 2 || results[0].Voters[0] != "111@s.whatsapp.net" {
+		t.Errorf("Unexpected Pizza result: %+v", results[0])
+	}
+	if len(results[1].Voters) != 0 {
+		t.Errorf("Expected no votes for Sushi, got %+v", results[1])
+	}
+	if len(results[2].Voters) != 1 {
+		t.Errorf("Expected 1 vote for Tacos, got %+v", results[2])
+	}
+}
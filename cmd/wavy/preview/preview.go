@@ -0,0 +1,270 @@
+// Package preview generates link previews from the OpenGraph metadata of web pages.
+package preview
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+	"golang.org/x/net/html"
+)
+
+const (
+	// MaxPageSize is the maximum number of bytes read from a web page
+	MaxPageSize = 2 << 20
+	// MaxImageSize is the maximum size of a preview image
+	MaxImageSize = 5 << 20
+	// MaxImagePixels is the maximum width times height of a preview image. A small compressed file can
+	// describe a huge image, which would take gigabytes of memory to decode.
+	MaxImagePixels = 25_000_000
+
+	// InlineThumbnailSize is the maximum width or height of a thumbnail embedded in a message
+	InlineThumbnailSize = 160
+	// uploadThumbnailSize is the maximum width or height of the uploaded high quality thumbnail
+	uploadThumbnailSize = 720
+)
+
+// Preview is the link preview of a web page
+type Preview struct {
+	// MatchedText is the URL as it appears in the message
+	MatchedText string
+	Title       string
+	Description string
+	// Thumbnail is a small JPEG embedded in the message
+	Thumbnail []byte
+	// Image is a larger JPEG to upload as high quality thumbnail
+	Image       []byte
+	ImageWidth  uint32
+	ImageHeight uint32
+}
+
+var urlPattern = regexp.MustCompile(`https?://[^\s<>"]+`)
+
+// FindURL returns the first http or https URL in text, or an empty string if there is none
+func FindURL(text string) string {
+	match := urlPattern.FindString(text)
+
+	// Punctuation at the end usually belongs to the sentence, not the URL. Closing brackets are kept
+	// when they close one in the URL, as in https://en.wikipedia.org/wiki/Go_(programming_language).
+	for match != "" {
+		last := match[len(match)-1]
+		if open, ok := closingBrackets[last]; ok {
+			if strings.Count(match, string(open)) >= strings.Count(match, string(last)) {
+				break
+			}
+		} else if !strings.ContainsRune(".,;:!?'", rune(last)) {
+			break
+		}
+		match = match[:len(match)-1]
+	}
+	return match
+}
+
+// closingBrackets maps the closing brackets trimmed from the end of URLs to their opening ones
+var closingBrackets = map[byte]byte{')': '(', ']': '[', '}': '{'}
+
+// Fetch downloads the page at rawURL and builds a preview from its OpenGraph metadata.
+// The preview image is optional, so failing to load it is not an error.
+func Fetch(ctx context.Context, client *http.Client, rawURL string) (*Preview, error) {
+	body, pageURL, err := get(ctx, client, rawURL, MaxPageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := parseMetadata(body)
+	if meta.Title == "" {
+		return nil, errors.New("page has no title")
+	}
+
+	preview := &Preview{
+		MatchedText: rawURL,
+		Title:       meta.Title,
+		Description: meta.Description,
+	}
+
+	if meta.Image != "" {
+		imageURL, err := pageURL.Parse(meta.Image)
+		if err == nil {
+			// Errors are ignored, the preview is still useful without an image
+			_ = preview.loadImage(ctx, client, imageURL.String())
+		}
+	}
+
+	return preview, nil
+}
+
+// loadImage downloads the preview image and creates the thumbnails
+func (p *Preview) loadImage(ctx context.Context, client *http.Client, imageURL string) error {
+	data, _, err := get(ctx, client, imageURL, MaxImageSize)
+	if err != nil {
+		return err
+	}
+
+	img, err := decodeImage(data)
+	if err != nil {
+		return err
+	}
+
+	p.Thumbnail, _, _, err = EncodeThumbnail(img, InlineThumbnailSize)
+	if err != nil {
+		return err
+	}
+	p.Image, p.ImageWidth, p.ImageHeight, err = EncodeThumbnail(img, uploadThumbnailSize)
+	return err
+}
+
+// decodeImage decodes a preview image, checking its dimensions before decoding the pixels
+func decodeImage(data []byte) (image.Image, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode preview image: %w", err)
+	}
+	if int64(config.Width)*int64(config.Height) > MaxImagePixels {
+		return nil, fmt.Errorf("preview image of %dx%d pixels is too large", config.Width, config.Height)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode preview image: %w", err)
+	}
+	return img, nil
+}
+
+// get downloads a URL, failing if the response is larger than limit
+func get(ctx context.Context, client *http.Client, rawURL string, limit int64) ([]byte, *url.URL, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Many sites only serve OpenGraph tags to known crawlers
+	req.Header.Set("User-Agent", "WhatsApp/2.0 (compatible; wavy link preview)")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("unexpected status %s for %s", resp.Status, rawURL)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, nil, fmt.Errorf("%s is larger than %d bytes", rawURL, limit)
+	}
+
+	return data, resp.Request.URL, nil
+}
+
+// metadata holds the preview fields found in a page
+type metadata struct {
+	Title       string
+	Description string
+	Image       string
+}
+
+// parseMetadata reads the OpenGraph tags of a page, falling back to the title and description tags
+func parseMetadata(page []byte) metadata {
+	var (
+		meta          metadata
+		title         string
+		description   string
+		inTitle       bool
+		tokenizer     = html.NewTokenizer(bytes.NewReader(page))
+		openGraphTags = map[string]*string{
+			"og:title":       &meta.Title,
+			"og:description": &meta.Description,
+			"og:image":       &meta.Image,
+		}
+	)
+
+loop:
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			break loop
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			switch token.Data {
+			case "title":
+				inTitle = true
+			case "body":
+				// Metadata lives in the head
+				break loop
+			case "meta":
+				attrs := make(map[string]string, len(token.Attr))
+				for _, attr := range token.Attr {
+					attrs[strings.ToLower(attr.Key)] = strings.TrimSpace(attr.Val)
+				}
+				property := attrs["property"]
+				if property == "" {
+					property = attrs["name"]
+				}
+				if field, ok := openGraphTags[strings.ToLower(property)]; ok && *field == "" {
+					*field = attrs["content"]
+				} else if strings.EqualFold(property, "description") {
+					description = attrs["content"]
+				}
+			}
+		case html.TextToken:
+			if inTitle && title == "" {
+				title = strings.TrimSpace(string(tokenizer.Text()))
+			}
+		case html.EndTagToken:
+			if tokenizer.Token().Data == "title" {
+				inTitle = false
+			}
+		}
+	}
+
+	if meta.Title == "" {
+		meta.Title = title
+	}
+	if meta.Description == "" {
+		meta.Description = description
+	}
+	return meta
+}
+
+// EncodeThumbnail scales img to fit within size and encodes it as JPEG.
+// It returns the JPEG with its width and height.
+func EncodeThumbnail(img image.Image, size int) ([]byte, uint32, uint32, error) {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > size || height > size {
+		if width >= height {
+			height = max(1, height*size/width)
+			width = size
+		} else {
+			width = max(1, width*size/height)
+			height = size
+		}
+	}
+
+	// JPEG has no transparency, so transparent images are put on a white background
+	thumbnail := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(thumbnail, thumbnail.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(thumbnail, thumbnail.Bounds(), img, bounds, draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, thumbnail, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, 0, 0, fmt.Errorf("failed to encode thumbnail: %w", err)
+	}
+	return buf.Bytes(), uint32(width), uint32(height), nil
+}
@@ -0,0 +1,120 @@
+package preview
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFindURL(t *testing.T) {
+	tests := map[string]string{
+		"See https://example.com/status.":                               "https://example.com/status",
+		"(details at http://example.com/a?b=c)":                         "http://example.com/a?b=c",
+		"Read https://en.wikipedia.org/wiki/Go_(programming_language).": "https://en.wikipedia.org/wiki/Go_(programming_language)",
+		"(see https://en.wikipedia.org/wiki/Go_(programming_language))": "https://en.wikipedia.org/wiki/Go_(programming_language)",
+		"first https://a.example then https://b.test":                   "https://a.example",
+		"no links here": "",
+	}
+
+	for text, want := range tests {
+		if got := FindURL(text); got != want {
+			t.Errorf("FindURL(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestParseMetadata(t *testing.T) {
+	page := `<html><head><title>Fallback title</title>
+		<meta name="description" content="Fallback description">
+		<meta property="og:title" content="Status: all systems operational">
+		<meta property="og:image" content="/og.png">
+		</head><body><meta property="og:description" content="Ignored in body"></body></html>`
+
+	meta := parseMetadata([]byte(page))
+	if meta.Title != "Status: all systems operational" {
+		t.Errorf("Expected OpenGraph title, got %q", meta.Title)
+	}
+	if meta.Description != "Fallback description" {
+		t.Errorf("Expected fallback description, got %q", meta.Description)
+	}
+	if meta.Image != "/og.png" {
+		t.Errorf("Expected image /og.png, got %q", meta.Image)
+	}
+}
+
+func TestDecodeImageRejectsHugeDimensions(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := decodeImage(buf.Bytes()); err != nil {
+		t.Fatalf("decodeImage of a small image: %v", err)
+	}
+
+	// Rewrite the IHDR chunk to claim 100000x100000 pixels without changing the data
+	data := buf.Bytes()
+	ihdr := data[8+8 : 8+8+13]
+	binary.BigEndian.PutUint32(ihdr[0:4], 100000)
+	binary.BigEndian.PutUint32(ihdr[4:8], 100000)
+	binary.BigEndian.PutUint32(data[8+8+13:], crc32.ChecksumIEEE(data[8+4:8+8+13]))
+
+	_, err := decodeImage(data)
+	if err == nil || !strings.Contains(err.Error(), "too large") {
+		t.Fatalf("decodeImage = %v, want a too large error", err)
+	}
+}
+
+func TestFetch(t *testing.T) {
+	var imageData bytes.Buffer
+	if err := png.Encode(&imageData, image.NewRGBA(image.Rect(0, 0, 1200, 600))); err != nil {
+		t.Fatalf("Failed to encode PNG: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><head><meta property="og:title" content="Incident resolved">
+			<meta property="og:description" content="Details"><meta property="og:image" content="/og.png"></head></html>`))
+	})
+	mux.HandleFunc("/og.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(imageData.Bytes())
+	})
+	mux.HandleFunc("/large", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("x", MaxPageSize+1)))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	preview, err := Fetch(context.Background(), server.Client(), server.URL+"/page")
+	if err != nil {
+		t.Fatalf("Fetch() returned error: %v", err)
+	}
+	if preview.Title != "Incident resolved" || preview.Description != "Details" {
+		t.Errorf("Unexpected preview: %+v", preview)
+	}
+	if preview.ImageWidth != uploadThumbnailSize || preview.ImageHeight != uploadThumbnailSize/2 {
+		t.Errorf("Expected %dx%d image, got %dx%d", uploadThumbnailSize, uploadThumbnailSize/2, preview.ImageWidth, preview.ImageHeight)
+	}
+
+	thumbnail, err := jpeg.DecodeConfig(bytes.NewReader(preview.Thumbnail))
+	if err != nil {
+		t.Fatalf("Thumbnail is not a JPEG: %v", err)
+	}
+	if thumbnail.Width != InlineThumbnailSize {
+		t.Errorf("Expected thumbnail width %d, got %d", InlineThumbnailSize, thumbnail.Width)
+	}
+
+	if _, err := Fetch(context.Background(), server.Client(), server.URL+"/large"); err == nil {
+		t.Error("Expected error for page over the size limit")
+	}
+	if _, err := Fetch(context.Background(), server.Client(), server.URL+"/missing"); err == nil {
+		t.Error("Expected error for missing page")
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+
+	"whatsmeow-go/cmd/wavy/common"
+	"whatsmeow-go/cmd/wavy/ratelimit"
+	"whatsmeow-go/cmd/wavy/storage"
+)
+
+// claimMargin is added to the longest a send can wait when deciding that a claimed message was abandoned.
+// It covers everything else a delivery does, such as uploading media and resolving the recipient.
+const claimMargin = 10 * time.Minute
+
+// ignoreRateLimit disables the rate limiter for the current command
+var ignoreRateLimit bool
+
+// sendLimiter is shared by all send paths of the process and opened on first use
+var sendLimiter struct {
+	once    sync.Once
+	db      *storage.DB
+	limiter *ratelimit.Limiter
+	err     error
+}
+
+// openLimiter returns the rate limiter, which keeps its state in the wavy database
+// so that all wavy processes share the same limits
+func openLimiter() (*ratelimit.Limiter, *storage.DB, error) {
+	sendLimiter.once.Do(func() {
+		config, err := common.LoadConfig()
+		if err != nil {
+			sendLimiter.err = err
+			return
+		}
+
+		if err := common.EnsureDirectories(); err != nil {
+			sendLimiter.err = err
+			return
+		}
+		path, err := common.GetStoragePath()
+		if err != nil {
+			sendLimiter.err = err
+			return
+		}
+		db, err := storage.Open(path)
+		if err != nil {
+			sendLimiter.err = err
+			return
+		}
+
+		limiter := ratelimit.NewLimiter(db, config.RateLimit)
+		limiter.Notify = func(class ratelimit.Class, wait time.Duration) {
+			fmt.Printf("Rate limit for %s contacts reached, waiting %s...\n", class, wait.Round(time.Second))
+		}
+		sendLimiter.db = db
+		sendLimiter.limiter = limiter
+	})
+	return sendLimiter.limiter, sendLimiter.db, sendLimiter.err
+}
+
+// isUserJID reports whether a JID is a single person rather than a group, broadcast list or status
+func isUserJID(jid types.JID) bool {
+	return jid.Server == types.DefaultUserServer || jid.Server == types.HiddenUserServer
+}
+
+// recipientClass decides which limits apply to a recipient. Contacts are known if wavy messaged them
+// before or they are saved in the address book; groups and other chats always count as known.
+func recipientClass(client *whatsmeow.Client, db *storage.DB, recipient types.JID) ratelimit.Class {
+	if !isUserJID(recipient) {
+		return ratelimit.KnownContact
+	}
+
+	if contacted, err := db.IsContacted(recipient.ToNonAD().String()); err == nil && contacted {
+		return ratelimit.KnownContact
+	}
+
+	info, err := client.Store.Contacts.GetContact(context.Background(), recipient.ToNonAD())
+	if err == nil && info.Found && (info.FullName != "" || info.FirstName != "") {
+		return ratelimit.KnownContact
+	}
+
+	return ratelimit.NewContact
+}
+
+// throttle waits until the rate limiter allows a message to the recipient
+func throttle(client *whatsmeow.Client, recipient types.JID) error {
+	if ignoreRateLimit {
+		return nil
+	}
+
+	limiter, db, err := openLimiter()
+	if err != nil {
+		return fmt.Errorf("failed to open rate limiter (use --ignore-rate-limit to skip it): %w", err)
+	}
+	return limiter.Wait(context.Background(), recipientClass(client, db, recipient))
+}
+
+// claimTimeout is how long a queued or scheduled message may stay claimed before it is assumed the worker
+// crashed. A live worker waits at most rate_limit.max_wait plus jitter for the limiter and --wait for the
+// confirmation, so a claim held longer than that plus claimMargin is stale.
+func claimTimeout(config ratelimit.Config) time.Duration {
+	return config.MaxWait + config.Jitter + time.Duration(wait)*time.Second + claimMargin
+}
+
+// markContacted remembers a recipient, so later messages count against the limits for known contacts
+func markContacted(recipient types.JID) {
+	if !isUserJID(recipient) {
+		return
+	}
+
+	_, db, err := openLimiter()
+	if err != nil {
+		return
+	}
+	if err := db.MarkContacted(recipient.ToNonAD().String(), time.Now()); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+}
@@ -0,0 +1,211 @@
+// Package ratelimit paces outgoing messages with token buckets whose state is shared between processes.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// Class separates recipients with their own limits
+type Class string
+
+const (
+	// KnownContact is the class of groups and of contacts that were messaged before or are in the address book
+	KnownContact Class = "known"
+	// NewContact is the class of contacts that were never messaged, which WhatsApp watches closely for spam
+	NewContact Class = "new"
+)
+
+// Rate limits the messages sent to one class of recipients. Zero values are unlimited.
+type Rate struct {
+	PerMinute int `yaml:"per_minute"`
+	PerHour   int `yaml:"per_hour"`
+}
+
+// Config holds the limits for all classes
+type Config struct {
+	Known Rate `yaml:"known"`
+	New   Rate `yaml:"new"`
+	// Jitter is the maximum random delay added before every message
+	Jitter time.Duration `yaml:"jitter"`
+	// MaxWait is the longest a message waits for the limit before giving up
+	MaxWait time.Duration `yaml:"max_wait"`
+}
+
+// DefaultConfig returns conservative limits suitable for a personal account
+func DefaultConfig() Config {
+	return Config{
+		Known:   Rate{PerMinute: 20, PerHour: 300},
+		New:     Rate{PerMinute: 4, PerHour: 40},
+		Jitter:  3 * time.Second,
+		MaxWait: 10 * time.Minute,
+	}
+}
+
+// Bucket is the persisted state of a token bucket
+type Bucket struct {
+	Tokens    float64
+	UpdatedAt time.Time
+}
+
+// Store loads and saves buckets. Update must run atomically with respect to other processes.
+// Buckets missing from the store are absent from the map and start full.
+type Store interface {
+	UpdateRateBuckets(names []string, update func(buckets map[string]Bucket) (map[string]Bucket, error)) error
+}
+
+// limit is a token bucket holding up to capacity tokens, refilled evenly over period
+type limit struct {
+	name     string
+	capacity float64
+	period   time.Duration
+}
+
+// refill returns the bucket with the tokens accumulated since its last update
+func (l limit) refill(b Bucket, ok bool, now time.Time) Bucket {
+	if !ok {
+		return Bucket{Tokens: l.capacity, UpdatedAt: now}
+	}
+
+	elapsed := now.Sub(b.UpdatedAt)
+	if elapsed > 0 {
+		b.Tokens += l.capacity * float64(elapsed) / float64(l.period)
+	}
+	if b.Tokens > l.capacity {
+		b.Tokens = l.capacity
+	}
+	b.UpdatedAt = now
+	return b
+}
+
+// waitFor returns how long until the bucket holds a whole token
+func (l limit) waitFor(b Bucket) time.Duration {
+	if b.Tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.Tokens) / l.capacity * float64(l.period))
+}
+
+// LimitError is returned when a message would have to wait longer than the configured maximum
+type LimitError struct {
+	Class      Class
+	RetryAfter time.Duration
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("rate limit for %s contacts reached, next message allowed in %s", e.Class, e.RetryAfter.Round(time.Second))
+}
+
+// Limiter paces messages according to a Config
+type Limiter struct {
+	// Notify, if set, is called before waiting for the limit
+	Notify func(class Class, wait time.Duration)
+
+	store  Store
+	config Config
+	now    func() time.Time
+	sleep  func(ctx context.Context, d time.Duration) error
+}
+
+// NewLimiter creates a limiter that keeps its state in store
+func NewLimiter(store Store, config Config) *Limiter {
+	return &Limiter{store: store, config: config, now: time.Now, sleep: sleep}
+}
+
+// limits returns the buckets that apply to a class
+func (l *Limiter) limits(class Class) []limit {
+	rate := l.config.Known
+	if class == NewContact {
+		rate = l.config.New
+	}
+
+	var limits []limit
+	if rate.PerMinute > 0 {
+		limits = append(limits, limit{name: string(class) + ":minute", capacity: float64(rate.PerMinute), period: time.Minute})
+	}
+	if rate.PerHour > 0 {
+		limits = append(limits, limit{name: string(class) + ":hour", capacity: float64(rate.PerHour), period: time.Hour})
+	}
+	return limits
+}
+
+// Wait blocks until a message to a recipient of the given class may be sent and takes a token for it.
+// It returns a *LimitError without waiting if the limit allows no message within MaxWait.
+func (l *Limiter) Wait(ctx context.Context, class Class) error {
+	limits := l.limits(class)
+	if len(limits) > 0 {
+		for {
+			wait, err := l.take(limits)
+			if err != nil {
+				return err
+			}
+			if wait == 0 {
+				break
+			}
+			if wait > l.config.MaxWait {
+				return &LimitError{Class: class, RetryAfter: wait}
+			}
+			if l.Notify != nil {
+				l.Notify(class, wait)
+			}
+			if err := l.sleep(ctx, wait); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Evenly spaced messages look automated
+	if l.config.Jitter > 0 {
+		return l.sleep(ctx, time.Duration(rand.Int63n(int64(l.config.Jitter))))
+	}
+	return nil
+}
+
+// take takes a token from every bucket if all of them have one, or returns how long to wait otherwise
+func (l *Limiter) take(limits []limit) (time.Duration, error) {
+	names := make([]string, len(limits))
+	for i, lim := range limits {
+		names[i] = lim.name
+	}
+
+	var wait time.Duration
+	err := l.store.UpdateRateBuckets(names, func(buckets map[string]Bucket) (map[string]Bucket, error) {
+		now := l.now()
+		updated := make(map[string]Bucket, len(limits))
+		wait = 0
+		for _, lim := range limits {
+			b, ok := buckets[lim.name]
+			b = lim.refill(b, ok, now)
+			if w := lim.waitFor(b); w > wait {
+				wait = w
+			}
+			updated[lim.name] = b
+		}
+
+		if wait == 0 {
+			for name, b := range updated {
+				b.Tokens--
+				updated[name] = b
+			}
+		}
+		return updated, nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to update rate limit: %w", err)
+	}
+	return wait, nil
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
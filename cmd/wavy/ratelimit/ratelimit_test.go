@@ -0,0 +1,137 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// memoryStore keeps buckets in memory
+type memoryStore struct {
+	buckets map[string]Bucket
+}
+
+func (m *memoryStore) UpdateRateBuckets(names []string, update func(map[string]Bucket) (map[string]Bucket, error)) error {
+	current := make(map[string]Bucket)
+	for _, name := range names {
+		if b, ok := m.buckets[name]; ok {
+			current[name] = b
+		}
+	}
+
+	updated, err := update(current)
+	if err != nil {
+		return err
+	}
+	for name, b := range updated {
+		m.buckets[name] = b
+	}
+	return nil
+}
+
+// newTestLimiter returns a limiter with a fake clock that advances when it sleeps
+func newTestLimiter(config Config) (*Limiter, *time.Time, *[]time.Duration) {
+	now := time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC)
+	var slept []time.Duration
+
+	l := NewLimiter(&memoryStore{buckets: make(map[string]Bucket)}, config)
+	l.now = func() time.Time { return now }
+	l.sleep = func(ctx context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		now = now.Add(d)
+		return nil
+	}
+	return l, &now, &slept
+}
+
+func TestWaitWithinBurst(t *testing.T) {
+	l, _, slept := newTestLimiter(Config{Known: Rate{PerMinute: 3}, MaxWait: time.Minute})
+
+	for i := 0; i < 3; i++ {
+		if err := l.Wait(context.Background(), KnownContact); err != nil {
+			t.Fatalf("Wait() failed: %v", err)
+		}
+	}
+	if len(*slept) != 0 {
+		t.Errorf("Expected no waiting within the burst, slept %v", *slept)
+	}
+
+	// The fourth message waits for a token to be refilled
+	if err := l.Wait(context.Background(), KnownContact); err != nil {
+		t.Fatalf("Wait() failed: %v", err)
+	}
+	if len(*slept) != 1 || (*slept)[0] != 20*time.Second {
+		t.Errorf("Expected to wait 20s for the next token, slept %v", *slept)
+	}
+}
+
+func TestWaitSeparateClasses(t *testing.T) {
+	l, _, slept := newTestLimiter(Config{Known: Rate{PerMinute: 1}, New: Rate{PerMinute: 1}, MaxWait: time.Minute})
+
+	if err := l.Wait(context.Background(), KnownContact); err != nil {
+		t.Fatalf("Wait() failed: %v", err)
+	}
+	if err := l.Wait(context.Background(), NewContact); err != nil {
+		t.Fatalf("Wait() failed: %v", err)
+	}
+	if len(*slept) != 0 {
+		t.Errorf("Expected classes to have separate buckets, slept %v", *slept)
+	}
+}
+
+func TestWaitExceedsMaxWait(t *testing.T) {
+	l, now, _ := newTestLimiter(Config{New: Rate{PerMinute: 10, PerHour: 2}, MaxWait: time.Minute})
+
+	for i := 0; i < 2; i++ {
+		if err := l.Wait(context.Background(), NewContact); err != nil {
+			t.Fatalf("Wait() failed: %v", err)
+		}
+	}
+
+	err := l.Wait(context.Background(), NewContact)
+	var limitErr *LimitError
+	if !errors.As(err, &limitErr) {
+		t.Fatalf("Expected LimitError, got %v", err)
+	}
+	if limitErr.RetryAfter != 30*time.Minute {
+		t.Errorf("Expected to retry after 30m, got %v", limitErr.RetryAfter)
+	}
+
+	// Once the hourly bucket refilled a message may be sent again
+	*now = now.Add(30 * time.Minute)
+	if err := l.Wait(context.Background(), NewContact); err != nil {
+		t.Errorf("Wait() after refill failed: %v", err)
+	}
+}
+
+func TestWaitUnlimitedAndJitter(t *testing.T) {
+	l, _, slept := newTestLimiter(Config{Jitter: time.Second})
+
+	for i := 0; i < 100; i++ {
+		if err := l.Wait(context.Background(), KnownContact); err != nil {
+			t.Fatalf("Wait() failed: %v", err)
+		}
+	}
+	if len(*slept) != 100 {
+		t.Fatalf("Expected a jitter delay before every message, got %d", len(*slept))
+	}
+	for _, d := range *slept {
+		if d < 0 || d >= time.Second {
+			t.Errorf("Jitter %v out of range", d)
+		}
+	}
+}
+
+func TestWaitCancelled(t *testing.T) {
+	l := NewLimiter(&memoryStore{buckets: make(map[string]Bucket)}, Config{Known: Rate{PerMinute: 1}, MaxWait: time.Hour})
+	if err := l.Wait(context.Background(), KnownContact); err != nil {
+		t.Fatalf("Wait() failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.Wait(ctx, KnownContact); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+
+	"whatsmeow-go/cmd/wavy/ratelimit"
+)
+
+func TestIsUserJID(t *testing.T) {
+	tests := []struct {
+		jid  types.JID
+		want bool
+	}{
+		{types.NewJID("15550100", types.DefaultUserServer), true},
+		{types.NewJID("123456789", types.HiddenUserServer), true},
+		{types.NewJID("120363000000000000", types.GroupServer), false},
+		{types.StatusBroadcastJID, false},
+	}
+
+	for _, tt := range tests {
+		if got := isUserJID(tt.jid); got != tt.want {
+			t.Errorf("isUserJID(%s) = %v, want %v", tt.jid, got, tt.want)
+		}
+	}
+}
+
+func TestClaimTimeout(t *testing.T) {
+	defer func(previous int) { wait = previous }(wait)
+	wait = 30
+
+	config := ratelimit.Config{Jitter: 3 * time.Second, MaxWait: time.Hour}
+	if got, want := claimTimeout(config), time.Hour+33*time.Second+claimMargin; got != want {
+		t.Errorf("claimTimeout() = %s, want %s", got, want)
+	}
+}
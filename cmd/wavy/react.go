@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"go.mau.fi/whatsmeow/types"
+)
+
+var (
+	reactSender string
+)
+
+var reactCmd = &cobra.Command{
+	Use:   "react [chat] [messageID] [emoji]",
+	Short: "React to a WhatsApp message",
+	Long: `React to a message with an emoji. The message ID is printed by 'wavy send'.
+Pass an empty emoji ("") to remove a previous reaction.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 3 {
+			cmd.Help()
+			os.Exit(1)
+		}
+
+		runReact(args[0], args[1], args[2])
+	},
+}
+
+func init() {
+	reactCmd.Flags().StringVarP(&reactSender, "sender", "s", "", "Author of the message (phone number, JID or alias), defaults to you")
+	reactCmd.Flags().BoolVarP(&debug, "debug", "d", false, "Enable verbose debugging")
+	reactCmd.Flags().IntVarP(&wait, "wait", "w", 5, "Seconds to wait for message confirmation")
+}
+
+func runReact(chat, messageID, emoji string) {
+	client := connectClient(debug)
+
+	chatJID, err := parseRecipient(client, chat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// An empty sender means the message was sent by us
+	sender := types.EmptyJID
+	if reactSender != "" {
+		sender, err = parseUserAlias(reactSender)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid sender: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	message := client.BuildReaction(chatJID, sender, messageID, emoji)
+	resp, err := sendMessage(client, chatJID, message)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error sending reaction: %v\n", err)
+		os.Exit(1)
+	}
+
+	if emoji == "" {
+		fmt.Printf("Reaction removed from message %s (ID: %s)\n", messageID, resp.ID)
+	} else {
+		fmt.Printf("Reacted with %s to message %s (ID: %s)\n", emoji, messageID, resp.ID)
+	}
+
+	client.Disconnect()
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// parseRecipient resolves a recipient (phone number, group ID, alias, or contact or group name) into a JID.
+// Phone numbers are verified against WhatsApp so the exact JID returned by the server is used.
+func parseRecipient(client *whatsmeow.Client, to string) (types.JID, error) {
+	to, err := resolveAlias(to)
+	if err != nil {
+		return types.EmptyJID, err
+	}
+
+	resolved := resolveRecipients(client, []string{to})
+	return resolved[0].JID, resolved[0].Err
+}
+
+// resolvedRecipient is a recipient from the command line with its JID, or the error resolving it
+type resolvedRecipient struct {
+	Input string
+	JID   types.JID
+	Err   error
+}
+
+// invalidRecipientError is a recipient that can never be resolved, such as a malformed group ID or a phone
+// number that is not on WhatsApp. Other resolve errors, like failing to load the contacts, may be temporary.
+type invalidRecipientError struct {
+	err error
+}
+
+func (e *invalidRecipientError) Error() string {
+	return e.err.Error()
+}
+
+func (e *invalidRecipientError) Unwrap() error {
+	return e.err
+}
+
+// invalidRecipient marks an error as an invalid recipient. It returns nil if err is nil.
+func invalidRecipient(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &invalidRecipientError{err: err}
+}
+
+// isInvalidRecipient reports whether resolving a recipient failed because of the recipient itself
+func isInvalidRecipient(err error) bool {
+	var invalid *invalidRecipientError
+	return errors.As(err, &invalid)
+}
+
+// splitRecipients splits repeated and comma-separated recipients and drops empty and duplicate entries
+func splitRecipients(values []string) []string {
+	var recipients []string
+	seen := make(map[string]bool)
+	for _, value := range values {
+		for _, recipient := range strings.Split(value, ",") {
+			recipient = strings.TrimSpace(recipient)
+			if recipient == "" || seen[recipient] {
+				continue
+			}
+			seen[recipient] = true
+			recipients = append(recipients, recipient)
+		}
+	}
+	return recipients
+}
+
+// resolveRecipients resolves recipients into JIDs, verifying all phone numbers with a single IsOnWhatsApp call.
+// Names are looked up in the contacts and groups. The results are in the order of the inputs.
+func resolveRecipients(client *whatsmeow.Client, inputs []string) []resolvedRecipient {
+	return resolveRecipientsWith(client.IsOnWhatsApp, recipientNameLookup(client), inputs)
+}
+
+func resolveRecipientsWith(isOnWhatsApp func([]string) ([]types.IsOnWhatsAppResponse, error), lookupName func(string) (types.JID, error), inputs []string) []resolvedRecipient {
+	results := make([]resolvedRecipient, len(inputs))
+	var (
+		phones []string
+		err    error
+	)
+	for i, input := range inputs {
+		results[i].Input = input
+
+		// Names of contacts and groups
+		if isRecipientName(input) {
+			results[i].JID, results[i].Err = lookupName(input)
+			continue
+		}
+
+		// Check if this is a group JID (contains "@g.us")
+		if strings.Contains(input, "@g.us") {
+			results[i].JID, err = parseGroupJID(input)
+			results[i].Err = invalidRecipient(err)
+			continue
+		}
+
+		// Broadcast lists and status updates use "id@broadcast"
+		if strings.Contains(input, "@broadcast") {
+			results[i].JID, err = parseBroadcastJID(input)
+			results[i].Err = invalidRecipient(err)
+			continue
+		}
+
+		// Handle as individual contact
+		phones = append(phones, normalizePhoneNumber(input))
+	}
+
+	if len(phones) == 0 {
+		return results
+	}
+
+	// First verify the numbers are on WhatsApp
+	exists, err := isOnWhatsApp(phones)
+	if err != nil {
+		fmt.Printf("Warning: Error checking if number exists on WhatsApp: %v\n", err)
+	}
+
+	found := make(map[string]types.IsOnWhatsAppResponse, len(exists))
+	for _, response := range exists {
+		found[normalizePhoneNumber(response.Query)] = response
+	}
+	// A single answer belongs to the single query, whatever format the server echoes it in
+	if len(phones) == 1 && len(exists) == 1 {
+		found[phones[0]] = exists[0]
+	}
+
+	for i := range results {
+		if results[i].Err != nil || !results[i].JID.IsEmpty() {
+			continue
+		}
+
+		phoneNumber := normalizePhoneNumber(results[i].Input)
+		if err != nil {
+			// If we can't verify, try to construct the JID anyway
+			results[i].JID = types.NewJID(phoneNumber, types.DefaultUserServer)
+		} else if response, ok := found[phoneNumber]; ok && response.IsIn {
+			// Use the exact JID returned by the WhatsApp server
+			results[i].JID = response.JID
+		} else {
+			results[i].Err = invalidRecipient(fmt.Errorf("phone number %s not found on WhatsApp", phoneNumber))
+		}
+	}
+
+	return results
+}
+
+// formatRecipient formats a resolved JID the way recipients are given on the command line:
+// a phone number with a plus sign for users, and the full JID for anything else
+func formatRecipient(jid types.JID) string {
+	if jid.Server == types.DefaultUserServer {
+		return "+" + jid.User
+	}
+	return jid.String()
+}
+
+// parseGroupJID parses a group ID in the 'number@g.us' format
+func parseGroupJID(to string) (types.JID, error) {
+	to = strings.TrimSpace(to)
+	if strings.Count(to, "@") != 1 || !strings.HasSuffix(to, "@g.us") {
+		return types.EmptyJID, fmt.Errorf("invalid group ID format. Should be 'number@g.us'")
+	}
+
+	parts := strings.Split(to, "@")
+	return types.NewJID(parts[0], types.GroupServer), nil
+}
+
+// parseBroadcastJID parses a broadcast JID in the 'id@broadcast' format.
+// WhatsApp only accepts messages to status@broadcast, other broadcast lists are rejected with a hint
+// to use the local lists of 'wavy broadcast' instead.
+func parseBroadcastJID(to string) (types.JID, error) {
+	to = strings.TrimSpace(to)
+	if strings.Count(to, "@") != 1 || !strings.HasSuffix(to, "@broadcast") {
+		return types.EmptyJID, fmt.Errorf("invalid broadcast ID format. Should be 'id@broadcast'")
+	}
+
+	jid := types.NewJID(strings.TrimSuffix(to, "@broadcast"), types.BroadcastServer)
+	if jid != types.StatusBroadcastJID {
+		return jid, fmt.Errorf("sending to WhatsApp broadcast list %s is not supported, use 'wavy broadcast' to send to a list of recipients", jid)
+	}
+	return jid, nil
+}
+
+// parseUserJID parses a full JID or a phone number without checking it against WhatsApp
+func parseUserJID(value string) (types.JID, error) {
+	if strings.Contains(value, "@") {
+		return types.ParseJID(strings.TrimSpace(value))
+	}
+	return types.NewJID(normalizePhoneNumber(value), types.DefaultUserServer), nil
+}
+
+// normalizePhoneNumber strips whitespace and the leading plus sign from a phone number
+func normalizePhoneNumber(phone string) string {
+	phone = strings.TrimSpace(phone)
+	return strings.TrimPrefix(phone, "+")
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+func TestParseGroupJID(t *testing.T) {
+	jid, err := parseGroupJID("123456789@g.us")
+	if err != nil {
+		t.Fatalf("parseGroupJID returned error: %v", err)
+	}
+	if jid.User != "123456789" || jid.Server != types.GroupServer {
+		t.Errorf("Expected 123456789@g.us, got %s", jid)
+	}
+
+	for _, invalid := range []string{"123@456@g.us", "123456789@s.whatsapp.net"} {
+		if _, err := parseGroupJID(invalid); err == nil {
+			t.Errorf("Expected error for %q, got nil", invalid)
+		}
+	}
+}
+
+func TestParseBroadcastJID(t *testing.T) {
+	jid, err := parseBroadcastJID("status@broadcast")
+	if err != nil || jid != types.StatusBroadcastJID {
+		t.Errorf("parseBroadcastJID(status@broadcast) = %s, %v", jid, err)
+	}
+
+	// Other broadcast lists are recognized but cannot be sent to
+	jid, err = parseBroadcastJID("1712345678@broadcast")
+	if err == nil || jid.Server != types.BroadcastServer {
+		t.Errorf("Expected a broadcast JID with an error, got %s, %v", jid, err)
+	}
+
+	if _, err := parseBroadcastJID("a@b@broadcast"); err == nil {
+		t.Error("Expected error for invalid broadcast ID")
+	}
+}
+
+func TestParseUserJID(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: "+1234567890", want: "1234567890@s.whatsapp.net"},
+		{input: " 1234567890 ", want: "1234567890@s.whatsapp.net"},
+		{input: "1234567890@s.whatsapp.net", want: "1234567890@s.whatsapp.net"},
+	}
+
+	for _, tt := range tests {
+		jid, err := parseUserJID(tt.input)
+		if err != nil {
+			t.Fatalf("parseUserJID(%q) returned error: %v", tt.input, err)
+		}
+		if jid.String() != tt.want {
+			t.Errorf("parseUserJID(%q) = %q, want %q", tt.input, jid.String(), tt.want)
+		}
+	}
+}
+
+func TestSplitRecipients(t *testing.T) {
+	got := splitRecipients([]string{"+15550100, 120363000000000000@g.us", "+15550101", "", "+15550100"})
+	want := []string{"+15550100", "120363000000000000@g.us", "+15550101"}
+	if len(got) != len(want) {
+		t.Fatalf("splitRecipients() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("splitRecipients()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestResolveRecipients(t *testing.T) {
+	calls := 0
+	lookup := func(phones []string) ([]types.IsOnWhatsAppResponse, error) {
+		calls++
+		if len(phones) != 2 {
+			t.Errorf("Expected both numbers in one lookup, got %v", phones)
+		}
+		return []types.IsOnWhatsAppResponse{
+			{Query: "+15550101", IsIn: false},
+			{Query: "+15550100", IsIn: true, JID: types.NewJID("15550100", types.DefaultUserServer)},
+		}, nil
+	}
+
+	results := resolveRecipientsWith(lookup, noNames, []string{"+15550100", "123456789@g.us", "15550101", "bad@x@g.us"})
+	if calls != 1 {
+		t.Errorf("Expected a single IsOnWhatsApp call, got %d", calls)
+	}
+
+	if results[0].Err != nil || results[0].JID.String() != "15550100@s.whatsapp.net" {
+		t.Errorf("Expected first recipient to resolve, got %+v", results[0])
+	}
+	if results[1].Err != nil || results[1].JID.Server != types.GroupServer {
+		t.Errorf("Expected group to resolve, got %+v", results[1])
+	}
+	if !isInvalidRecipient(results[2].Err) {
+		t.Errorf("Expected number not on WhatsApp to be invalid, got %+v", results[2])
+	}
+	if !isInvalidRecipient(results[3].Err) {
+		t.Errorf("Expected invalid group ID to be invalid, got %+v", results[3])
+	}
+}
+
+func TestResolveRecipientsNameLookupError(t *testing.T) {
+	// Failing to load the contacts may be temporary, unlike a name that matches nobody
+	lookupName := func(name string) (types.JID, error) {
+		return types.EmptyJID, errors.New("failed to get contacts: database is locked")
+	}
+
+	results := resolveRecipientsWith(nil, lookupName, []string{"Alice"})
+	if results[0].Err == nil || isInvalidRecipient(results[0].Err) {
+		t.Errorf("Expected a temporary error, got %+v", results[0])
+	}
+}
+
+func TestResolveRecipientsLookupError(t *testing.T) {
+	lookup := func(phones []string) ([]types.IsOnWhatsAppResponse, error) {
+		return nil, errors.New("timeout")
+	}
+
+	// Numbers that cannot be verified are still tried
+	results := resolveRecipientsWith(lookup, noNames, []string{"+15550100"})
+	if results[0].Err != nil || results[0].JID.String() != "15550100@s.whatsapp.net" {
+		t.Errorf("Expected fallback JID, got %+v", results[0])
+	}
+}
+
+// noNames is a name lookup for tests that only use phone numbers and group IDs
+func noNames(name string) (types.JID, error) {
+	return types.EmptyJID, fmt.Errorf("unexpected name lookup for %q", name)
+}
+
+func TestFormatRecipient(t *testing.T) {
+	tests := map[types.JID]string{
+		types.NewJID("15550100", types.DefaultUserServer): "+15550100",
+		types.NewJID("123456789", types.GroupServer):      "123456789@g.us",
+	}
+	for jid, want := range tests {
+		if got := formatRecipient(jid); got != want {
+			t.Errorf("formatRecipient(%s) = %q, want %q", jid, got, want)
+		}
+	}
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+
+	"whatsmeow-go/cmd/wavy/storage"
+)
+
+// maxListedCandidates is how many candidates an ambiguous name error lists
+const maxListedCandidates = 5
+
+// Scores of a name match, from the weakest to the strongest
+const (
+	matchNone = iota
+	matchFuzzy
+	matchWords
+	matchSubstring
+	matchPrefix
+	matchExact
+)
+
+// exactNames requires recipient names to match the name of a contact or group exactly, ignoring case
+var exactNames bool
+
+// recipientCandidate is a contact or group a recipient name can refer to
+type recipientCandidate struct {
+	Name string
+	JID  types.JID
+}
+
+// String formats a candidate for error messages
+func (c recipientCandidate) String() string {
+	if c.JID.Server == types.DefaultUserServer {
+		return fmt.Sprintf("%s (+%s)", c.Name, c.JID.User)
+	}
+	return fmt.Sprintf("%s (%s)", c.Name, c.JID)
+}
+
+// isPhoneNumber reports whether a recipient is written as a phone number rather than a name
+func isPhoneNumber(value string) bool {
+	digits := 0
+	for _, r := range value {
+		switch {
+		case unicode.IsDigit(r):
+			digits++
+		case r == '+' || r == '-' || r == '(' || r == ')' || r == '.' || unicode.IsSpace(r):
+		default:
+			return false
+		}
+	}
+	return digits > 0
+}
+
+// isRecipientName reports whether a recipient is the name of a contact or group
+func isRecipientName(value string) bool {
+	return !strings.Contains(value, "@") && !isPhoneNumber(value)
+}
+
+// normalizeName lowercases a name and collapses its whitespace for comparison
+func normalizeName(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
+
+// nameScore rates how well a query matches a name. Both must be normalized.
+func nameScore(query, name string) int {
+	switch {
+	case query == "" || name == "":
+		return matchNone
+	case name == query:
+		return matchExact
+	case strings.HasPrefix(name, query) || strings.Contains(name, " "+query):
+		// The query starts a word, "phoenix" matches "Team Phoenix" as well as "Phoenix Ops"
+		return matchPrefix
+	case strings.Contains(name, query):
+		return matchSubstring
+	}
+
+	// Every word of the query appears in the name, in any order
+	words := strings.Fields(query)
+	found := 0
+	for _, word := range words {
+		if strings.Contains(name, word) {
+			found++
+		}
+	}
+	if found == len(words) {
+		return matchWords
+	}
+
+	// Allow a typo for every four characters
+	if editDistance(query, name) <= max(1, len([]rune(query))/4) {
+		return matchFuzzy
+	}
+	return matchNone
+}
+
+// editDistance returns the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
+
+// matchRecipientName finds the contact or group a name refers to.
+// The best matches win; if several recipients match equally well, the error lists them.
+func matchRecipientName(candidates []recipientCandidate, name string, exact bool) (types.JID, error) {
+	query := normalizeName(name)
+
+	// A contact can have several names, keep the best match per recipient
+	best := make(map[types.JID]int)
+	names := make(map[types.JID]string)
+	for _, candidate := range candidates {
+		score := nameScore(query, normalizeName(candidate.Name))
+		if exact && score != matchExact {
+			continue
+		}
+		if score > best[candidate.JID] {
+			best[candidate.JID] = score
+			names[candidate.JID] = candidate.Name
+		}
+	}
+
+	top := matchNone
+	for _, score := range best {
+		top = max(top, score)
+	}
+
+	var matches []recipientCandidate
+	for jid, score := range best {
+		if score == top && top != matchNone {
+			matches = append(matches, recipientCandidate{Name: names[jid], JID: jid})
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		if exact {
+			return types.EmptyJID, fmt.Errorf("no contact or group is named %q", name)
+		}
+		return types.EmptyJID, fmt.Errorf("no contact or group matches %q", name)
+	case 1:
+		return matches[0].JID, nil
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Name != matches[j].Name {
+			return matches[i].Name < matches[j].Name
+		}
+		return matches[i].JID.String() < matches[j].JID.String()
+	})
+	listed := make([]string, 0, maxListedCandidates)
+	for _, match := range matches[:min(len(matches), maxListedCandidates)] {
+		listed = append(listed, match.String())
+	}
+	if len(matches) > maxListedCandidates {
+		listed = append(listed, fmt.Sprintf("and %d more", len(matches)-maxListedCandidates))
+	}
+	return types.EmptyJID, fmt.Errorf("%q matches several recipients: %s; use a more specific name or the phone number or group ID",
+		name, strings.Join(listed, ", "))
+}
+
+// contactCandidates returns a candidate for every name of the contacts with a phone number
+func contactCandidates(contacts map[types.JID]types.ContactInfo) []recipientCandidate {
+	var candidates []recipientCandidate
+	for jid, contact := range contacts {
+		if jid.Server != types.DefaultUserServer {
+			continue
+		}
+		for _, name := range []string{contact.FullName, contact.FirstName, contact.PushName, contact.BusinessName} {
+			if name != "" {
+				candidates = append(candidates, recipientCandidate{Name: name, JID: jid})
+			}
+		}
+	}
+	return candidates
+}
+
+// groupCandidates returns a candidate for every group
+func groupCandidates(groups []*types.GroupInfo) []recipientCandidate {
+	candidates := make([]recipientCandidate, 0, len(groups))
+	for _, group := range groups {
+		candidates = append(candidates, recipientCandidate{Name: group.Name, JID: group.JID})
+	}
+	return candidates
+}
+
+// joinedGroups returns the groups from the cache of 'wavy groups', fetching them from WhatsApp if it is outdated
+func joinedGroups(client *whatsmeow.Client) ([]*types.GroupInfo, error) {
+	db, err := loadStorage()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	now := time.Now()
+	groups, _, err := cachedGroups(db, now)
+	if err == nil {
+		return groups, nil
+	}
+	if !errors.Is(err, storage.ErrNotFound) {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	groups, err = client.GetJoinedGroups()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get groups: %w", err)
+	}
+	if err := cacheGroups(db, groups, now); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	return groups, nil
+}
+
+// resolveNameNow turns a contact or group name into the phone number or group ID it matches, for messages
+// that are stored and sent later. Matching the name now shows the user who it refers to, instead of matching
+// it again at send time, when it could find someone else. Other recipients are returned unchanged.
+func resolveNameNow(recipient string) (string, error) {
+	if !isRecipientName(recipient) {
+		return recipient, nil
+	}
+
+	client := connectClient(debug)
+	defer client.Disconnect()
+
+	jid, err := recipientNameLookup(client)(recipient)
+	if err != nil {
+		return "", err
+	}
+
+	resolved := formatRecipient(jid)
+	fmt.Printf("%q matches %s\n", recipient, resolved)
+	return resolved, nil
+}
+
+// findGroupByName returns the joined group with the given name, taking the groups from the cache of 'wavy groups'
+func findGroupByName(client *whatsmeow.Client, name string) (types.JID, error) {
+	groups, err := joinedGroups(client)
+	if err != nil {
+		return types.EmptyJID, err
+	}
+	return matchGroupName(groups, name)
+}
+
+// matchGroupName finds the group with the given name. Names must match exactly, ignoring case,
+// as commands that change a group should not act on a group that only looks similar.
+func matchGroupName(groups []*types.GroupInfo, name string) (types.JID, error) {
+	return matchRecipientName(groupCandidates(groups), name, true)
+}
+
+// recipientCandidates returns the names of the contacts and groups recipients can be addressed by
+func recipientCandidates(client *whatsmeow.Client) ([]recipientCandidate, error) {
+	contacts, err := client.Store.Contacts.GetAllContacts(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get contacts: %w", err)
+	}
+
+	groups, err := joinedGroups(client)
+	if err != nil {
+		return nil, err
+	}
+	return append(contactCandidates(contacts), groupCandidates(groups)...), nil
+}
+
+// recipientNameLookup returns a function that finds recipients by name in the contact store and the joined groups.
+// The contacts and groups are only loaded when the first name is looked up.
+func recipientNameLookup(client *whatsmeow.Client) func(name string) (types.JID, error) {
+	var (
+		candidates []recipientCandidate
+		err        error
+		loaded     bool
+	)
+	return func(name string) (types.JID, error) {
+		if !loaded {
+			loaded = true
+			candidates, err = recipientCandidates(client)
+		}
+		if err != nil {
+			return types.EmptyJID, err
+		}
+		jid, err := matchRecipientName(candidates, name, exactNames)
+		return jid, invalidRecipient(err)
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+func testCandidates() []recipientCandidate {
+	alice := types.NewJID("15550100", types.DefaultUserServer)
+	alicia := types.NewJID("15550101", types.DefaultUserServer)
+	bob := types.NewJID("15550102", types.DefaultUserServer)
+	return []recipientCandidate{
+		{Name: "Alice Smith", JID: alice},
+		{Name: "Ali", JID: alice},
+		{Name: "Alicia Jones", JID: alicia},
+		{Name: "Bob Martin", JID: bob},
+		{Name: "Bobby", JID: bob},
+		{Name: "Team Phoenix", JID: types.NewJID("120363001", types.GroupServer)},
+		{Name: "Phoenix Ops", JID: types.NewJID("120363002", types.GroupServer)},
+	}
+}
+
+func TestIsRecipientName(t *testing.T) {
+	tests := map[string]bool{
+		"+1 (555) 010-0100": false,
+		"15550100":          false,
+		"123456789@g.us":    false,
+		"status@broadcast":  false,
+		"Alice Smith":       true,
+		"team 2":            true,
+	}
+	for value, want := range tests {
+		if got := isRecipientName(value); got != want {
+			t.Errorf("isRecipientName(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
+
+func TestMatchRecipientName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "alice smith", want: "15550100@s.whatsapp.net"},
+		// An exact name beats a prefix of another contact
+		{name: "ALI", want: "15550100@s.whatsapp.net"},
+		{name: "alicia", want: "15550101@s.whatsapp.net"},
+		{name: "smith", want: "15550100@s.whatsapp.net"},
+		{name: "phoenix team", want: "120363001@g.us"},
+		{name: "bob martni", want: "15550102@s.whatsapp.net"},
+		{name: "team phoenix", want: "120363001@g.us"},
+	}
+
+	for _, tt := range tests {
+		jid, err := matchRecipientName(testCandidates(), tt.name, false)
+		if err != nil {
+			t.Errorf("matchRecipientName(%q) failed: %v", tt.name, err)
+			continue
+		}
+		if jid.String() != tt.want {
+			t.Errorf("matchRecipientName(%q) = %s, want %s", tt.name, jid, tt.want)
+		}
+	}
+}
+
+func TestMatchRecipientNameAmbiguous(t *testing.T) {
+	_, err := matchRecipientName(testCandidates(), "phoenix", false)
+	if err == nil {
+		t.Fatal("Expected an error for a name matching two groups")
+	}
+	for _, want := range []string{"Phoenix Ops (120363002@g.us)", "Team Phoenix (120363001@g.us)"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected the error to list %q, got %v", want, err)
+		}
+	}
+
+	if _, err := matchRecipientName(testCandidates(), "carol", false); err == nil {
+		t.Error("Expected an error for a name matching nobody")
+	}
+}
+
+func TestMatchRecipientNameExact(t *testing.T) {
+	if _, err := matchRecipientName(testCandidates(), "alice", true); err == nil {
+		t.Error("Expected --exact to reject a prefix")
+	}
+
+	jid, err := matchRecipientName(testCandidates(), "  bobby ", true)
+	if err != nil || jid.User != "15550102" {
+		t.Errorf("Expected an exact name to match, got %s, %v", jid, err)
+	}
+}
+
+func TestMatchGroupName(t *testing.T) {
+	groups := []*types.GroupInfo{
+		{JID: types.NewJID("1", types.GroupServer), GroupName: types.GroupName{Name: "Book club"}},
+		{JID: types.NewJID("2", types.GroupServer), GroupName: types.GroupName{Name: "Book club fans"}},
+	}
+
+	jid, err := matchGroupName(groups, "BOOK CLUB")
+	if err != nil || jid.User != "1" {
+		t.Errorf("Expected the name to match ignoring case, got %s, %v", jid, err)
+	}
+	if _, err := matchGroupName(groups, "Book"); err == nil {
+		t.Error("Expected a partial name not to match")
+	}
+
+	groups = append(groups, &types.GroupInfo{JID: types.NewJID("3", types.GroupServer), GroupName: types.GroupName{Name: "book club"}})
+	if _, err := matchGroupName(groups, "Book club"); err == nil {
+		t.Error("Expected an error for an ambiguous name")
+	}
+}
+
+func TestContactCandidates(t *testing.T) {
+	contacts := map[types.JID]types.ContactInfo{
+		types.NewJID("15550100", types.DefaultUserServer): {Found: true, FullName: "Alice Smith", PushName: "Ali"},
+		types.NewJID("42", types.HiddenUserServer):        {Found: true, PushName: "Alice"},
+	}
+
+	candidates := contactCandidates(contacts)
+	if len(candidates) != 2 {
+		t.Fatalf("Expected the 2 names of the contact with a phone number, got %+v", candidates)
+	}
+	for _, candidate := range candidates {
+		if candidate.JID.Server != types.DefaultUserServer {
+			t.Errorf("Expected only phone number contacts, got %s", candidate.JID)
+		}
+	}
+}
+
+func TestResolveRecipientsNames(t *testing.T) {
+	lookup := func(phones []string) ([]types.IsOnWhatsAppResponse, error) {
+		t.Errorf("Expected no IsOnWhatsApp call for names, got %v", phones)
+		return nil, nil
+	}
+	names := func(name string) (types.JID, error) {
+		return matchRecipientName(testCandidates(), name, false)
+	}
+
+	results := resolveRecipientsWith(lookup, names, []string{"Bob Martin", "phoenix"})
+	if results[0].Err != nil || results[0].JID.User != "15550102" {
+		t.Errorf("Expected the contact to resolve, got %+v", results[0])
+	}
+	if results[1].Err == nil {
+		t.Errorf("Expected the ambiguous name to fail, got %+v", results[1])
+	}
+}
@@ -0,0 +1,368 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"whatsmeow-go/cmd/wavy/common"
+	"whatsmeow-go/cmd/wavy/storage"
+)
+
+var (
+	scheduleAt       string
+	scheduleIfMissed string
+	scheduleAll      bool
+	scheduleInterval time.Duration
+	scheduleGrace    time.Duration
+	scheduleOnce     bool
+)
+
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Schedule messages to be sent later",
+	Long: `Queue messages to be sent at a specific time. Scheduled messages are stored in the
+data directory and delivered by 'wavy schedule run'.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var scheduleAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Schedule a message",
+	Long: `Schedule a text message. The time can be an RFC 3339 timestamp (2025-07-01T08:00:00-03:00),
+a local date and time (2025-07-01 08:00), a time of day (08:00, tomorrow 08:00) or a
+relative duration (+2h, in 30m, 1d).`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if to == "" || msg == "" || scheduleAt == "" {
+			cmd.Help()
+			os.Exit(1)
+		}
+
+		runScheduleAdd()
+	},
+}
+
+var scheduleListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List scheduled messages",
+	Run: func(cmd *cobra.Command, args []string) {
+		runScheduleList()
+	},
+}
+
+var scheduleCancelCmd = &cobra.Command{
+	Use:   "cancel [id]",
+	Short: "Cancel a scheduled message",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			cmd.Help()
+			os.Exit(1)
+		}
+
+		runScheduleCancel(args[0])
+	},
+}
+
+var scheduleRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Send scheduled messages when they are due",
+	Long: `Check for due messages and send them. WhatsApp is only connected while messages are due.
+Messages whose time passed more than the grace period ago, for example because the worker was
+not running, are sent late or skipped according to their --if-missed policy.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runScheduleRun()
+	},
+}
+
+func init() {
+	scheduleAddCmd.Flags().StringVarP(&to, "to", "t", "", "Recipient (phone number, group ID, name or alias)")
+	scheduleAddCmd.Flags().BoolVar(&exactNames, "exact", false, "Require a recipient name to match a contact or group name exactly")
+	scheduleAddCmd.Flags().StringVarP(&msg, "msg", "m", "", "Message text to send")
+	scheduleAddCmd.Flags().StringVar(&scheduleAt, "at", "", "When to send the message")
+	scheduleAddCmd.Flags().StringVar(&scheduleIfMissed, "if-missed", storage.MissedSend, "What to do if the send time was missed: send or skip")
+	scheduleAddCmd.Flags().BoolVarP(&debug, "debug", "d", false, "Enable verbose debugging")
+
+	scheduleListCmd.Flags().BoolVarP(&scheduleAll, "all", "a", false, "Include sent, failed and cancelled messages")
+
+	scheduleRunCmd.Flags().DurationVar(&scheduleInterval, "interval", 30*time.Second, "How often to check for due messages")
+	scheduleRunCmd.Flags().DurationVar(&scheduleGrace, "grace", 5*time.Minute, "How late a message can be before it counts as missed")
+	scheduleRunCmd.Flags().BoolVar(&scheduleOnce, "once", false, "Send the due messages and exit")
+	scheduleRunCmd.Flags().BoolVarP(&debug, "debug", "d", false, "Enable verbose debugging")
+	scheduleRunCmd.Flags().IntVarP(&wait, "wait", "w", 5, "Seconds to wait for message confirmation")
+
+	scheduleCmd.AddCommand(scheduleAddCmd)
+	scheduleCmd.AddCommand(scheduleListCmd)
+	scheduleCmd.AddCommand(scheduleCancelCmd)
+	scheduleCmd.AddCommand(scheduleRunCmd)
+}
+
+// parseScheduleTime parses an absolute or relative send time
+func parseScheduleTime(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+
+	// A mistyped date in the past would be sent at once or skipped, depending on --if-missed
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return futureTime(value, t, now)
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04", value, now.Location()); err == nil {
+		return futureTime(value, t, now)
+	}
+
+	// Relative durations: +2h, in 30m, 1d
+	relative := strings.TrimPrefix(strings.TrimPrefix(value, "+"), "in ")
+	if d, err := parseDuration(relative); err == nil {
+		if d <= 0 {
+			return time.Time{}, fmt.Errorf("relative time %q must be in the future", value)
+		}
+		return now.Add(d), nil
+	}
+
+	// Time of day: 08:00 or tomorrow 08:00
+	clock, tomorrow := strings.CutPrefix(value, "tomorrow ")
+	if t, err := time.ParseInLocation("15:04", strings.TrimSpace(clock), now.Location()); err == nil {
+		next := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location())
+		if tomorrow {
+			next = next.AddDate(0, 0, 1)
+		} else if !next.After(now) {
+			next = next.AddDate(0, 0, 1)
+		}
+		return next, nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time %q", value)
+}
+
+// futureTime returns t if it is after now, and an error otherwise
+func futureTime(value string, t, now time.Time) (time.Time, error) {
+	if !t.After(now) {
+		return time.Time{}, fmt.Errorf("time %q is in the past", value)
+	}
+	return t, nil
+}
+
+// parseDuration parses a Go duration, also accepting whole days such as 1d
+func parseDuration(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(value)
+}
+
+func runScheduleAdd() {
+	sendAt, err := parseScheduleTime(scheduleAt, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if scheduleIfMissed != storage.MissedSend && scheduleIfMissed != storage.MissedSkip {
+		fmt.Fprintf(os.Stderr, "Error: --if-missed must be %q or %q\n", storage.MissedSend, storage.MissedSkip)
+		os.Exit(1)
+	}
+
+	// Aliases and names are resolved now, so later changes to them do not redirect the message
+	recipient, err := resolveAlias(to)
+	if err == nil {
+		recipient, err = resolveNameNow(recipient)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	db := openStorage()
+	defer db.Close()
+
+	id, err := db.AddScheduledMessage(storage.ScheduledMessage{
+		Recipient: recipient,
+		Message:   msg,
+		SendAt:    sendAt,
+		IfMissed:  scheduleIfMissed,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Message %d scheduled for %s\n", id, sendAt.Format(time.RFC1123))
+	fmt.Println("Make sure 'wavy schedule run' is running to deliver it.")
+}
+
+func runScheduleList() {
+	db := openStorage()
+	defer db.Close()
+
+	messages, err := db.ListScheduledMessages(scheduleAll)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(messages) == 0 {
+		fmt.Println("No scheduled messages")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSEND AT\tTO\tSTATUS\tMESSAGE")
+	for _, m := range messages {
+		status := m.Status
+		if m.Error != "" {
+			status += " (" + m.Error + ")"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", m.ID, m.SendAt.Format("2006-01-02 15:04"), m.Recipient, status, truncate(m.Message, 40))
+	}
+	w.Flush()
+}
+
+func runScheduleCancel(value string) {
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid ID %q\n", value)
+		os.Exit(1)
+	}
+
+	db := openStorage()
+	defer db.Close()
+
+	err = db.CancelScheduledMessage(id)
+	if errors.Is(err, storage.ErrNotFound) {
+		fmt.Fprintf(os.Stderr, "No pending scheduled message with ID %d\n", id)
+		os.Exit(1)
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Scheduled message %d cancelled\n", id)
+}
+
+func runScheduleRun() {
+	db := openStorage()
+	defer db.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if !scheduleOnce {
+		fmt.Printf("Checking for scheduled messages every %s. Press Ctrl+C to stop.\n", scheduleInterval)
+	}
+
+	for {
+		if err := sendDueMessages(db, time.Now()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			if scheduleOnce {
+				os.Exit(1)
+			}
+		}
+
+		if scheduleOnce {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			fmt.Println("\nStopping scheduler...")
+			return
+		case <-time.After(scheduleInterval):
+		}
+	}
+}
+
+// sendDueMessages sends all due messages, connecting to WhatsApp only if there are any
+func sendDueMessages(db *storage.DB, now time.Time) error {
+	// Messages claimed by a crashed worker are pending again, and are sent or skipped like other missed messages
+	config, err := common.LoadConfig()
+	if err != nil {
+		return err
+	}
+	if _, err := db.RequeueStaleScheduledMessages(now.Add(-claimTimeout(config.RateLimit))); err != nil {
+		return err
+	}
+
+	due, err := db.DueScheduledMessages(now)
+	if err != nil || len(due) == 0 {
+		return err
+	}
+
+	// Missed messages with the skip policy don't need a connection
+	var toSend []storage.ScheduledMessage
+	for _, m := range due {
+		if isMissed(m, now, scheduleGrace) && m.IfMissed == storage.MissedSkip {
+			if err := db.ClaimScheduledMessage(m.ID); err != nil {
+				continue
+			}
+			fmt.Printf("Skipping message %d, it was due at %s\n", m.ID, m.SendAt.Format(time.RFC1123))
+			if err := db.FinishScheduledMessage(m.ID, storage.StatusSkipped, "", "missed send time"); err != nil {
+				return err
+			}
+			continue
+		}
+		toSend = append(toSend, m)
+	}
+
+	if len(toSend) == 0 {
+		return nil
+	}
+
+	client, err := openClient(debug)
+	if err != nil {
+		// The messages stay pending and are retried on the next check
+		return err
+	}
+	defer client.Disconnect()
+
+	for _, m := range toSend {
+		// Another worker may have sent it in the meantime
+		if err := db.ClaimScheduledMessage(m.ID); err != nil {
+			continue
+		}
+
+		resp, err := deliverText(client, m.Recipient, m.Message)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to send message %d: %v\n", m.ID, err)
+			if err := db.FinishScheduledMessage(m.ID, storage.StatusFailed, "", err.Error()); err != nil {
+				return err
+			}
+			continue
+		}
+
+		fmt.Printf("Sent message %d to %s (ID: %s)\n", m.ID, m.Recipient, resp.ID)
+		if err := db.FinishScheduledMessage(m.ID, storage.StatusSent, resp.ID, ""); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isMissed reports whether a message is later than the grace period allows
+func isMissed(m storage.ScheduledMessage, now time.Time, grace time.Duration) bool {
+	return now.Sub(m.SendAt) > grace
+}
+
+// truncate shortens text to at most n runes for table output
+func truncate(text string, n int) string {
+	text = strings.ReplaceAll(text, "\n", " ")
+	runes := []rune(text)
+	if len(runes) <= n {
+		return text
+	}
+	return string(runes[:n-1]) + "…"
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"whatsmeow-go/cmd/wavy/storage"
+)
+
+func TestParseScheduleTime(t *testing.T) {
+	now := time.Date(2025, 6, 10, 14, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"2025-07-01T08:00:00Z", time.Date(2025, 7, 1, 8, 0, 0, 0, time.UTC)},
+		{"2025-07-01 08:00", time.Date(2025, 7, 1, 8, 0, 0, 0, time.UTC)},
+		{"+2h", now.Add(2 * time.Hour)},
+		{"in 30m", now.Add(30 * time.Minute)},
+		{"1d", now.Add(24 * time.Hour)},
+		{"16:00", time.Date(2025, 6, 10, 16, 0, 0, 0, time.UTC)},
+		{"08:00", time.Date(2025, 6, 11, 8, 0, 0, 0, time.UTC)},
+		{"tomorrow 16:00", time.Date(2025, 6, 11, 16, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		got, err := parseScheduleTime(tt.value, now)
+		if err != nil {
+			t.Errorf("parseScheduleTime(%q) returned error: %v", tt.value, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseScheduleTime(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+
+	for _, value := range []string{"", "soon", "-2h", "25:00", "xd", "2025-06-10 14:00", "2025-06-01T08:00:00Z"} {
+		if _, err := parseScheduleTime(value, now); err == nil {
+			t.Errorf("parseScheduleTime(%q) should have failed", value)
+		}
+	}
+}
+
+func TestIsMissed(t *testing.T) {
+	now := time.Date(2025, 6, 10, 14, 30, 0, 0, time.UTC)
+
+	onTime := storage.ScheduledMessage{SendAt: now.Add(-time.Minute)}
+	if isMissed(onTime, now, 5*time.Minute) {
+		t.Error("message within the grace period should not be missed")
+	}
+
+	late := storage.ScheduledMessage{SendAt: now.Add(-time.Hour)}
+	if !isMissed(late, now, 5*time.Minute) {
+		t.Error("message an hour late should be missed")
+	}
+}
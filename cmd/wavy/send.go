@@ -1,18 +1,18 @@
 package main
 
 import (
-	"context"
 	"fmt"
+	"io"
 	"os"
 	"strings"
-	"time"
+	"text/tabwriter"
 
 	"github.com/spf13/cobra"
+	"go.mau.fi/whatsmeow"
 	//nolint:staticcheck // Using deprecated package for compatibility
 	waProto "go.mau.fi/whatsmeow/binary/proto"
 	"go.mau.fi/whatsmeow/types"
-
-	"whatsmeow-go/cmd/wavy/common"
+	"google.golang.org/protobuf/proto"
 )
 
 var (
@@ -20,22 +20,61 @@ var (
 	msg   string
 	debug bool
 	wait  int
+
+	locationCoords  string
+	locationFile    string
+	locationName    string
+	locationAddress string
+	locationLive    bool
+
+	contactFiles []string
+	contactName  string
+	contactPhone string
+
+	stickerFile string
+	voiceFile   string
+
+	noPreview bool
+
+	viewOnce  bool
+	ephemeral string
+
+	enqueue        bool
+	idempotencyKey string
+
+	typing bool
+
+	sendRecipients []string
 )
 
 var sendCmd = &cobra.Command{
 	Use:   "send [recipient] [message]",
 	Short: "Send a WhatsApp message",
-	Long:  `Send a WhatsApp message to a contact or group.`,
+	Long: `Send a WhatsApp message to a contact or group.
+
+Several recipients can be given by repeating --to or separating them with commas.
+
+Recipients can also be the names of contacts and groups. Names are matched loosely, ignoring case,
+small typos and word order; the best match is used and an error lists the candidates if several
+match equally well. Use --exact to only accept exact names.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Handle positional arguments if provided
-		if len(args) >= 2 && to == "" {
-			to = args[0]
+		if len(args) >= 2 && len(sendRecipients) == 0 {
+			sendRecipients = []string{args[0]}
 			msg = args[1]
+		} else if len(args) >= 1 && len(sendRecipients) == 0 && hasNonTextContent() {
+			sendRecipients = []string{args[0]}
 		} else if len(args) >= 1 && msg == "" {
 			msg = args[0]
 		}
 
-		if to == "" || msg == "" {
+		recipients, err := parseRecipientList(sendRecipients)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		sendRecipients = recipients
+		if len(sendRecipients) == 0 || (msg == "" && !hasNonTextContent()) {
 			cmd.Help()
 			os.Exit(1)
 		}
@@ -45,104 +84,339 @@ var sendCmd = &cobra.Command{
 }
 
 func init() {
-	sendCmd.Flags().StringVarP(&to, "to", "t", "", "Recipient (phone number or group ID)")
+	sendCmd.Flags().StringArrayVarP(&sendRecipients, "to", "t", nil, "Recipient (phone number, group ID, alias, or contact or group name), can be repeated or comma-separated")
+	sendCmd.Flags().BoolVar(&exactNames, "exact", false, "Require recipient names to match a contact or group name exactly")
 	sendCmd.Flags().StringVarP(&msg, "msg", "m", "", "Message text to send")
 	sendCmd.Flags().BoolVarP(&debug, "debug", "d", false, "Enable verbose debugging")
 	sendCmd.Flags().IntVarP(&wait, "wait", "w", 5, "Seconds to wait for message confirmation")
+	sendCmd.Flags().StringVar(&locationCoords, "location", "", "Send a location as 'latitude,longitude'")
+	sendCmd.Flags().StringVar(&locationFile, "location-file", "", "Send the location from a GeoJSON Point file")
+	sendCmd.Flags().StringVar(&locationName, "location-name", "", "Name of the location")
+	sendCmd.Flags().StringVar(&locationAddress, "location-address", "", "Address of the location")
+	sendCmd.Flags().BoolVar(&locationLive, "live", false, "Send the location as a live location snapshot")
+	sendCmd.Flags().StringArrayVar(&contactFiles, "contact", nil, "Send the contact cards from a vCard file (can be repeated)")
+	sendCmd.Flags().StringVar(&contactName, "contact-name", "", "Name for a generated contact card")
+	sendCmd.Flags().StringVar(&contactPhone, "contact-phone", "", "Phone number for a generated contact card")
+	sendCmd.Flags().StringVar(&stickerFile, "sticker", "", "Send a PNG, JPEG, GIF or WebP image as a sticker")
+	sendCmd.Flags().StringVar(&voiceFile, "voice", "", "Send an Ogg/Opus file as a voice note")
+	sendCmd.Flags().BoolVar(&noPreview, "no-preview", false, "Do not generate a preview for links in the message")
+	sendCmd.Flags().BoolVar(&viewOnce, "view-once", false, "Allow the voice note to be played only once")
+	sendCmd.Flags().StringVar(&ephemeral, "ephemeral", "", "Make the message disappear after 24h, 7d or 90d")
+	sendCmd.Flags().BoolVar(&typing, "typing", false, "Show a typing indicator before sending, as a person would")
+	sendCmd.Flags().BoolVar(&enqueue, "enqueue", false, "Queue the message in the outbox instead of sending it now")
+	sendCmd.Flags().StringVar(&idempotencyKey, "idempotency-key", "", "Queue the message only if no message with this key was queued before")
+}
+
+// sendContentKinds returns the flags of the non-text message kinds requested on the command line
+func sendContentKinds() []string {
+	var kinds []string
+	if locationCoords != "" || locationFile != "" {
+		kinds = append(kinds, "--location")
+	}
+	if len(contactFiles) > 0 || contactName != "" || contactPhone != "" {
+		kinds = append(kinds, "--contact")
+	}
+	if stickerFile != "" {
+		kinds = append(kinds, "--sticker")
+	}
+	if voiceFile != "" {
+		kinds = append(kinds, "--voice")
+	}
+	return kinds
+}
+
+// hasNonTextContent reports whether the send flags describe something other than a plain text message
+func hasNonTextContent() bool {
+	return len(sendContentKinds()) > 0
+}
+
+// buildSendMessage builds the message described by the send flags, including its delivery options.
+// If the message contains media, the returned upload must be completed before sending.
+func buildSendMessage() (*waProto.Message, *mediaUpload, error) {
+	message, upload, err := buildSendContent()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if ephemeral != "" {
+		expiration, err := parseDisappearingTimer(ephemeral)
+		if err != nil {
+			return nil, nil, err
+		}
+		if expiration == 0 {
+			return nil, nil, fmt.Errorf("--ephemeral needs a duration of 24h, 7d or 90d")
+		}
+		if err := applyEphemeral(message, expiration); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if viewOnce {
+		message, err = applyViewOnce(message)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return message, upload, nil
+}
+
+// buildSendContent builds the content of the message described by the send flags
+func buildSendContent() (*waProto.Message, *mediaUpload, error) {
+	kinds := sendContentKinds()
+	if len(kinds) > 1 {
+		return nil, nil, fmt.Errorf("%s cannot be combined in one message", strings.Join(kinds, " and "))
+	}
+
+	switch {
+	case len(kinds) == 0:
+		message, upload := buildTextMessage(msg, !noPreview)
+		return message, upload, nil
+	case kinds[0] == "--location":
+		loc, err := sendLocation()
+		if err != nil {
+			return nil, nil, err
+		}
+		return buildLocationMessage(loc, msg, locationLive), nil, nil
+	case kinds[0] == "--contact":
+		cards, err := loadContactCards(contactFiles, contactName, contactPhone)
+		if err != nil {
+			return nil, nil, err
+		}
+		return buildContactMessage(cards), nil, nil
+	case kinds[0] == "--sticker":
+		return buildStickerMessage(stickerFile)
+	case kinds[0] == "--voice":
+		return buildVoiceMessage(voiceFile)
+	}
+
+	return nil, nil, fmt.Errorf("unsupported message kind %s", kinds[0])
+}
+
+// sendLocation reads the location from the --location or --location-file flags.
+// Name and address flags override the ones found in a GeoJSON feature.
+func sendLocation() (*location, error) {
+	if locationCoords != "" && locationFile != "" {
+		return nil, fmt.Errorf("use either --location or --location-file, not both")
+	}
+
+	loc := &location{}
+	if locationFile != "" {
+		var err error
+		loc, err = loadGeoJSONPoint(locationFile)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		lat, lng, err := parseCoordinates(locationCoords)
+		if err != nil {
+			return nil, err
+		}
+		loc.Latitude, loc.Longitude = lat, lng
+	}
+
+	if locationName != "" {
+		loc.Name = locationName
+	}
+	if locationAddress != "" {
+		loc.Address = locationAddress
+	}
+	return loc, nil
 }
 
 func runSend() {
-	// Create client
-	client, needsSetup, err := common.CreateWAClient(debug)
+	if typing && enqueue {
+		fmt.Fprintf(os.Stderr, "Error: --typing cannot be used with --enqueue\n")
+		os.Exit(1)
+	}
+
+	if idempotencyKey != "" && !enqueue {
+		fmt.Fprintf(os.Stderr, "Error: --idempotency-key can only be used with --enqueue\n")
+		os.Exit(1)
+	}
+
+	// Images and videos cannot be sent yet, so voice notes are the only media that can be viewed once
+	if viewOnce && voiceFile == "" {
+		fmt.Fprintf(os.Stderr, "Error: --view-once can only be used with --voice\n")
+		os.Exit(1)
+	}
+
+	// Prepare the message before connecting, so invalid input fails fast
+	message, upload, err := buildSendMessage()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error creating client: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	if needsSetup {
-		fmt.Fprintf(os.Stderr, "No WhatsApp session found. Please run 'wavy setup' first.\n")
+	if enqueue {
+		runEnqueue(message, upload)
+		return
+	}
+
+	client := connectClient(debug)
+
+	// Determine recipient types and parse the JIDs, checking all phone numbers at once
+	recipients := resolveRecipients(client, sendRecipients)
+	single := len(recipients) == 1
+	if single && recipients[0].Err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", recipients[0].Err)
 		os.Exit(1)
 	}
 
-	// Connect to WhatsApp
-	err = client.Connect()
+	results, err := sendToAll(client, recipients, message, upload)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to connect: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Print own ID for debugging
-	if debug {
-		fmt.Printf("Connected as JID: %s\n", client.Store.ID)
+	// Disconnect client after sending
+	client.Disconnect()
+
+	if single && results[0].Err != nil {
+		fmt.Fprintf(os.Stderr, "Error sending message: %v\n", results[0].Err)
+		os.Exit(1)
 	}
 
-	// Determine recipient type and parse the JID
-	var recipient types.JID
+	if single {
+		resp := results[0].Response
+		fmt.Printf("Message sent successfully to %s, server response: %v\n", recipients[0].JID.String(), resp)
+		fmt.Printf("Message ID: %s\n", resp.ID)
+		return
+	}
 
-	// Check if this is a group JID (contains "@g.us")
-	if strings.Contains(to, "@g.us") {
-		// Parse directly as a group JID
-		if strings.Count(to, "@") != 1 {
-			fmt.Fprintf(os.Stderr, "Invalid group ID format. Should be 'number@g.us'\n")
-			os.Exit(1)
+	if failed := printSendResults(os.Stdout, results); failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// sendToAll uploads the media of a message once and sends the message to every resolved recipient.
+// Recipients that failed to resolve are skipped with their error. An error is only returned if the upload fails.
+// A failed link preview thumbnail upload only drops the thumbnail.
+func sendToAll(client *whatsmeow.Client, recipients []resolvedRecipient, message *waProto.Message, upload *mediaUpload) ([]sendResult, error) {
+	// Upload media once before sending, it is not limited by the confirmation timeout.
+	// The uploaded file is shared by all recipients.
+	if upload != nil {
+		fmt.Println("Uploading media...")
+		if err := uploadMedia(client, message, upload); err != nil {
+			return nil, err
 		}
+	}
 
-		parts := strings.Split(to, "@")
-		recipient = types.JID{
-			User:   parts[0],
-			Server: "g.us",
+	if typing {
+		// Being online while typing is what a person's phone would show
+		if err := client.SendPresence(types.PresenceAvailable); err != nil {
+			fmt.Printf("Warning: could not set presence: %v\n", err)
 		}
+	}
 
-		if debug {
-			fmt.Printf("Sending to group: %s\n", recipient.String())
+	results := make([]sendResult, len(recipients))
+	for i, recipient := range recipients {
+		results[i] = sendResult{Recipient: recipient}
+		if recipient.Err != nil {
+			continue
 		}
-	} else {
-		// Handle as individual contact
-		phoneNumber := to
-		phoneNumber = strings.TrimSpace(phoneNumber)
-		phoneNumber = strings.TrimPrefix(phoneNumber, "+")
 
-		// First verify the number is on WhatsApp
-		exists, err := client.IsOnWhatsApp([]string{phoneNumber})
-		if err != nil {
-			fmt.Printf("Warning: Error checking if number exists on WhatsApp: %v\n", err)
-
-			// If we can't verify, try to construct the JID anyway
-			recipient = types.JID{
-				User:   phoneNumber,
-				Server: "s.whatsapp.net",
-			}
-		} else if len(exists) > 0 && exists[0].IsIn {
-			// Use the exact JID returned by the WhatsApp server
-			recipient = exists[0].JID
-		} else {
-			fmt.Fprintf(os.Stderr, "Error: Phone number %s not found on WhatsApp\n", phoneNumber)
-			os.Exit(1)
+		// Each recipient gets its own copy, as sending may modify the message
+		results[i].Response, results[i].Err = sendToRecipient(client, recipient.JID, proto.Clone(message).(*waProto.Message))
+	}
+
+	if typing {
+		if err := client.SendPresence(types.PresenceUnavailable); err != nil {
+			fmt.Printf("Warning: could not set presence: %v\n", err)
 		}
+	}
+
+	return results, nil
+}
 
-		if debug {
+// sendToRecipient waits for the rate limiter, shows the typing indicator if requested and sends the message
+func sendToRecipient(client *whatsmeow.Client, recipient types.JID, message *waProto.Message) (whatsmeow.SendResponse, error) {
+	if debug {
+		if recipient.Server == types.GroupServer {
+			fmt.Printf("Sending to group: %s\n", recipient.String())
+		} else {
 			fmt.Printf("Sending to individual contact: %s\n", recipient.String())
 		}
 	}
 
-	// Prepare the message
-	message := &waProto.Message{
-		Conversation: &msg,
+	// Wait for the rate limiter before typing, so the indicator is directly followed by the message
+	if err := throttle(client, recipient); err != nil {
+		return whatsmeow.SendResponse{}, err
 	}
 
-	// Send message with context and timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(wait)*time.Second)
-	defer cancel()
+	if typing {
+		simulateTyping(client, recipient, message)
+	}
 
 	fmt.Printf("Sending message to %s...\n", recipient.String())
-	resp, err := client.SendMessage(ctx, recipient, message)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error sending message: %v\n", err)
-		os.Exit(1)
+	return sendThrottled(client, recipient, message)
+}
+
+// sendResult is the outcome of sending to one recipient
+type sendResult struct {
+	Recipient resolvedRecipient
+	Response  whatsmeow.SendResponse
+	Err       error
+}
+
+// err returns the error resolving or sending to the recipient, if any
+func (r sendResult) err() error {
+	if r.Recipient.Err != nil {
+		return r.Recipient.Err
 	}
+	return r.Err
+}
 
-	fmt.Printf("Message sent successfully to %s, server response: %v\n", recipient.String(), resp)
+// printSendResults prints a table with the outcome per recipient and returns the number of failures
+func printSendResults(out io.Writer, results []sendResult) int {
+	failed := 0
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RECIPIENT\tJID\tSTATUS\tMESSAGE ID / ERROR")
+	for _, result := range results {
+		err := result.err()
 
-	// Disconnect client after sending
-	client.Disconnect()
+		jid := "-"
+		if !result.Recipient.JID.IsEmpty() {
+			jid = result.Recipient.JID.String()
+		}
+
+		if err != nil {
+			failed++
+			fmt.Fprintf(w, "%s\t%s\tfailed\t%v\n", result.Recipient.Input, jid, err)
+		} else {
+			fmt.Fprintf(w, "%s\t%s\tsent\t%s\n", result.Recipient.Input, jid, result.Response.ID)
+		}
+	}
+	w.Flush()
+
+	fmt.Fprintf(out, "\nSent to %d of %d recipients\n", len(results)-failed, len(results))
+	return failed
+}
+
+// runEnqueue stores the message in the outbox for delivery by 'wavy outbox flush' or 'wavy outbox watch'
+func runEnqueue(message *waProto.Message, upload *mediaUpload) {
+	db := openStorage()
+	defer db.Close()
+
+	// The recipients are queued with their aliases expanded, so changing an alias later does not redirect them
+	for _, recipient := range sendRecipients {
+		// Every recipient gets its own entry, so the key is made unique per recipient
+		key := idempotencyKey
+		if key != "" && len(sendRecipients) > 1 {
+			key += "/" + recipient
+		}
+
+		id, queued, err := enqueueMessage(db, recipient, message, upload, key)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if !queued {
+			fmt.Printf("A message with key %q is already queued as %d, not queuing it again\n", key, id)
+			continue
+		}
+		fmt.Printf("Message to %s queued as %d\n", recipient, id)
+	}
+	fmt.Println("Deliver queued messages with 'wavy outbox flush'.")
 }
@@ -0,0 +1,356 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"go.mau.fi/whatsmeow"
+	//nolint:staticcheck // Using deprecated package for compatibility
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"whatsmeow-go/cmd/wavy/preview"
+	"whatsmeow-go/cmd/wavy/storage"
+)
+
+// Audiences of a status update
+const (
+	audienceContacts = "contacts"
+	audienceAllow    = "allow-list"
+	audienceDeny     = "deny-list"
+)
+
+// statusAudiences maps the audience names to WhatsApp's status privacy types
+var statusAudiences = map[string]types.StatusPrivacyType{
+	audienceContacts: types.StatusPrivacyTypeContacts,
+	audienceAllow:    types.StatusPrivacyTypeWhitelist,
+	audienceDeny:     types.StatusPrivacyTypeBlacklist,
+}
+
+var (
+	statusText            string
+	statusBackground      string
+	statusFont            int
+	statusFile            string
+	statusRequireAudience string
+	statusLimit           int
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Post WhatsApp Status updates",
+	Long:  `Post text, image and video updates to your WhatsApp Status and list the updates posted with wavy.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var statusPostCmd = &cobra.Command{
+	Use:   "post",
+	Short: "Post a status update",
+	Long: `Post a text status with --text, or an image or video with --file (--text becomes the caption).
+
+Who sees the update is decided by the status privacy of your account, which is set in the WhatsApp
+app under Settings > Privacy > Status: all contacts, only the contacts on an allow list, or all
+contacts except those on a deny list. WhatsApp does not let linked devices change it, so wavy
+cannot choose the audience of an update. --require-audience only checks that the account is set
+to the given audience and refuses to post otherwise.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if statusText == "" && statusFile == "" {
+			cmd.Help()
+			os.Exit(1)
+		}
+
+		runStatusPost()
+	},
+}
+
+var statusAudienceCmd = &cobra.Command{
+	Use:   "audience",
+	Short: "Show who receives your status updates",
+	Run: func(cmd *cobra.Command, args []string) {
+		runStatusAudience()
+	},
+}
+
+var statusListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the status updates posted with wavy",
+	Run: func(cmd *cobra.Command, args []string) {
+		runStatusList()
+	},
+}
+
+func init() {
+	statusPostCmd.Flags().StringVar(&statusText, "text", "", "Text of the status, or caption of the file")
+	statusPostCmd.Flags().StringVar(&statusBackground, "background", "", "Background color of a text status, such as #1E88E5")
+	statusPostCmd.Flags().IntVar(&statusFont, "font", 0, "Font of a text status (0-2 or 6-10)")
+	statusPostCmd.Flags().StringVar(&statusFile, "file", "", "JPEG or PNG image, or MP4 video to post")
+	statusPostCmd.Flags().StringVar(&statusRequireAudience, "require-audience", "", "Refuse to post unless the account shares status with: contacts, allow-list or deny-list")
+	statusPostCmd.Flags().BoolVarP(&debug, "debug", "d", false, "Enable verbose debugging")
+	statusPostCmd.Flags().IntVarP(&wait, "wait", "w", 5, "Seconds to wait for message confirmation")
+
+	statusAudienceCmd.Flags().BoolVarP(&debug, "debug", "d", false, "Enable verbose debugging")
+
+	statusListCmd.Flags().IntVarP(&statusLimit, "limit", "n", 20, "Number of updates to show")
+
+	statusCmd.AddCommand(statusPostCmd)
+	statusCmd.AddCommand(statusAudienceCmd)
+	statusCmd.AddCommand(statusListCmd)
+}
+
+// parseColor parses a color in the #RRGGBB or #AARRGGBB format into ARGB.
+// Colors without alpha are opaque.
+func parseColor(value string) (uint32, error) {
+	hex := strings.TrimPrefix(strings.TrimSpace(value), "#")
+	if len(hex) != 6 && len(hex) != 8 {
+		return 0, fmt.Errorf("invalid color %q, use #RRGGBB or #AARRGGBB", value)
+	}
+
+	color, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid color %q, use #RRGGBB or #AARRGGBB", value)
+	}
+	if len(hex) == 6 {
+		color |= 0xFF000000
+	}
+	return uint32(color), nil
+}
+
+// buildTextStatus builds a text status with the given background color and font
+func buildTextStatus(text, background string, font int) (*waProto.Message, error) {
+	fontType := waProto.ExtendedTextMessage_FontType(font)
+	if fontType.Descriptor().Values().ByNumber(protoreflect.EnumNumber(font)) == nil {
+		return nil, fmt.Errorf("unknown font %d, use 0-2 or 6-10", font)
+	}
+
+	extended := &waProto.ExtendedTextMessage{
+		Text:        proto.String(text),
+		TextArgb:    proto.Uint32(0xFFFFFFFF),
+		Font:        fontType.Enum(),
+		PreviewType: waProto.ExtendedTextMessage_NONE.Enum(),
+	}
+
+	if background != "" {
+		color, err := parseColor(background)
+		if err != nil {
+			return nil, err
+		}
+		extended.BackgroundArgb = proto.Uint32(color)
+	}
+
+	return &waProto.Message{ExtendedTextMessage: extended}, nil
+}
+
+// buildMediaStatus builds an image or video status from a file, with an optional caption.
+// It returns the kind of status with the upload that must be completed before it is posted.
+func buildMediaStatus(data []byte, caption string) (*waProto.Message, *mediaUpload, string, error) {
+	mimeType := http.DetectContentType(data)
+
+	var (
+		message   *waProto.Message
+		mediaType whatsmeow.MediaType
+		kind      string
+	)
+	switch mimeType {
+	case "image/jpeg", "image/png":
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, nil, "", fmt.Errorf("failed to decode image: %w", err)
+		}
+		thumbnail, _, _, err := preview.EncodeThumbnail(img, preview.InlineThumbnailSize)
+		if err != nil {
+			return nil, nil, "", err
+		}
+
+		bounds := img.Bounds()
+		message = &waProto.Message{ImageMessage: &waProto.ImageMessage{
+			Mimetype:      proto.String(mimeType),
+			Caption:       optionalString(caption),
+			Width:         proto.Uint32(uint32(bounds.Dx())),
+			Height:        proto.Uint32(uint32(bounds.Dy())),
+			JPEGThumbnail: thumbnail,
+		}}
+		mediaType, kind = whatsmeow.MediaImage, "image"
+	case "video/mp4":
+		message = &waProto.Message{VideoMessage: &waProto.VideoMessage{
+			Mimetype: proto.String(mimeType),
+			Caption:  optionalString(caption),
+		}}
+		mediaType, kind = whatsmeow.MediaVideo, "video"
+	default:
+		return nil, nil, "", fmt.Errorf("unsupported file type %s, use a JPEG or PNG image or an MP4 video", mimeType)
+	}
+
+	upload := &mediaUpload{
+		Data:      data,
+		MediaType: mediaType,
+		apply:     uploadTarget(message),
+	}
+	return message, upload, kind, nil
+}
+
+// checkStatusAudience returns the audience the account posts status updates to,
+// or an error if an expected audience was given and the account is set to another one
+func checkStatusAudience(privacy []types.StatusPrivacy, expected string) (types.StatusPrivacy, error) {
+	// The first setting is the one WhatsApp uses for new updates
+	current := types.StatusPrivacy{Type: types.StatusPrivacyTypeContacts, IsDefault: true}
+	if len(privacy) > 0 {
+		current = privacy[0]
+	}
+
+	if expected == "" {
+		return current, nil
+	}
+	if statusAudiences[expected] != current.Type {
+		return current, fmt.Errorf("your status is shared with %s, not %s. Change it in the WhatsApp app under Settings > Privacy > Status",
+			describeStatusAudience(current), expected)
+	}
+	return current, nil
+}
+
+// describeStatusAudience describes a status privacy setting using the audience names
+func describeStatusAudience(privacy types.StatusPrivacy) string {
+	switch privacy.Type {
+	case types.StatusPrivacyTypeWhitelist:
+		return fmt.Sprintf("%s (%d contacts)", audienceAllow, len(privacy.List))
+	case types.StatusPrivacyTypeBlacklist:
+		return fmt.Sprintf("%s (%d contacts excluded)", audienceDeny, len(privacy.List))
+	default:
+		return audienceContacts
+	}
+}
+
+func runStatusPost() {
+	if _, ok := statusAudiences[statusRequireAudience]; statusRequireAudience != "" && !ok {
+		fmt.Fprintf(os.Stderr, "Error: invalid audience %q, use contacts, allow-list or deny-list\n", statusRequireAudience)
+		os.Exit(1)
+	}
+
+	// Prepare the update before connecting, so invalid input fails fast
+	var (
+		message *waProto.Message
+		upload  *mediaUpload
+		kind    = "text"
+		err     error
+	)
+	if statusFile != "" {
+		if statusBackground != "" || statusFont != 0 {
+			fmt.Fprintf(os.Stderr, "Error: --background and --font can only be used for text updates\n")
+			os.Exit(1)
+		}
+
+		data, readErr := os.ReadFile(statusFile)
+		if readErr != nil {
+			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", readErr)
+			os.Exit(1)
+		}
+		message, upload, kind, err = buildMediaStatus(data, statusText)
+	} else {
+		message, err = buildTextStatus(statusText, statusBackground, statusFont)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	client := connectClient(debug)
+	defer client.Disconnect()
+
+	privacy, err := client.GetStatusPrivacy()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error getting status privacy: %v\n", err)
+		os.Exit(1)
+	}
+	audience, err := checkStatusAudience(privacy, statusRequireAudience)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Posting %s status to %s...\n", kind, describeStatusAudience(audience))
+	resp, err := deliverMessage(client, types.StatusBroadcastJID, message, upload)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	db := openStorage()
+	defer db.Close()
+
+	_, err = db.AddStatusPost(storage.StatusPost{
+		MessageID: resp.ID,
+		Kind:      kind,
+		Text:      statusText,
+		Audience:  statusAudienceName(audience.Type),
+		PostedAt:  resp.Timestamp,
+	})
+	if err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+
+	fmt.Println("Status posted successfully")
+	fmt.Printf("Message ID: %s\n", resp.ID)
+}
+
+// statusAudienceName returns the audience name of a status privacy type
+func statusAudienceName(privacyType types.StatusPrivacyType) string {
+	for name, t := range statusAudiences {
+		if t == privacyType {
+			return name
+		}
+	}
+	return string(privacyType)
+}
+
+func runStatusAudience() {
+	client := connectClient(debug)
+	defer client.Disconnect()
+
+	privacy, err := client.GetStatusPrivacy()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error getting status privacy: %v\n", err)
+		os.Exit(1)
+	}
+
+	audience, _ := checkStatusAudience(privacy, "")
+	fmt.Printf("Status updates are shared with %s\n", describeStatusAudience(audience))
+	for _, jid := range audience.List {
+		fmt.Printf("  %s\n", jid.User)
+	}
+}
+
+func runStatusList() {
+	db := openStorage()
+	defer db.Close()
+
+	posts, err := db.ListStatusPosts(statusLimit)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(posts) == 0 {
+		fmt.Println("No status updates posted")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "POSTED\tKIND\tAUDIENCE\tMESSAGE ID\tTEXT")
+	for _, post := range posts {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", post.PostedAt.Format("2006-01-02 15:04"), post.Kind, post.Audience,
+			post.MessageID, truncate(post.Text, 40))
+	}
+	w.Flush()
+}
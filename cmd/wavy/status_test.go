@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"testing"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+)
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		input string
+		want  uint32
+	}{
+		{input: "#1E88E5", want: 0xFF1E88E5},
+		{input: "1e88e5", want: 0xFF1E88E5},
+		{input: "#801E88E5", want: 0x801E88E5},
+	}
+
+	for _, tt := range tests {
+		got, err := parseColor(tt.input)
+		if err != nil {
+			t.Fatalf("parseColor(%q) returned error: %v", tt.input, err)
+		}
+		if got != tt.want {
+			t.Errorf("parseColor(%q) = %#x, want %#x", tt.input, got, tt.want)
+		}
+	}
+
+	for _, invalid := range []string{"#FFF", "#GGGGGG", "red"} {
+		if _, err := parseColor(invalid); err == nil {
+			t.Errorf("Expected error for %q, got nil", invalid)
+		}
+	}
+}
+
+func TestBuildTextStatus(t *testing.T) {
+	message, err := buildTextStatus("Daily deal", "#1E88E5", 2)
+	if err != nil {
+		t.Fatalf("buildTextStatus returned error: %v", err)
+	}
+
+	extended := message.GetExtendedTextMessage()
+	if extended.GetText() != "Daily deal" || extended.GetBackgroundArgb() != 0xFF1E88E5 || int(extended.GetFont()) != 2 {
+		t.Errorf("Unexpected text status %v", extended)
+	}
+
+	if _, err := buildTextStatus("Daily deal", "", 4); err == nil {
+		t.Error("Expected error for unknown font")
+	}
+}
+
+func TestBuildMediaStatus(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 400, 200))); err != nil {
+		t.Fatal(err)
+	}
+
+	message, upload, kind, err := buildMediaStatus(buf.Bytes(), "New arrivals")
+	if err != nil {
+		t.Fatalf("buildMediaStatus returned error: %v", err)
+	}
+
+	img := message.GetImageMessage()
+	if kind != "image" || img.GetCaption() != "New arrivals" || img.GetWidth() != 400 || len(img.GetJPEGThumbnail()) == 0 {
+		t.Errorf("Unexpected image status %v", img)
+	}
+	if upload.MediaType != whatsmeow.MediaImage {
+		t.Errorf("Expected image upload, got %s", upload.MediaType)
+	}
+
+	upload.apply(whatsmeow.UploadResponse{DirectPath: "/v/image", FileLength: 42})
+	if img.GetDirectPath() != "/v/image" || img.GetFileLength() != 42 {
+		t.Errorf("Expected upload details in the message, got %v", img)
+	}
+
+	if _, _, _, err := buildMediaStatus([]byte("plain text"), ""); err == nil {
+		t.Error("Expected error for unsupported file type")
+	}
+}
+
+func TestCheckStatusAudience(t *testing.T) {
+	allowList := []types.StatusPrivacy{{
+		Type:      types.StatusPrivacyTypeWhitelist,
+		List:      []types.JID{types.NewJID("15550100", types.DefaultUserServer)},
+		IsDefault: true,
+	}}
+
+	if _, err := checkStatusAudience(allowList, audienceAllow); err != nil {
+		t.Errorf("Expected matching audience to pass, got %v", err)
+	}
+	if _, err := checkStatusAudience(allowList, audienceContacts); err == nil {
+		t.Error("Expected error when the account uses another audience")
+	}
+
+	// Without settings WhatsApp shares with all contacts
+	current, err := checkStatusAudience(nil, audienceContacts)
+	if err != nil || current.Type != types.StatusPrivacyTypeContacts {
+		t.Errorf("Expected contacts audience by default, got %v, %v", current, err)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"go.mau.fi/whatsmeow"
+	//nolint:staticcheck // Using deprecated package for compatibility
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"google.golang.org/protobuf/proto"
+
+	"whatsmeow-go/cmd/wavy/sticker"
+)
+
+// buildStickerMessage converts an image file into a sticker message.
+// The returned upload must be completed before the message is sent.
+func buildStickerMessage(path string) (*waProto.Message, *mediaUpload, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read sticker image: %w", err)
+	}
+
+	converted, err := sticker.Convert(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to convert sticker: %w", err)
+	}
+
+	stickerMsg := &waProto.StickerMessage{
+		Mimetype:   proto.String(sticker.MimeType),
+		Width:      proto.Uint32(converted.Width),
+		Height:     proto.Uint32(converted.Height),
+		IsAnimated: proto.Bool(converted.Animated),
+	}
+
+	message := &waProto.Message{StickerMessage: stickerMsg}
+
+	// Stickers are uploaded as images
+	upload := &mediaUpload{
+		Data:      converted.Data,
+		MediaType: whatsmeow.MediaImage,
+		apply:     uploadTarget(message),
+	}
+
+	return message, upload, nil
+}
@@ -0,0 +1,220 @@
+// Package sticker converts images into the 512x512 WebP format used by WhatsApp stickers.
+package sticker
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	"github.com/HugoSmits86/nativewebp"
+	"golang.org/x/image/draw"
+)
+
+const (
+	// Size is the width and height of a sticker in pixels
+	Size = 512
+	// MimeType is the MIME type of sticker files
+	MimeType = "image/webp"
+
+	// MaxStaticSize is the largest static sticker file WhatsApp accepts
+	MaxStaticSize = 100 << 10
+	// MaxAnimatedSize is the largest animated sticker file WhatsApp accepts
+	MaxAnimatedSize = 500 << 10
+	// MaxFrames is the maximum number of frames converted from an animated GIF
+	MaxFrames = 100
+
+	// defaultFrameDelay is used for GIF frames without a delay, in milliseconds
+	defaultFrameDelay = 100
+)
+
+// Sticker is an image converted to the WhatsApp sticker format
+type Sticker struct {
+	Data     []byte
+	Width    uint32
+	Height   uint32
+	Animated bool
+}
+
+// Convert turns a PNG, JPEG, GIF or WebP image into a sticker. Images are scaled to fit
+// a transparent 512x512 canvas. WebP images that are already 512x512 are used as is,
+// and animated GIFs become animated stickers. The WebP encoder is lossless, so photos
+// can exceed the sticker size limits, in which case an error is returned.
+func Convert(data []byte) (*Sticker, error) {
+	result, err := convert(data)
+	if err != nil {
+		return nil, err
+	}
+
+	limit, kind := MaxStaticSize, "static"
+	if result.Animated {
+		limit, kind = MaxAnimatedSize, "animated"
+	}
+	if len(result.Data) > limit {
+		return nil, fmt.Errorf("sticker is %d KB, but %s stickers can be at most %d KB; use a simpler image or a smaller WebP",
+			(len(result.Data)+1023)>>10, kind, limit>>10)
+	}
+	return result, nil
+}
+
+// convert converts an image into a sticker without checking its size
+func convert(data []byte) (*Sticker, error) {
+	if len(data) == 0 {
+		return nil, errors.New("sticker image is empty")
+	}
+
+	if isWebP(data) {
+		return convertWebP(data)
+	}
+
+	if bytes.HasPrefix(data, []byte("GIF8")) {
+		anim, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode GIF: %w", err)
+		}
+		if len(anim.Image) > 1 {
+			return convertAnimation(anim)
+		}
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("unsupported image: %w", err)
+	}
+	return encode(fit(img))
+}
+
+// convertWebP uses WebP images as is when they already have the sticker size, and rescales them otherwise
+func convertWebP(data []byte) (*Sticker, error) {
+	width, height, animated, ok := extendedHeader(data)
+	if animated {
+		// Animated WebP cannot be decoded, so it can only be used if it already has the right size
+		if width != Size || height != Size {
+			return nil, fmt.Errorf("animated WebP stickers must be %dx%d, got %dx%d", Size, Size, width, height)
+		}
+		return &Sticker{Data: data, Width: Size, Height: Size, Animated: true}, nil
+	}
+
+	if !ok {
+		config, err := nativewebp.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode WebP: %w", err)
+		}
+		width, height = uint32(config.Width), uint32(config.Height)
+	}
+
+	if width == Size && height == Size {
+		return &Sticker{Data: data, Width: Size, Height: Size}, nil
+	}
+
+	img, err := nativewebp.DecodeIgnoreAlphaFlag(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode WebP: %w", err)
+	}
+	return encode(fit(img))
+}
+
+// convertAnimation composites the frames of an animated GIF and encodes them as an animated WebP
+func convertAnimation(anim *gif.GIF) (*Sticker, error) {
+	if len(anim.Image) > MaxFrames {
+		return nil, fmt.Errorf("animated GIF has %d frames, stickers can have at most %d", len(anim.Image), MaxFrames)
+	}
+
+	canvas := image.NewRGBA(image.Rect(0, 0, anim.Config.Width, anim.Config.Height))
+	frames := &nativewebp.Animation{}
+
+	for i, frame := range anim.Image {
+		disposal := byte(0)
+		if i < len(anim.Disposal) {
+			disposal = anim.Disposal[i]
+		}
+
+		var previous *image.RGBA
+		if disposal == gif.DisposalPrevious {
+			previous = image.NewRGBA(canvas.Bounds())
+			draw.Draw(previous, canvas.Bounds(), canvas, image.Point{}, draw.Src)
+		}
+
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+
+		delay := uint(defaultFrameDelay)
+		if i < len(anim.Delay) && anim.Delay[i] > 0 {
+			delay = uint(anim.Delay[i]) * 10
+		}
+
+		// Every frame is a full composited image, so the canvas is cleared in between
+		frames.Images = append(frames.Images, fit(canvas))
+		frames.Durations = append(frames.Durations, delay)
+		frames.Disposals = append(frames.Disposals, 1)
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = previous
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := nativewebp.EncodeAll(&buf, frames, nil); err != nil {
+		return nil, fmt.Errorf("failed to encode animated WebP: %w", err)
+	}
+	return &Sticker{Data: buf.Bytes(), Width: Size, Height: Size, Animated: true}, nil
+}
+
+// fit scales an image to fit the sticker canvas, keeping its aspect ratio and centering it
+func fit(img image.Image) *image.NRGBA {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	if width >= height {
+		height = max(1, height*Size/width)
+		width = Size
+	} else {
+		width = max(1, width*Size/height)
+		height = Size
+	}
+
+	canvas := image.NewNRGBA(image.Rect(0, 0, Size, Size))
+	offset := image.Pt((Size-width)/2, (Size-height)/2)
+	target := image.Rectangle{Min: offset, Max: offset.Add(image.Pt(width, height))}
+	draw.CatmullRom.Scale(canvas, target, img, bounds, draw.Over, nil)
+	return canvas
+}
+
+// encode encodes a static sticker image as WebP
+func encode(img image.Image) (*Sticker, error) {
+	var buf bytes.Buffer
+	if err := nativewebp.Encode(&buf, img, nil); err != nil {
+		return nil, fmt.Errorf("failed to encode WebP: %w", err)
+	}
+	return &Sticker{Data: buf.Bytes(), Width: Size, Height: Size}, nil
+}
+
+// isWebP reports whether data starts with a WebP RIFF header
+func isWebP(data []byte) bool {
+	return len(data) >= 12 && string(data[0:4]) == "RIFF" && string(data[8:12]) == "WEBP"
+}
+
+// extendedHeader reads the canvas size and animation flag from a VP8X chunk.
+// ok is false if the file does not use the extended format.
+func extendedHeader(data []byte) (width, height uint32, animated, ok bool) {
+	if len(data) < 30 || string(data[12:16]) != "VP8X" {
+		return 0, 0, false, false
+	}
+
+	const animationFlag = 0x02
+	animated = data[20]&animationFlag != 0
+	width = readUint24(data[24:27]) + 1
+	height = readUint24(data[27:30]) + 1
+	return width, height, animated, true
+}
+
+// readUint24 reads a little-endian 24-bit integer
+func readUint24(b []byte) uint32 {
+	return binary.LittleEndian.Uint32(append([]byte{}, b[0], b[1], b[2], 0))
+}
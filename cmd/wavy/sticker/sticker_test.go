@@ -0,0 +1,133 @@
+package sticker
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"math/rand"
+	"testing"
+
+	"github.com/HugoSmits86/nativewebp"
+)
+
+func encodePNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		img.Set(x, height/2, color.RGBA{R: 255, A: 255})
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("Failed to encode PNG: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestConvertPNG(t *testing.T) {
+	result, err := Convert(encodePNG(t, 200, 100))
+	if err != nil {
+		t.Fatalf("Convert() returned error: %v", err)
+	}
+
+	if result.Width != Size || result.Height != Size || result.Animated {
+		t.Errorf("Unexpected sticker: %dx%d animated=%v", result.Width, result.Height, result.Animated)
+	}
+
+	config, err := nativewebp.DecodeConfig(bytes.NewReader(result.Data))
+	if err != nil {
+		t.Fatalf("Converted sticker is not a valid WebP: %v", err)
+	}
+	if config.Width != Size || config.Height != Size {
+		t.Errorf("Expected %dx%d WebP, got %dx%d", Size, Size, config.Width, config.Height)
+	}
+}
+
+func TestConvertWebPPassthrough(t *testing.T) {
+	var buf bytes.Buffer
+	if err := nativewebp.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, Size, Size)), nil); err != nil {
+		t.Fatalf("Failed to encode WebP: %v", err)
+	}
+
+	result, err := Convert(buf.Bytes())
+	if err != nil {
+		t.Fatalf("Convert() returned error: %v", err)
+	}
+	if !bytes.Equal(result.Data, buf.Bytes()) {
+		t.Error("Expected a 512x512 WebP to be used as is")
+	}
+}
+
+func TestConvertAnimatedGIF(t *testing.T) {
+	palette := color.Palette{color.Transparent, color.RGBA{B: 255, A: 255}}
+	anim := &gif.GIF{Config: image.Config{Width: 64, Height: 64, ColorModel: palette}}
+	for i := 0; i < 3; i++ {
+		frame := image.NewPaletted(image.Rect(0, 0, 64, 64), palette)
+		frame.SetColorIndex(i*10, i*10, 1)
+		anim.Image = append(anim.Image, frame)
+		anim.Delay = append(anim.Delay, 5)
+	}
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, anim); err != nil {
+		t.Fatalf("Failed to encode GIF: %v", err)
+	}
+
+	result, err := Convert(buf.Bytes())
+	if err != nil {
+		t.Fatalf("Convert() returned error: %v", err)
+	}
+	if !result.Animated {
+		t.Error("Expected animated sticker")
+	}
+
+	width, height, animated, ok := extendedHeader(result.Data)
+	if !ok || !animated || width != Size || height != Size {
+		t.Errorf("Unexpected animated WebP header: %dx%d animated=%v ok=%v", width, height, animated, ok)
+	}
+}
+
+func TestConvertInvalid(t *testing.T) {
+	if _, err := Convert(nil); err == nil {
+		t.Error("Expected error for empty data")
+	}
+	if _, err := Convert([]byte("not an image")); err == nil {
+		t.Error("Expected error for invalid image")
+	}
+}
+
+func TestConvertTooLarge(t *testing.T) {
+	// Noise does not compress, so the lossless WebP is far over the static sticker limit
+	img := image.NewRGBA(image.Rect(0, 0, Size, Size))
+	random := rand.New(rand.NewSource(1))
+	random.Read(img.Pix)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("Failed to encode PNG: %v", err)
+	}
+
+	if _, err := Convert(buf.Bytes()); err == nil {
+		t.Error("Expected error for a sticker over the size limit")
+	}
+}
+
+func TestConvertTooManyFrames(t *testing.T) {
+	palette := color.Palette{color.Transparent, color.RGBA{B: 255, A: 255}}
+	anim := &gif.GIF{Config: image.Config{Width: 8, Height: 8, ColorModel: palette}}
+	for i := 0; i <= MaxFrames; i++ {
+		anim.Image = append(anim.Image, image.NewPaletted(image.Rect(0, 0, 8, 8), palette))
+		anim.Delay = append(anim.Delay, 5)
+	}
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, anim); err != nil {
+		t.Fatalf("Failed to encode GIF: %v", err)
+	}
+
+	if _, err := Convert(buf.Bytes()); err == nil {
+		t.Error("Expected error for a GIF with too many frames")
+	}
+}
@@ -0,0 +1,231 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// BroadcastList is a named list of recipients that messages are fanned out to
+type BroadcastList struct {
+	ID        int64
+	Name      string
+	CreatedAt time.Time
+	Members   int
+}
+
+// BroadcastMember is a recipient of a broadcast list with the outcome of the last message sent to it
+type BroadcastMember struct {
+	ListID    int64
+	Recipient string
+	AddedAt   time.Time
+	// Status is empty until a message was sent to the list
+	Status    string
+	MessageID string
+	Error     string
+	SentAt    time.Time
+}
+
+// CreateBroadcastList creates a broadcast list with the given members and returns its ID.
+// The list and its members are created in one transaction, so a failure leaves no list behind.
+func (d *DB) CreateBroadcastList(name string, recipients []string, createdAt time.Time) (int64, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to create broadcast list: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
+		`INSERT OR IGNORE INTO broadcast_lists (name, created_at) VALUES (?, ?)`,
+		name, createdAt.Unix(),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create broadcast list: %w", err)
+	}
+
+	if err := expectOneRow(result); errors.Is(err, ErrNotFound) {
+		return 0, fmt.Errorf("broadcast list %q already exists", name)
+	} else if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := addBroadcastMembers(tx, id, recipients, createdAt); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to create broadcast list: %w", err)
+	}
+	return id, nil
+}
+
+// GetBroadcastList returns the broadcast list with the given name
+func (d *DB) GetBroadcastList(name string) (*BroadcastList, error) {
+	lists, err := d.queryBroadcastLists(`WHERE l.name = ?`, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(lists) == 0 {
+		return nil, ErrNotFound
+	}
+	return &lists[0], nil
+}
+
+// ListBroadcastLists returns all broadcast lists ordered by name
+func (d *DB) ListBroadcastLists() ([]BroadcastList, error) {
+	return d.queryBroadcastLists(``)
+}
+
+// DeleteBroadcastList deletes a broadcast list and its members
+func (d *DB) DeleteBroadcastList(id int64) error {
+	result, err := d.db.Exec(`DELETE FROM broadcast_lists WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete broadcast list: %w", err)
+	}
+	return expectOneRow(result)
+}
+
+// AddBroadcastMembers adds recipients to a broadcast list, ignoring those already in it.
+// It returns the number of members added.
+func (d *DB) AddBroadcastMembers(listID int64, recipients []string, addedAt time.Time) (int64, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to add members: %w", err)
+	}
+	defer tx.Rollback()
+
+	added, err := addBroadcastMembers(tx, listID, recipients, addedAt)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to add members: %w", err)
+	}
+	return added, nil
+}
+
+// addBroadcastMembers inserts members within a transaction, ignoring those already in the list
+func addBroadcastMembers(tx *sql.Tx, listID int64, recipients []string, addedAt time.Time) (int64, error) {
+	var added int64
+	for _, recipient := range recipients {
+		result, err := tx.Exec(
+			`INSERT OR IGNORE INTO broadcast_members (list_id, recipient, added_at) VALUES (?, ?, ?)`,
+			listID, recipient, addedAt.Unix(),
+		)
+		if err != nil {
+			return 0, fmt.Errorf("failed to add member %s: %w", recipient, err)
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		added += n
+	}
+	return added, nil
+}
+
+// RemoveBroadcastMembers removes recipients from a broadcast list and returns the number of members removed
+func (d *DB) RemoveBroadcastMembers(listID int64, recipients []string) (int64, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to remove members: %w", err)
+	}
+	defer tx.Rollback()
+
+	var removed int64
+	for _, recipient := range recipients {
+		result, err := tx.Exec(`DELETE FROM broadcast_members WHERE list_id = ? AND recipient = ?`, listID, recipient)
+		if err != nil {
+			return 0, fmt.Errorf("failed to remove member %s: %w", recipient, err)
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		removed += n
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to remove members: %w", err)
+	}
+	return removed, nil
+}
+
+// GetBroadcastMembers returns the members of a broadcast list in the order they were added
+func (d *DB) GetBroadcastMembers(listID int64) ([]BroadcastMember, error) {
+	rows, err := d.db.Query(
+		`SELECT list_id, recipient, added_at, status, message_id, error, sent_at
+		FROM broadcast_members WHERE list_id = ? ORDER BY added_at, rowid`,
+		listID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get members: %w", err)
+	}
+	defer rows.Close()
+
+	var members []BroadcastMember
+	for rows.Next() {
+		var (
+			member  BroadcastMember
+			addedAt int64
+			sentAt  sql.NullInt64
+		)
+		err := rows.Scan(&member.ListID, &member.Recipient, &addedAt, &member.Status, &member.MessageID, &member.Error, &sentAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read member: %w", err)
+		}
+		member.AddedAt = time.Unix(addedAt, 0)
+		if sentAt.Valid {
+			member.SentAt = time.Unix(sentAt.Int64, 0)
+		}
+		members = append(members, member)
+	}
+
+	return members, rows.Err()
+}
+
+// SetBroadcastMemberStatus records the outcome of sending a message to a member
+func (d *DB) SetBroadcastMemberStatus(listID int64, recipient, status, messageID, errMsg string, sentAt time.Time) error {
+	result, err := d.db.Exec(
+		`UPDATE broadcast_members SET status = ?, message_id = ?, error = ?, sent_at = ? WHERE list_id = ? AND recipient = ?`,
+		status, messageID, errMsg, sentAt.Unix(), listID, recipient,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update member: %w", err)
+	}
+	return expectOneRow(result)
+}
+
+func (d *DB) queryBroadcastLists(where string, args ...interface{}) ([]BroadcastList, error) {
+	rows, err := d.db.Query(
+		`SELECT l.id, l.name, l.created_at, COUNT(m.recipient)
+		FROM broadcast_lists l LEFT JOIN broadcast_members m ON m.list_id = l.id `+where+`
+		GROUP BY l.id ORDER BY l.name`,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get broadcast lists: %w", err)
+	}
+	defer rows.Close()
+
+	var lists []BroadcastList
+	for rows.Next() {
+		var (
+			list      BroadcastList
+			createdAt int64
+		)
+		if err := rows.Scan(&list.ID, &list.Name, &createdAt, &list.Members); err != nil {
+			return nil, fmt.Errorf("failed to read broadcast list: %w", err)
+		}
+		list.CreatedAt = time.Unix(createdAt, 0)
+		lists = append(lists, list)
+	}
+
+	return lists, rows.Err()
+}
@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBroadcastLists(t *testing.T) {
+	db := openTestDB(t)
+	now := time.Now()
+
+	id, err := db.CreateBroadcastList("team", []string{"+15550100", "123456789@g.us", "+15550101"}, now)
+	if err != nil {
+		t.Fatalf("CreateBroadcastList() failed: %v", err)
+	}
+	if _, err := db.CreateBroadcastList("team", nil, now); err == nil {
+		t.Error("Expected an error when creating a list twice")
+	}
+	// Existing members are not added again
+	if added, err := db.AddBroadcastMembers(id, []string{"+15550100", "+15550102"}, now); err != nil || added != 1 {
+		t.Errorf("AddBroadcastMembers() = %d, %v, want 1", added, err)
+	}
+
+	if removed, err := db.RemoveBroadcastMembers(id, []string{"+15550101", "+15550199"}); err != nil || removed != 1 {
+		t.Errorf("RemoveBroadcastMembers() = %d, %v, want 1", removed, err)
+	}
+
+	list, err := db.GetBroadcastList("team")
+	if err != nil || list.ID != id || list.Members != 3 {
+		t.Fatalf("GetBroadcastList() = %+v, %v", list, err)
+	}
+	if _, err := db.GetBroadcastList("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for missing list, got %v", err)
+	}
+
+	if err := db.SetBroadcastMemberStatus(id, "+15550100", StatusSent, "MSGID", "", now); err != nil {
+		t.Fatalf("SetBroadcastMemberStatus() failed: %v", err)
+	}
+	if err := db.SetBroadcastMemberStatus(id, "+15550199", StatusFailed, "", "gone", now); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a non-member, got %v", err)
+	}
+
+	members, err := db.GetBroadcastMembers(id)
+	if err != nil || len(members) != 3 {
+		t.Fatalf("GetBroadcastMembers() = %+v, %v", members, err)
+	}
+	if members[0].Recipient != "+15550100" || members[0].Status != StatusSent || members[0].MessageID != "MSGID" {
+		t.Errorf("Unexpected first member %+v", members[0])
+	}
+	if members[1].Status != "" || !members[1].SentAt.IsZero() {
+		t.Errorf("Expected a member without status, got %+v", members[1])
+	}
+
+	// Deleting a list deletes its members
+	if err := db.DeleteBroadcastList(id); err != nil {
+		t.Fatalf("DeleteBroadcastList() failed: %v", err)
+	}
+	if members, _ := db.GetBroadcastMembers(id); len(members) != 0 {
+		t.Errorf("Expected no members after deleting the list, got %d", len(members))
+	}
+	if lists, _ := db.ListBroadcastLists(); len(lists) != 0 {
+		t.Errorf("Expected no lists, got %d", len(lists))
+	}
+}
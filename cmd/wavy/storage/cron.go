@@ -0,0 +1,169 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// CronJob is a recurring message sent according to a cron expression
+type CronJob struct {
+	ID        int64
+	Spec      string
+	Timezone  string
+	Recipient string
+	// Template is the text/template source of the message
+	Template  string
+	CreatedAt time.Time
+	NextRunAt time.Time
+	// DisabledAt is when the job was disabled because it could no longer run, zero if it is enabled
+	DisabledAt time.Time
+}
+
+// CronRun records the outcome of a single run of a cron job
+type CronRun struct {
+	ID          int64
+	JobID       int64
+	ScheduledAt time.Time
+	RanAt       time.Time
+	Status      string
+	MessageID   string
+	Error       string
+}
+
+const cronJobColumns = `id, spec, timezone, recipient, template, created_at, next_run_at, disabled_at`
+
+// AddCronJob stores a new cron job and returns its ID
+func (d *DB) AddCronJob(job CronJob) (int64, error) {
+	result, err := d.db.Exec(
+		`INSERT INTO cron_jobs (spec, timezone, recipient, template, created_at, next_run_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		job.Spec, job.Timezone, job.Recipient, job.Template, job.CreatedAt.Unix(), job.NextRunAt.Unix(),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to add cron job: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+// ListCronJobs returns all cron jobs ordered by ID
+func (d *DB) ListCronJobs() ([]CronJob, error) {
+	return d.queryCronJobs(`SELECT ` + cronJobColumns + ` FROM cron_jobs ORDER BY id`)
+}
+
+// DueCronJobs returns the enabled cron jobs whose next run is not after now
+func (d *DB) DueCronJobs(now time.Time) ([]CronJob, error) {
+	return d.queryCronJobs(
+		`SELECT `+cronJobColumns+` FROM cron_jobs WHERE next_run_at <= ? AND disabled_at IS NULL ORDER BY next_run_at, id`,
+		now.Unix(),
+	)
+}
+
+// DeleteCronJob removes a cron job and its run history. It returns ErrNotFound if the job does not exist.
+func (d *DB) DeleteCronJob(id int64) error {
+	result, err := d.db.Exec(`DELETE FROM cron_jobs WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete cron job: %w", err)
+	}
+	return expectOneRow(result)
+}
+
+// AdvanceCronJob moves a job's next run from the given time to next, claiming the run.
+// It returns ErrNotFound if the job was deleted or another runner already advanced it.
+func (d *DB) AdvanceCronJob(id int64, from, next time.Time) error {
+	result, err := d.db.Exec(
+		`UPDATE cron_jobs SET next_run_at = ? WHERE id = ? AND next_run_at = ?`,
+		next.Unix(), id, from.Unix(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update cron job: %w", err)
+	}
+	return expectOneRow(result)
+}
+
+// DisableCronJob stops a job whose run at the given time is due, claiming that run like AdvanceCronJob.
+// It returns ErrNotFound if the job was deleted, disabled or advanced by another runner.
+func (d *DB) DisableCronJob(id int64, at time.Time) error {
+	result, err := d.db.Exec(
+		`UPDATE cron_jobs SET disabled_at = ? WHERE id = ? AND next_run_at = ? AND disabled_at IS NULL`,
+		time.Now().Unix(), id, at.Unix(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to disable cron job: %w", err)
+	}
+	return expectOneRow(result)
+}
+
+// AddCronRun records the outcome of a cron job run
+func (d *DB) AddCronRun(run CronRun) error {
+	_, err := d.db.Exec(
+		`INSERT INTO cron_runs (job_id, scheduled_at, ran_at, status, message_id, error) VALUES (?, ?, ?, ?, ?, ?)`,
+		run.JobID, run.ScheduledAt.Unix(), run.RanAt.Unix(), run.Status, run.MessageID, run.Error,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record cron run: %w", err)
+	}
+	return nil
+}
+
+// GetCronRuns returns the most recent runs, newest first. If jobID is 0, runs of all jobs are returned.
+func (d *DB) GetCronRuns(jobID int64, limit int) ([]CronRun, error) {
+	query := `SELECT id, job_id, scheduled_at, ran_at, status, message_id, error FROM cron_runs`
+	var args []interface{}
+	if jobID != 0 {
+		query += ` WHERE job_id = ?`
+		args = append(args, jobID)
+	}
+	query += ` ORDER BY ran_at DESC, id DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cron runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []CronRun
+	for rows.Next() {
+		var (
+			run                CronRun
+			scheduledAt, ranAt int64
+		)
+		if err := rows.Scan(&run.ID, &run.JobID, &scheduledAt, &ranAt, &run.Status, &run.MessageID, &run.Error); err != nil {
+			return nil, fmt.Errorf("failed to read cron run: %w", err)
+		}
+		run.ScheduledAt = time.Unix(scheduledAt, 0)
+		run.RanAt = time.Unix(ranAt, 0)
+		runs = append(runs, run)
+	}
+
+	return runs, rows.Err()
+}
+
+func (d *DB) queryCronJobs(query string, args ...interface{}) ([]CronJob, error) {
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cron jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []CronJob
+	for rows.Next() {
+		var (
+			job                  CronJob
+			createdAt, nextRunAt int64
+			disabledAt           sql.NullInt64
+		)
+		err := rows.Scan(&job.ID, &job.Spec, &job.Timezone, &job.Recipient, &job.Template, &createdAt, &nextRunAt, &disabledAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cron job: %w", err)
+		}
+		job.CreatedAt = time.Unix(createdAt, 0)
+		job.NextRunAt = time.Unix(nextRunAt, 0)
+		if disabledAt.Valid {
+			job.DisabledAt = time.Unix(disabledAt.Int64, 0)
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, rows.Err()
+}
@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCronJobs(t *testing.T) {
+	db := openTestDB(t)
+	now := time.Now().Truncate(time.Second)
+
+	id, err := db.AddCronJob(CronJob{
+		Spec: "0 9 * * MON", Timezone: "UTC", Recipient: "123@g.us", Template: "Stand-up {{date \"Jan 2\"}}",
+		CreatedAt: now, NextRunAt: now.Add(-time.Minute),
+	})
+	if err != nil {
+		t.Fatalf("AddCronJob() failed: %v", err)
+	}
+	if _, err := db.AddCronJob(CronJob{
+		Spec: "@daily", Timezone: "UTC", Recipient: "+15550100", Template: "Later",
+		CreatedAt: now, NextRunAt: now.Add(time.Hour),
+	}); err != nil {
+		t.Fatalf("AddCronJob() failed: %v", err)
+	}
+
+	due, err := db.DueCronJobs(now)
+	if err != nil {
+		t.Fatalf("DueCronJobs() failed: %v", err)
+	}
+	if len(due) != 1 || due[0].ID != id || due[0].Template != "Stand-up {{date \"Jan 2\"}}" {
+		t.Fatalf("Expected only the first job to be due, got %+v", due)
+	}
+
+	// Only one runner can claim a run
+	next := now.Add(7 * 24 * time.Hour)
+	if err := db.AdvanceCronJob(id, due[0].NextRunAt, next); err != nil {
+		t.Fatalf("AdvanceCronJob() failed: %v", err)
+	}
+	if err := db.AdvanceCronJob(id, due[0].NextRunAt, next); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound when advancing twice, got %v", err)
+	}
+
+	if err := db.AddCronRun(CronRun{JobID: id, ScheduledAt: due[0].NextRunAt, RanAt: now, Status: StatusSent, MessageID: "MSGID"}); err != nil {
+		t.Fatalf("AddCronRun() failed: %v", err)
+	}
+	if err := db.AddCronRun(CronRun{JobID: id, ScheduledAt: next, RanAt: now.Add(time.Second), Status: StatusFailed, Error: "offline"}); err != nil {
+		t.Fatalf("AddCronRun() failed: %v", err)
+	}
+
+	runs, err := db.GetCronRuns(id, 10)
+	if err != nil {
+		t.Fatalf("GetCronRuns() failed: %v", err)
+	}
+	if len(runs) != 2 || runs[0].Status != StatusFailed || runs[1].MessageID != "MSGID" {
+		t.Errorf("Expected newest run first, got %+v", runs)
+	}
+
+	// Deleting a job removes its history
+	if err := db.DeleteCronJob(id); err != nil {
+		t.Fatalf("DeleteCronJob() failed: %v", err)
+	}
+	if err := db.DeleteCronJob(id); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound when deleting twice, got %v", err)
+	}
+	runs, err = db.GetCronRuns(0, 10)
+	if err != nil {
+		t.Fatalf("GetCronRuns() failed: %v", err)
+	}
+	if len(runs) != 0 {
+		t.Errorf("Expected runs to be deleted with their job, got %+v", runs)
+	}
+
+	jobs, err := db.ListCronJobs()
+	if err != nil {
+		t.Fatalf("ListCronJobs() failed: %v", err)
+	}
+	if len(jobs) != 1 || jobs[0].Spec != "@daily" {
+		t.Errorf("Expected one remaining job, got %+v", jobs)
+	}
+}
+
+func TestDisableCronJob(t *testing.T) {
+	db := openTestDB(t)
+	now := time.Now().Truncate(time.Second)
+
+	id, err := db.AddCronJob(CronJob{
+		Spec: "@daily", Timezone: "Nowhere/Gone", Recipient: "+15550100", Template: "Hello",
+		CreatedAt: now, NextRunAt: now.Add(-time.Minute),
+	})
+	if err != nil {
+		t.Fatalf("AddCronJob() failed: %v", err)
+	}
+
+	if err := db.DisableCronJob(id, now.Add(-time.Minute)); err != nil {
+		t.Fatalf("DisableCronJob() failed: %v", err)
+	}
+	if err := db.DisableCronJob(id, now.Add(-time.Minute)); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound when disabling twice, got %v", err)
+	}
+
+	// A disabled job is listed but never due
+	due, err := db.DueCronJobs(now)
+	if err != nil || len(due) != 0 {
+		t.Errorf("Expected no due jobs, got %+v, %v", due, err)
+	}
+	jobs, err := db.ListCronJobs()
+	if err != nil || len(jobs) != 1 || jobs[0].DisabledAt.IsZero() {
+		t.Errorf("Expected the disabled job to be listed, got %+v, %v", jobs, err)
+	}
+}
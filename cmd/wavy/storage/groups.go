@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// CachedGroup is a group from the last time the joined groups were fetched from WhatsApp
+type CachedGroup struct {
+	JID string
+	// Info is the group info as returned by WhatsApp, encoded as JSON
+	Info []byte
+}
+
+// ReplaceCachedGroups replaces the cached groups with the ones fetched from WhatsApp at fetchedAt
+func (d *DB) ReplaceCachedGroups(groups []CachedGroup, fetchedAt time.Time) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM cached_groups`); err != nil {
+		return fmt.Errorf("failed to clear cached groups: %w", err)
+	}
+	for _, group := range groups {
+		if _, err := tx.Exec(
+			`INSERT INTO cached_groups (jid, info, fetched_at) VALUES (?, ?, ?)`,
+			group.JID, string(group.Info), fetchedAt.Unix(),
+		); err != nil {
+			return fmt.Errorf("failed to cache group %s: %w", group.JID, err)
+		}
+	}
+	if _, err := tx.Exec(
+		`INSERT INTO cached_groups_fetch (id, fetched_at) VALUES (1, ?)
+		ON CONFLICT (id) DO UPDATE SET fetched_at = excluded.fetched_at`,
+		fetchedAt.Unix(),
+	); err != nil {
+		return fmt.Errorf("failed to record group fetch time: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// ListCachedGroups returns the cached groups and when they were fetched.
+// It returns ErrNotFound if the groups were never fetched or the cache was cleared.
+func (d *DB) ListCachedGroups() ([]CachedGroup, time.Time, error) {
+	var fetchedAt int64
+	err := d.db.QueryRow(`SELECT fetched_at FROM cached_groups_fetch WHERE id = 1`).Scan(&fetchedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, time.Time{}, ErrNotFound
+	} else if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to get cached groups: %w", err)
+	}
+
+	rows, err := d.db.Query(`SELECT jid, info FROM cached_groups ORDER BY jid`)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to get cached groups: %w", err)
+	}
+	defer rows.Close()
+
+	var groups []CachedGroup
+	for rows.Next() {
+		var (
+			group CachedGroup
+			info  string
+		)
+		if err := rows.Scan(&group.JID, &info); err != nil {
+			return nil, time.Time{}, fmt.Errorf("failed to read cached group: %w", err)
+		}
+		group.Info = []byte(info)
+		groups = append(groups, group)
+	}
+
+	return groups, time.Unix(fetchedAt, 0), rows.Err()
+}
+
+// ClearCachedGroups empties the group cache, so the next listing fetches the groups from WhatsApp
+func (d *DB) ClearCachedGroups() error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range []string{`DELETE FROM cached_groups_fetch`, `DELETE FROM cached_groups`} {
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to clear cached groups: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// GroupPhoto is a group photo set with wavy
+type GroupPhoto struct {
+	GroupJID string
+	// PhotoHash is the SHA-256 of the uploaded JPEG, in hex
+	PhotoHash string
+	// PictureID is the ID WhatsApp gave the picture
+	PictureID string
+	SetAt     time.Time
+}
+
+// SetGroupPhoto records the photo last set for a group
+func (d *DB) SetGroupPhoto(photo GroupPhoto) error {
+	_, err := d.db.Exec(
+		`INSERT INTO group_photos (group_jid, photo_hash, picture_id, set_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (group_jid) DO UPDATE SET photo_hash = excluded.photo_hash, picture_id = excluded.picture_id, set_at = excluded.set_at`,
+		photo.GroupJID, photo.PhotoHash, photo.PictureID, photo.SetAt.Unix(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record group photo: %w", err)
+	}
+	return nil
+}
+
+// GetGroupPhoto returns the photo last set for a group with wavy, or ErrNotFound
+func (d *DB) GetGroupPhoto(groupJID string) (*GroupPhoto, error) {
+	var (
+		photo GroupPhoto
+		setAt int64
+	)
+	err := d.db.QueryRow(
+		`SELECT group_jid, photo_hash, picture_id, set_at FROM group_photos WHERE group_jid = ?`, groupJID,
+	).Scan(&photo.GroupJID, &photo.PhotoHash, &photo.PictureID, &setAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get group photo: %w", err)
+	}
+	photo.SetAt = time.Unix(setAt, 0)
+	return &photo, nil
+}
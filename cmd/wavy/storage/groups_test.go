@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCachedGroups(t *testing.T) {
+	db := openTestDB(t)
+
+	if _, _, err := db.ListCachedGroups(); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound before the groups were fetched, got %v", err)
+	}
+
+	first := time.Unix(1700000000, 0)
+	groups := []CachedGroup{
+		{JID: "1@g.us", Info: []byte(`{"Name":"Phoenix"}`)},
+		{JID: "2@g.us", Info: []byte(`{"Name":"Family"}`)},
+	}
+	if err := db.ReplaceCachedGroups(groups, first); err != nil {
+		t.Fatalf("ReplaceCachedGroups() failed: %v", err)
+	}
+
+	second := first.Add(time.Hour)
+	if err := db.ReplaceCachedGroups(groups[1:], second); err != nil {
+		t.Fatalf("ReplaceCachedGroups() failed: %v", err)
+	}
+
+	cached, fetchedAt, err := db.ListCachedGroups()
+	if err != nil {
+		t.Fatalf("ListCachedGroups() failed: %v", err)
+	}
+	if len(cached) != 1 || cached[0].JID != "2@g.us" || string(cached[0].Info) != `{"Name":"Family"}` {
+		t.Errorf("Expected only the groups of the last fetch, got %+v", cached)
+	}
+	if !fetchedAt.Equal(second) {
+		t.Errorf("Expected fetch time %v, got %v", second, fetchedAt)
+	}
+
+	// An account without groups is cached as well
+	if err := db.ReplaceCachedGroups(nil, second); err != nil {
+		t.Fatalf("ReplaceCachedGroups() failed: %v", err)
+	}
+	if cached, fetchedAt, err := db.ListCachedGroups(); err != nil || len(cached) != 0 || !fetchedAt.Equal(second) {
+		t.Errorf("ListCachedGroups() = %+v, %v, %v, want no groups fetched at %v", cached, fetchedAt, err, second)
+	}
+
+	if err := db.ClearCachedGroups(); err != nil {
+		t.Fatalf("ClearCachedGroups() failed: %v", err)
+	}
+	if _, _, err := db.ListCachedGroups(); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after clearing the cache, got %v", err)
+	}
+}
+
+func TestGroupPhotos(t *testing.T) {
+	db := openTestDB(t)
+
+	if _, err := db.GetGroupPhoto("1@g.us"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound for a group without photo, got %v", err)
+	}
+
+	setAt := time.Unix(1700000000, 0)
+	for _, id := range []string{"111", "222"} {
+		if err := db.SetGroupPhoto(GroupPhoto{GroupJID: "1@g.us", PhotoHash: "abc", PictureID: id, SetAt: setAt}); err != nil {
+			t.Fatalf("SetGroupPhoto() failed: %v", err)
+		}
+	}
+
+	photo, err := db.GetGroupPhoto("1@g.us")
+	if err != nil {
+		t.Fatalf("GetGroupPhoto() failed: %v", err)
+	}
+	if photo.PictureID != "222" || photo.PhotoHash != "abc" || !photo.SetAt.Equal(setAt) {
+		t.Errorf("Expected the last photo, got %+v", photo)
+	}
+}
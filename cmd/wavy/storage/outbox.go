@@ -0,0 +1,231 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// StatusDead marks outbox messages that ran out of delivery attempts
+const StatusDead = "dead"
+
+// OutboxMessage is a message waiting in the outbox to be delivered
+type OutboxMessage struct {
+	ID        int64
+	Recipient string
+	// Message is the serialized protobuf message
+	Message []byte
+	// Media is the file to upload before sending, if the message has media
+	Media     []byte
+	MediaType string
+	// IdempotencyKey prevents the same message from being queued twice
+	IdempotencyKey string
+	Status         string
+	Attempts       int
+	NextAttemptAt  time.Time
+	CreatedAt      time.Time
+	FinishedAt     time.Time
+	MessageID      string
+	Error          string
+}
+
+const outboxColumns = `id, recipient, message, media, media_type, idempotency_key, status, attempts, next_attempt_at, created_at, finished_at, message_id, error`
+
+// EnqueueOutboxMessage adds a pending message to the outbox and returns its ID.
+// If a message with the same idempotency key exists, its ID is returned and queued is false.
+func (d *DB) EnqueueOutboxMessage(msg OutboxMessage) (id int64, queued bool, err error) {
+	var key sql.NullString
+	if msg.IdempotencyKey != "" {
+		key = sql.NullString{String: msg.IdempotencyKey, Valid: true}
+	}
+
+	result, err := d.db.Exec(
+		`INSERT OR IGNORE INTO outbox (recipient, message, media, media_type, idempotency_key, status, next_attempt_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		msg.Recipient, msg.Message, msg.Media, msg.MediaType, key, StatusPending, msg.CreatedAt.Unix(), msg.CreatedAt.Unix(),
+	)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to queue message: %w", err)
+	}
+
+	if err := expectOneRow(result); errors.Is(err, ErrNotFound) {
+		err = d.db.QueryRow(`SELECT id FROM outbox WHERE idempotency_key = ?`, key).Scan(&id)
+		if err != nil {
+			return 0, false, fmt.Errorf("failed to find queued message: %w", err)
+		}
+		return id, false, nil
+	} else if err != nil {
+		return 0, false, err
+	}
+
+	id, err = result.LastInsertId()
+	return id, err == nil, err
+}
+
+// GetOutboxMessage returns a single outbox message
+func (d *DB) GetOutboxMessage(id int64) (*OutboxMessage, error) {
+	messages, err := d.queryOutbox(`SELECT `+outboxColumns+` FROM outbox WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(messages) == 0 {
+		return nil, ErrNotFound
+	}
+	return &messages[0], nil
+}
+
+// ListOutboxMessages returns the outbox messages with the given status, or all if status is empty
+func (d *DB) ListOutboxMessages(status string) ([]OutboxMessage, error) {
+	query := `SELECT ` + outboxColumns + ` FROM outbox`
+	var args []interface{}
+	if status != "" {
+		query += ` WHERE status = ?`
+		args = append(args, status)
+	}
+	query += ` ORDER BY id`
+
+	return d.queryOutbox(query, args...)
+}
+
+// DueOutboxMessages returns the pending messages whose next attempt is not after now
+func (d *DB) DueOutboxMessages(now time.Time) ([]OutboxMessage, error) {
+	return d.queryOutbox(
+		`SELECT `+outboxColumns+` FROM outbox WHERE status = ? AND next_attempt_at <= ? ORDER BY next_attempt_at, id`,
+		StatusPending, now.Unix(),
+	)
+}
+
+// ClaimOutboxMessage marks a pending message as being sent, so no other worker picks it up.
+// It returns ErrNotFound if the message is no longer pending.
+func (d *DB) ClaimOutboxMessage(id int64) error {
+	result, err := d.db.Exec(
+		`UPDATE outbox SET status = ?, claimed_at = ? WHERE id = ? AND status = ?`,
+		StatusSending, time.Now().Unix(), id, StatusPending,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to claim queued message: %w", err)
+	}
+	return expectOneRow(result)
+}
+
+// RequeueStaleOutboxMessages returns messages claimed before the given time to the queue,
+// for example after a worker crashed while sending them
+func (d *DB) RequeueStaleOutboxMessages(claimedBefore time.Time) (int64, error) {
+	result, err := d.db.Exec(
+		`UPDATE outbox SET status = ? WHERE status = ? AND claimed_at < ?`,
+		StatusPending, StatusSending, claimedBefore.Unix(),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to requeue messages: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// CompleteOutboxMessage records the successful delivery of a claimed message
+func (d *DB) CompleteOutboxMessage(id int64, messageID string) error {
+	_, err := d.db.Exec(
+		`UPDATE outbox SET status = ?, attempts = attempts + 1, message_id = ?, error = '', finished_at = ? WHERE id = ?`,
+		StatusSent, messageID, time.Now().Unix(), id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update queued message: %w", err)
+	}
+	return nil
+}
+
+// FailOutboxMessage records a failed attempt. The message is retried at nextAttempt, or marked dead if dead is set.
+func (d *DB) FailOutboxMessage(id int64, errMsg string, nextAttempt time.Time, dead bool) error {
+	status := StatusPending
+	var finishedAt sql.NullInt64
+	if dead {
+		status = StatusDead
+		finishedAt = sql.NullInt64{Int64: time.Now().Unix(), Valid: true}
+	}
+
+	_, err := d.db.Exec(
+		`UPDATE outbox SET status = ?, attempts = attempts + 1, error = ?, next_attempt_at = ?, finished_at = ? WHERE id = ?`,
+		status, errMsg, nextAttempt.Unix(), finishedAt, id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update queued message: %w", err)
+	}
+	return nil
+}
+
+// RetryOutboxMessage queues a dead or pending message for immediate delivery with a fresh set of attempts.
+// If id is 0, all dead messages are retried. It returns the number of messages queued.
+func (d *DB) RetryOutboxMessage(id int64) (int64, error) {
+	query := `UPDATE outbox SET status = ?, attempts = 0, next_attempt_at = ?, finished_at = NULL WHERE `
+	args := []interface{}{StatusPending, time.Now().Unix()}
+	if id != 0 {
+		query += `id = ? AND status IN (?, ?)`
+		args = append(args, id, StatusDead, StatusPending)
+	} else {
+		query += `status = ?`
+		args = append(args, StatusDead)
+	}
+
+	result, err := d.db.Exec(query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to retry messages: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// PurgeOutboxMessages deletes messages with the given statuses that finished before the given time.
+// It returns the number of messages deleted.
+func (d *DB) PurgeOutboxMessages(statuses []string, finishedBefore time.Time) (int64, error) {
+	if len(statuses) == 0 {
+		return 0, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(statuses)), ", ")
+	args := make([]interface{}, 0, len(statuses)+1)
+	for _, status := range statuses {
+		args = append(args, status)
+	}
+	args = append(args, finishedBefore.Unix())
+
+	result, err := d.db.Exec(
+		`DELETE FROM outbox WHERE status IN (`+placeholders+`) AND finished_at <= ?`,
+		args...,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge messages: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+func (d *DB) queryOutbox(query string, args ...interface{}) ([]OutboxMessage, error) {
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get queued messages: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []OutboxMessage
+	for rows.Next() {
+		var (
+			msg                      OutboxMessage
+			key                      sql.NullString
+			nextAttemptAt, createdAt int64
+			finishedAt               sql.NullInt64
+		)
+		err := rows.Scan(&msg.ID, &msg.Recipient, &msg.Message, &msg.Media, &msg.MediaType, &key, &msg.Status,
+			&msg.Attempts, &nextAttemptAt, &createdAt, &finishedAt, &msg.MessageID, &msg.Error)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read queued message: %w", err)
+		}
+		msg.IdempotencyKey = key.String
+		msg.NextAttemptAt = time.Unix(nextAttemptAt, 0)
+		msg.CreatedAt = time.Unix(createdAt, 0)
+		if finishedAt.Valid {
+			msg.FinishedAt = time.Unix(finishedAt.Int64, 0)
+		}
+		messages = append(messages, msg)
+	}
+
+	return messages, rows.Err()
+}
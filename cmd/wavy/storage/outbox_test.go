@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestOutbox(t *testing.T) {
+	db := openTestDB(t)
+	now := time.Now()
+
+	id, queued, err := db.EnqueueOutboxMessage(OutboxMessage{
+		Recipient: "+15550100", Message: []byte{1, 2}, Media: []byte{3}, MediaType: "image",
+		IdempotencyKey: "alert-42", CreatedAt: now,
+	})
+	if err != nil || !queued {
+		t.Fatalf("EnqueueOutboxMessage() = %d, %v, %v", id, queued, err)
+	}
+
+	// The same key is only queued once
+	dup, queued, err := db.EnqueueOutboxMessage(OutboxMessage{
+		Recipient: "+15550100", Message: []byte{9}, IdempotencyKey: "alert-42", CreatedAt: now,
+	})
+	if err != nil || queued || dup != id {
+		t.Errorf("Expected duplicate key to return message %d, got %d, %v, %v", id, dup, queued, err)
+	}
+
+	// Messages without a key are never deduplicated
+	other, _, err := db.EnqueueOutboxMessage(OutboxMessage{Recipient: "+15550101", Message: []byte{4}, CreatedAt: now})
+	if err != nil {
+		t.Fatalf("EnqueueOutboxMessage() failed: %v", err)
+	}
+	if _, queued, _ := db.EnqueueOutboxMessage(OutboxMessage{Recipient: "+15550101", Message: []byte{4}, CreatedAt: now}); !queued {
+		t.Error("Expected a message without key to be queued")
+	}
+
+	msg, err := db.GetOutboxMessage(id)
+	if err != nil {
+		t.Fatalf("GetOutboxMessage() failed: %v", err)
+	}
+	if !bytes.Equal(msg.Message, []byte{1, 2}) || !bytes.Equal(msg.Media, []byte{3}) || msg.Status != StatusPending {
+		t.Errorf("Unexpected message %+v", msg)
+	}
+
+	due, err := db.DueOutboxMessages(now)
+	if err != nil || len(due) != 3 {
+		t.Fatalf("Expected 3 due messages, got %d, %v", len(due), err)
+	}
+
+	// A failed attempt is retried later
+	if err := db.ClaimOutboxMessage(id); err != nil {
+		t.Fatalf("ClaimOutboxMessage() failed: %v", err)
+	}
+	if err := db.ClaimOutboxMessage(id); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound when claiming twice, got %v", err)
+	}
+	if err := db.FailOutboxMessage(id, "offline", now.Add(time.Minute), false); err != nil {
+		t.Fatalf("FailOutboxMessage() failed: %v", err)
+	}
+	due, _ = db.DueOutboxMessages(now)
+	if len(due) != 2 {
+		t.Errorf("Expected the failed message to wait for its next attempt, got %d due", len(due))
+	}
+
+	// After the last attempt it is dead until retried
+	if err := db.ClaimOutboxMessage(id); err != nil {
+		t.Fatalf("ClaimOutboxMessage() failed: %v", err)
+	}
+	if err := db.FailOutboxMessage(id, "offline", now, true); err != nil {
+		t.Fatalf("FailOutboxMessage() failed: %v", err)
+	}
+	msg, _ = db.GetOutboxMessage(id)
+	if msg.Status != StatusDead || msg.Attempts != 2 || msg.Error != "offline" {
+		t.Errorf("Expected dead message after 2 attempts, got %+v", msg)
+	}
+
+	if n, err := db.RetryOutboxMessage(0); err != nil || n != 1 {
+		t.Errorf("RetryOutboxMessage(0) = %d, %v, want 1", n, err)
+	}
+	msg, _ = db.GetOutboxMessage(id)
+	if msg.Status != StatusPending || msg.Attempts != 0 {
+		t.Errorf("Expected retried message to be pending with no attempts, got %+v", msg)
+	}
+
+	// A crashed worker's claims are returned to the queue
+	if err := db.ClaimOutboxMessage(other); err != nil {
+		t.Fatalf("ClaimOutboxMessage() failed: %v", err)
+	}
+	if n, err := db.RequeueStaleOutboxMessages(now.Add(time.Hour)); err != nil || n != 1 {
+		t.Errorf("RequeueStaleOutboxMessages() = %d, %v, want 1", n, err)
+	}
+
+	if err := db.ClaimOutboxMessage(other); err != nil {
+		t.Fatalf("ClaimOutboxMessage() failed: %v", err)
+	}
+	if err := db.CompleteOutboxMessage(other, "MSGID"); err != nil {
+		t.Fatalf("CompleteOutboxMessage() failed: %v", err)
+	}
+
+	sent, err := db.ListOutboxMessages(StatusSent)
+	if err != nil || len(sent) != 1 || sent[0].MessageID != "MSGID" {
+		t.Errorf("Expected one sent message, got %+v, %v", sent, err)
+	}
+
+	if n, err := db.PurgeOutboxMessages([]string{StatusSent}, now.Add(time.Hour)); err != nil || n != 1 {
+		t.Errorf("PurgeOutboxMessages() = %d, %v, want 1", n, err)
+	}
+	if _, err := db.GetOutboxMessage(other); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected purged message to be gone, got %v", err)
+	}
+
+	all, _ := db.ListOutboxMessages("")
+	if len(all) != 2 {
+		t.Errorf("Expected 2 remaining messages, got %d", len(all))
+	}
+}
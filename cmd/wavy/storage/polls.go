@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNotFound is returned when a requested record does not exist
+var ErrNotFound = errors.New("not found")
+
+// Poll is a poll created with wavy
+type Poll struct {
+	ID            string
+	Chat          string
+	Question      string
+	Options       []string
+	MaxSelections int
+	CreatedAt     time.Time
+}
+
+// PollVote is the latest vote of a single voter on a poll
+type PollVote struct {
+	PollID  string
+	Voter   string
+	Options []string
+	VotedAt time.Time
+}
+
+// SavePoll stores a newly created poll
+func (d *DB) SavePoll(poll Poll) error {
+	options, err := json.Marshal(poll.Options)
+	if err != nil {
+		return fmt.Errorf("failed to encode poll options: %w", err)
+	}
+
+	_, err = d.db.Exec(
+		`INSERT INTO polls (id, chat, question, options, max_selections, created_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		poll.ID, poll.Chat, poll.Question, string(options), poll.MaxSelections, poll.CreatedAt.Unix(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save poll: %w", err)
+	}
+	return nil
+}
+
+// GetPoll returns the poll with the given message ID
+func (d *DB) GetPoll(id string) (*Poll, error) {
+	var (
+		poll      Poll
+		options   string
+		createdAt int64
+	)
+
+	err := d.db.QueryRow(
+		`SELECT id, chat, question, options, max_selections, created_at FROM polls WHERE id = ?`, id,
+	).Scan(&poll.ID, &poll.Chat, &poll.Question, &options, &poll.MaxSelections, &createdAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get poll: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(options), &poll.Options); err != nil {
+		return nil, fmt.Errorf("failed to decode poll options: %w", err)
+	}
+	poll.CreatedAt = time.Unix(createdAt, 0)
+
+	return &poll, nil
+}
+
+// SavePollVote stores a vote, replacing any earlier vote from the same voter
+func (d *DB) SavePollVote(vote PollVote) error {
+	options, err := json.Marshal(vote.Options)
+	if err != nil {
+		return fmt.Errorf("failed to encode vote options: %w", err)
+	}
+
+	// Votes can arrive out of order, so only keep the newest one
+	_, err = d.db.Exec(
+		`INSERT INTO poll_votes (poll_id, voter, options, voted_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (poll_id, voter) DO UPDATE SET options = excluded.options, voted_at = excluded.voted_at
+		WHERE excluded.voted_at >= poll_votes.voted_at`,
+		vote.PollID, vote.Voter, string(options), vote.VotedAt.Unix(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save poll vote: %w", err)
+	}
+	return nil
+}
+
+// GetPollVotes returns the latest vote of every voter on a poll
+func (d *DB) GetPollVotes(pollID string) ([]PollVote, error) {
+	rows, err := d.db.Query(
+		`SELECT poll_id, voter, options, voted_at FROM poll_votes WHERE poll_id = ? ORDER BY voted_at`, pollID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get poll votes: %w", err)
+	}
+	defer rows.Close()
+
+	var votes []PollVote
+	for rows.Next() {
+		var (
+			vote    PollVote
+			options string
+			votedAt int64
+		)
+		if err := rows.Scan(&vote.PollID, &vote.Voter, &options, &votedAt); err != nil {
+			return nil, fmt.Errorf("failed to read poll vote: %w", err)
+		}
+		if err := json.Unmarshal([]byte(options), &vote.Options); err != nil {
+			return nil, fmt.Errorf("failed to decode vote options: %w", err)
+		}
+		vote.VotedAt = time.Unix(votedAt, 0)
+		votes = append(votes, vote)
+	}
+
+	return votes, rows.Err()
+}
@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func openTestDB(t *testing.T) *DB {
+	t.Helper()
+	db, err := Open(filepath.Join(t.TempDir(), "wavy.db"))
+	if err != nil {
+		t.Fatalf("Open() failed: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestPolls(t *testing.T) {
+	db := openTestDB(t)
+
+	if _, err := db.GetPoll("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for missing poll, got %v", err)
+	}
+
+	poll := Poll{
+		ID:            "POLL1",
+		Chat:          "123456789@g.us",
+		Question:      "Lunch?",
+		Options:       []string{"Pizza", "Sushi"},
+		MaxSelections: 1,
+		CreatedAt:     time.Unix(1700000000, 0),
+	}
+	if err := db.SavePoll(poll); err != nil {
+		t.Fatalf("SavePoll() failed: %v", err)
+	}
+
+	got, err := db.GetPoll("POLL1")
+	if err != nil {
+		t.Fatalf("GetPoll() failed: %v", err)
+	}
+	if got.Question != poll.Question || len(got.Options) != 2 || got.Options[1] != "Sushi" {
+		t.Errorf("GetPoll() = %+v, want %+v", got, poll)
+	}
+
+	// A newer vote replaces the previous one, an older one is ignored
+	votes := []PollVote{
+		{PollID: "POLL1", Voter: "111@s.whatsapp.net", Options: []string{"Pizza"}, VotedAt: time.Unix(100, 0)},
+		{PollID: "POLL1", Voter: "111@s.whatsapp.net", Options: []string{"Sushi"}, VotedAt: time.Unix(200, 0)},
+		{PollID: "POLL1", Voter: "111@s.whatsapp.net", Options: []string{"Pizza"}, VotedAt: time.Unix(150, 0)},
+		{PollID: "POLL1", Voter: "222@s.whatsapp.net", Options: []string{"Pizza"}, VotedAt: time.Unix(300, 0)},
+	}
+	for _, vote := range votes {
+		if err := db.SavePollVote(vote); err != nil {
+			t.Fatalf("SavePollVote() failed: %v", err)
+		}
+	}
+
+	stored, err := db.GetPollVotes("POLL1")
+	if err != nil {
+		t.Fatalf("GetPollVotes() failed: %v", err)
+	}
+	if len(stored) != 2 {
+		t.Fatalf("Expected 2 votes, got %d", len(stored))
+	}
+	if stored[0].Voter != "111@s.whatsapp.net" || stored[0].Options[0] != "Sushi" {
+		t.Errorf("Expected latest vote for Sushi, got %+v", stored[0])
+	}
+}
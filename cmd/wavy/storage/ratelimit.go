@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"whatsmeow-go/cmd/wavy/ratelimit"
+)
+
+// UpdateRateBuckets loads the named rate limit buckets and saves the ones returned by update,
+// all in one transaction so that concurrent wavy processes share the limits
+func (d *DB) UpdateRateBuckets(names []string, update func(map[string]ratelimit.Bucket) (map[string]ratelimit.Bucket, error)) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	buckets := make(map[string]ratelimit.Bucket, len(names))
+	for _, name := range names {
+		var (
+			tokens    float64
+			updatedAt int64
+		)
+		err := tx.QueryRow(`SELECT tokens, updated_at FROM rate_buckets WHERE name = ?`, name).Scan(&tokens, &updatedAt)
+		if err == nil {
+			buckets[name] = ratelimit.Bucket{Tokens: tokens, UpdatedAt: time.Unix(0, updatedAt)}
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("failed to read rate limit: %w", err)
+		}
+	}
+
+	updated, err := update(buckets)
+	if err != nil {
+		return err
+	}
+
+	for name, b := range updated {
+		_, err := tx.Exec(
+			`INSERT INTO rate_buckets (name, tokens, updated_at) VALUES (?, ?, ?)
+			ON CONFLICT (name) DO UPDATE SET tokens = excluded.tokens, updated_at = excluded.updated_at`,
+			name, b.Tokens, b.UpdatedAt.UnixNano(),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save rate limit: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// MarkContacted remembers that a message was sent to the JID
+func (d *DB) MarkContacted(jid string, at time.Time) error {
+	_, err := d.db.Exec(`INSERT OR IGNORE INTO contacted (jid, first_sent_at) VALUES (?, ?)`, jid, at.Unix())
+	if err != nil {
+		return fmt.Errorf("failed to save contact: %w", err)
+	}
+	return nil
+}
+
+// IsContacted reports whether a message was ever sent to the JID
+func (d *DB) IsContacted(jid string) (bool, error) {
+	var n int
+	if err := d.db.QueryRow(`SELECT COUNT(*) FROM contacted WHERE jid = ?`, jid).Scan(&n); err != nil {
+		return false, fmt.Errorf("failed to look up contact: %w", err)
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"whatsmeow-go/cmd/wavy/ratelimit"
+)
+
+func TestRateBuckets(t *testing.T) {
+	db := openTestDB(t)
+
+	// A limit of 2 per hour is shared by every limiter using the database
+	config := ratelimit.Config{New: ratelimit.Rate{PerHour: 2}}
+	first := ratelimit.NewLimiter(db, config)
+	second := ratelimit.NewLimiter(db, config)
+
+	if err := first.Wait(context.Background(), ratelimit.NewContact); err != nil {
+		t.Fatalf("Wait() failed: %v", err)
+	}
+	if err := second.Wait(context.Background(), ratelimit.NewContact); err != nil {
+		t.Fatalf("Wait() failed: %v", err)
+	}
+
+	var limitErr *ratelimit.LimitError
+	if err := first.Wait(context.Background(), ratelimit.NewContact); !errors.As(err, &limitErr) {
+		t.Errorf("Expected the shared limit to be reached, got %v", err)
+	}
+}
+
+func TestContacted(t *testing.T) {
+	db := openTestDB(t)
+
+	if known, err := db.IsContacted("15550100@s.whatsapp.net"); err != nil || known {
+		t.Errorf("IsContacted() = %v, %v, want false", known, err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := db.MarkContacted("15550100@s.whatsapp.net", time.Now()); err != nil {
+			t.Fatalf("MarkContacted() failed: %v", err)
+		}
+	}
+
+	if known, err := db.IsContacted("15550100@s.whatsapp.net"); err != nil || !known {
+		t.Errorf("IsContacted() = %v, %v, want true", known, err)
+	}
+}
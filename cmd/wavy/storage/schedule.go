@@ -0,0 +1,166 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Scheduled message statuses
+const (
+	StatusPending   = "pending"
+	StatusSending   = "sending"
+	StatusSent      = "sent"
+	StatusFailed    = "failed"
+	StatusSkipped   = "skipped"
+	StatusCancelled = "cancelled"
+)
+
+// Policies for scheduled messages whose send time passed while no worker was running
+const (
+	MissedSend = "send"
+	MissedSkip = "skip"
+)
+
+// ScheduledMessage is a message waiting to be sent at a specific time
+type ScheduledMessage struct {
+	ID         int64
+	Recipient  string
+	Message    string
+	SendAt     time.Time
+	IfMissed   string
+	Status     string
+	CreatedAt  time.Time
+	FinishedAt time.Time
+	MessageID  string
+	Error      string
+}
+
+const scheduledMessageColumns = `id, recipient, message, send_at, if_missed, status, created_at, finished_at, message_id, error`
+
+// AddScheduledMessage stores a new pending message and returns its ID
+func (d *DB) AddScheduledMessage(msg ScheduledMessage) (int64, error) {
+	result, err := d.db.Exec(
+		`INSERT INTO scheduled_messages (recipient, message, send_at, if_missed, status, created_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		msg.Recipient, msg.Message, msg.SendAt.Unix(), msg.IfMissed, StatusPending, msg.CreatedAt.Unix(),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to schedule message: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+// ListScheduledMessages returns the scheduled messages ordered by send time.
+// Unless all is set, only pending messages are returned.
+func (d *DB) ListScheduledMessages(all bool) ([]ScheduledMessage, error) {
+	query := `SELECT ` + scheduledMessageColumns + ` FROM scheduled_messages`
+	var args []interface{}
+	if !all {
+		query += ` WHERE status = ?`
+		args = append(args, StatusPending)
+	}
+	query += ` ORDER BY send_at, id`
+
+	return d.queryScheduledMessages(query, args...)
+}
+
+// DueScheduledMessages returns the pending messages whose send time is not after now
+func (d *DB) DueScheduledMessages(now time.Time) ([]ScheduledMessage, error) {
+	return d.queryScheduledMessages(
+		`SELECT `+scheduledMessageColumns+` FROM scheduled_messages WHERE status = ? AND send_at <= ? ORDER BY send_at, id`,
+		StatusPending, now.Unix(),
+	)
+}
+
+// CancelScheduledMessage cancels a pending message. It returns ErrNotFound if there is no pending message with the ID.
+func (d *DB) CancelScheduledMessage(id int64) error {
+	result, err := d.db.Exec(
+		`UPDATE scheduled_messages SET status = ?, finished_at = ? WHERE id = ? AND status = ?`,
+		StatusCancelled, time.Now().Unix(), id, StatusPending,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to cancel scheduled message: %w", err)
+	}
+	return expectOneRow(result)
+}
+
+// ClaimScheduledMessage marks a pending message as being sent, so no other worker picks it up.
+// It returns ErrNotFound if the message is no longer pending.
+func (d *DB) ClaimScheduledMessage(id int64) error {
+	result, err := d.db.Exec(
+		`UPDATE scheduled_messages SET status = ?, claimed_at = ? WHERE id = ? AND status = ?`,
+		StatusSending, time.Now().Unix(), id, StatusPending,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to claim scheduled message: %w", err)
+	}
+	return expectOneRow(result)
+}
+
+// RequeueStaleScheduledMessages returns messages claimed before the given time to pending,
+// for example after a worker crashed while sending them. Messages claimed before claim times
+// were recorded are requeued too.
+func (d *DB) RequeueStaleScheduledMessages(claimedBefore time.Time) (int64, error) {
+	result, err := d.db.Exec(
+		`UPDATE scheduled_messages SET status = ? WHERE status = ? AND (claimed_at IS NULL OR claimed_at < ?)`,
+		StatusPending, StatusSending, claimedBefore.Unix(),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to requeue scheduled messages: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// FinishScheduledMessage records the outcome of a claimed message
+func (d *DB) FinishScheduledMessage(id int64, status, messageID, errMsg string) error {
+	_, err := d.db.Exec(
+		`UPDATE scheduled_messages SET status = ?, message_id = ?, error = ?, finished_at = ? WHERE id = ?`,
+		status, messageID, errMsg, time.Now().Unix(), id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update scheduled message: %w", err)
+	}
+	return nil
+}
+
+func (d *DB) queryScheduledMessages(query string, args ...interface{}) ([]ScheduledMessage, error) {
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scheduled messages: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []ScheduledMessage
+	for rows.Next() {
+		var (
+			msg               ScheduledMessage
+			sendAt, createdAt int64
+			finishedAt        sql.NullInt64
+		)
+		err := rows.Scan(&msg.ID, &msg.Recipient, &msg.Message, &sendAt, &msg.IfMissed, &msg.Status,
+			&createdAt, &finishedAt, &msg.MessageID, &msg.Error)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read scheduled message: %w", err)
+		}
+		msg.SendAt = time.Unix(sendAt, 0)
+		msg.CreatedAt = time.Unix(createdAt, 0)
+		if finishedAt.Valid {
+			msg.FinishedAt = time.Unix(finishedAt.Int64, 0)
+		}
+		messages = append(messages, msg)
+	}
+
+	return messages, rows.Err()
+}
+
+// expectOneRow returns ErrNotFound if a statement did not change exactly one row
+func expectOneRow(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected != 1 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestScheduledMessages(t *testing.T) {
+	db := openTestDB(t)
+	now := time.Now()
+
+	first, err := db.AddScheduledMessage(ScheduledMessage{
+		Recipient: "+15550100", Message: "Due", SendAt: now.Add(-time.Minute), IfMissed: MissedSend, CreatedAt: now,
+	})
+	if err != nil {
+		t.Fatalf("AddScheduledMessage() failed: %v", err)
+	}
+	second, err := db.AddScheduledMessage(ScheduledMessage{
+		Recipient: "+15550100", Message: "Later", SendAt: now.Add(time.Hour), IfMissed: MissedSkip, CreatedAt: now,
+	})
+	if err != nil {
+		t.Fatalf("AddScheduledMessage() failed: %v", err)
+	}
+
+	due, err := db.DueScheduledMessages(now)
+	if err != nil {
+		t.Fatalf("DueScheduledMessages() failed: %v", err)
+	}
+	if len(due) != 1 || due[0].ID != first || due[0].Status != StatusPending {
+		t.Fatalf("Expected only the first message to be due, got %+v", due)
+	}
+
+	// A message can only be claimed once
+	if err := db.ClaimScheduledMessage(first); err != nil {
+		t.Fatalf("ClaimScheduledMessage() failed: %v", err)
+	}
+	if err := db.ClaimScheduledMessage(first); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound when claiming twice, got %v", err)
+	}
+	if err := db.FinishScheduledMessage(first, StatusSent, "MSGID", ""); err != nil {
+		t.Fatalf("FinishScheduledMessage() failed: %v", err)
+	}
+
+	if err := db.CancelScheduledMessage(second); err != nil {
+		t.Fatalf("CancelScheduledMessage() failed: %v", err)
+	}
+	if err := db.CancelScheduledMessage(second); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound when cancelling twice, got %v", err)
+	}
+
+	pending, err := db.ListScheduledMessages(false)
+	if err != nil {
+		t.Fatalf("ListScheduledMessages() failed: %v", err)
+	}
+	if len(pending) != 0 {
+		t.Errorf("Expected no pending messages, got %+v", pending)
+	}
+
+	all, err := db.ListScheduledMessages(true)
+	if err != nil {
+		t.Fatalf("ListScheduledMessages() failed: %v", err)
+	}
+	if len(all) != 2 || all[0].Status != StatusSent || all[0].MessageID != "MSGID" || all[1].Status != StatusCancelled {
+		t.Errorf("Unexpected scheduled messages: %+v", all)
+	}
+	if all[0].FinishedAt.IsZero() {
+		t.Error("Expected finished time to be recorded")
+	}
+}
+
+func TestRequeueStaleScheduledMessages(t *testing.T) {
+	db := openTestDB(t)
+	now := time.Now()
+
+	id, err := db.AddScheduledMessage(ScheduledMessage{
+		Recipient: "+15550100", Message: "Due", SendAt: now.Add(-time.Minute), IfMissed: MissedSend, CreatedAt: now,
+	})
+	if err != nil {
+		t.Fatalf("AddScheduledMessage() failed: %v", err)
+	}
+	if err := db.ClaimScheduledMessage(id); err != nil {
+		t.Fatalf("ClaimScheduledMessage() failed: %v", err)
+	}
+
+	// A recent claim is left alone
+	if n, err := db.RequeueStaleScheduledMessages(now.Add(-time.Hour)); err != nil || n != 0 {
+		t.Errorf("RequeueStaleScheduledMessages() = %d, %v, want 0", n, err)
+	}
+	if n, err := db.RequeueStaleScheduledMessages(now.Add(time.Hour)); err != nil || n != 1 {
+		t.Errorf("RequeueStaleScheduledMessages() = %d, %v, want 1", n, err)
+	}
+
+	due, err := db.DueScheduledMessages(now)
+	if err != nil || len(due) != 1 || due[0].ID != id {
+		t.Errorf("Expected the requeued message to be due again, got %+v, %v", due, err)
+	}
+}
+
+func TestScheduledMessagesClaimedAtMigration(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wavy.db")
+
+	// The table as it was created before claim times were recorded
+	old, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("sql.Open() failed: %v", err)
+	}
+	_, err = old.Exec(`CREATE TABLE scheduled_messages (
+		id          INTEGER PRIMARY KEY AUTOINCREMENT,
+		recipient   TEXT NOT NULL,
+		message     TEXT NOT NULL,
+		send_at     INTEGER NOT NULL,
+		if_missed   TEXT NOT NULL,
+		status      TEXT NOT NULL,
+		created_at  INTEGER NOT NULL,
+		finished_at INTEGER,
+		message_id  TEXT NOT NULL DEFAULT '',
+		error       TEXT NOT NULL DEFAULT ''
+	)`)
+	if err == nil {
+		_, err = old.Exec(`INSERT INTO scheduled_messages (recipient, message, send_at, if_missed, status, created_at)
+			VALUES ('+15550100', 'Stuck', 0, 'send', 'sending', 0)`)
+	}
+	old.Close()
+	if err != nil {
+		t.Fatalf("Exec() failed: %v", err)
+	}
+
+	// Opening twice checks the column is only added once
+	for i := 0; i < 2; i++ {
+		db, err := Open(path)
+		if err != nil {
+			t.Fatalf("Open() failed: %v", err)
+		}
+		db.Close()
+	}
+
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() failed: %v", err)
+	}
+	defer db.Close()
+
+	// A message claimed before claim times were recorded is stale
+	if n, err := db.RequeueStaleScheduledMessages(time.Now()); err != nil || n != 1 {
+		t.Errorf("RequeueStaleScheduledMessages() = %d, %v, want 1", n, err)
+	}
+}
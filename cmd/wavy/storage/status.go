@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// StatusPost is a status update posted with wavy
+type StatusPost struct {
+	ID        int64
+	MessageID string
+	// Kind is text, image or video
+	Kind string
+	// Text is the text of a text status or the caption of a media status
+	Text string
+	// Audience is the status privacy setting the update was posted with
+	Audience string
+	PostedAt time.Time
+}
+
+// AddStatusPost records a posted status update and returns its ID
+func (d *DB) AddStatusPost(post StatusPost) (int64, error) {
+	result, err := d.db.Exec(
+		`INSERT INTO status_posts (message_id, kind, text, audience, posted_at) VALUES (?, ?, ?, ?, ?)`,
+		post.MessageID, post.Kind, post.Text, post.Audience, post.PostedAt.Unix(),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record status update: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+// ListStatusPosts returns the most recent status updates, newest first. A limit of 0 returns all of them.
+func (d *DB) ListStatusPosts(limit int) ([]StatusPost, error) {
+	query := `SELECT id, message_id, kind, text, audience, posted_at FROM status_posts ORDER BY posted_at DESC, id DESC`
+	var args []interface{}
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status updates: %w", err)
+	}
+	defer rows.Close()
+
+	var posts []StatusPost
+	for rows.Next() {
+		var (
+			post     StatusPost
+			postedAt int64
+		)
+		if err := rows.Scan(&post.ID, &post.MessageID, &post.Kind, &post.Text, &post.Audience, &postedAt); err != nil {
+			return nil, fmt.Errorf("failed to read status update: %w", err)
+		}
+		post.PostedAt = time.Unix(postedAt, 0)
+		posts = append(posts, post)
+	}
+
+	return posts, rows.Err()
+}
@@ -0,0 +1,30 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStatusPosts(t *testing.T) {
+	db := openTestDB(t)
+	now := time.Now()
+
+	for i, id := range []string{"MSG1", "MSG2", "MSG3"} {
+		post := StatusPost{MessageID: id, Kind: "text", Text: "Daily deal", Audience: "contacts", PostedAt: now.Add(time.Duration(i) * time.Hour)}
+		if _, err := db.AddStatusPost(post); err != nil {
+			t.Fatalf("AddStatusPost() failed: %v", err)
+		}
+	}
+
+	posts, err := db.ListStatusPosts(2)
+	if err != nil {
+		t.Fatalf("ListStatusPosts() failed: %v", err)
+	}
+	if len(posts) != 2 || posts[0].MessageID != "MSG3" || posts[1].MessageID != "MSG2" {
+		t.Errorf("Expected the 2 newest posts, got %+v", posts)
+	}
+
+	if all, _ := db.ListStatusPosts(0); len(all) != 3 {
+		t.Errorf("Expected 3 posts without limit, got %d", len(all))
+	}
+}
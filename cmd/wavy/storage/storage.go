@@ -0,0 +1,181 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// migrations contains the statements that create the wavy database schema.
+// They are executed in order every time the database is opened, so they must be idempotent.
+var migrations = []string{
+	`CREATE TABLE IF NOT EXISTS polls (
+		id             TEXT PRIMARY KEY,
+		chat           TEXT NOT NULL,
+		question       TEXT NOT NULL,
+		options        TEXT NOT NULL,
+		max_selections INTEGER NOT NULL,
+		created_at     INTEGER NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS poll_votes (
+		poll_id  TEXT NOT NULL REFERENCES polls(id) ON DELETE CASCADE,
+		voter    TEXT NOT NULL,
+		options  TEXT NOT NULL,
+		voted_at INTEGER NOT NULL,
+		PRIMARY KEY (poll_id, voter)
+	)`,
+	`CREATE TABLE IF NOT EXISTS scheduled_messages (
+		id          INTEGER PRIMARY KEY AUTOINCREMENT,
+		recipient   TEXT NOT NULL,
+		message     TEXT NOT NULL,
+		send_at     INTEGER NOT NULL,
+		if_missed   TEXT NOT NULL,
+		status      TEXT NOT NULL,
+		created_at  INTEGER NOT NULL,
+		finished_at INTEGER,
+		message_id  TEXT NOT NULL DEFAULT '',
+		error       TEXT NOT NULL DEFAULT '',
+		claimed_at  INTEGER
+	)`,
+	`CREATE INDEX IF NOT EXISTS scheduled_messages_due ON scheduled_messages (status, send_at)`,
+	`CREATE TABLE IF NOT EXISTS cron_jobs (
+		id          INTEGER PRIMARY KEY AUTOINCREMENT,
+		spec        TEXT NOT NULL,
+		timezone    TEXT NOT NULL,
+		recipient   TEXT NOT NULL,
+		template    TEXT NOT NULL,
+		created_at  INTEGER NOT NULL,
+		next_run_at INTEGER NOT NULL,
+		disabled_at INTEGER
+	)`,
+	`CREATE TABLE IF NOT EXISTS cron_runs (
+		id           INTEGER PRIMARY KEY AUTOINCREMENT,
+		job_id       INTEGER NOT NULL REFERENCES cron_jobs(id) ON DELETE CASCADE,
+		scheduled_at INTEGER NOT NULL,
+		ran_at       INTEGER NOT NULL,
+		status       TEXT NOT NULL,
+		message_id   TEXT NOT NULL DEFAULT '',
+		error        TEXT NOT NULL DEFAULT ''
+	)`,
+	`CREATE INDEX IF NOT EXISTS cron_runs_job ON cron_runs (job_id, ran_at)`,
+	`CREATE TABLE IF NOT EXISTS outbox (
+		id              INTEGER PRIMARY KEY AUTOINCREMENT,
+		recipient       TEXT NOT NULL,
+		message         BLOB NOT NULL,
+		media           BLOB,
+		media_type      TEXT NOT NULL DEFAULT '',
+		idempotency_key TEXT UNIQUE,
+		status          TEXT NOT NULL,
+		attempts        INTEGER NOT NULL DEFAULT 0,
+		next_attempt_at INTEGER NOT NULL,
+		claimed_at      INTEGER,
+		created_at      INTEGER NOT NULL,
+		finished_at     INTEGER,
+		message_id      TEXT NOT NULL DEFAULT '',
+		error           TEXT NOT NULL DEFAULT ''
+	)`,
+	`CREATE INDEX IF NOT EXISTS outbox_due ON outbox (status, next_attempt_at)`,
+	`CREATE TABLE IF NOT EXISTS rate_buckets (
+		name       TEXT PRIMARY KEY,
+		tokens     REAL NOT NULL,
+		updated_at INTEGER NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS contacted (
+		jid           TEXT PRIMARY KEY,
+		first_sent_at INTEGER NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS broadcast_lists (
+		id         INTEGER PRIMARY KEY AUTOINCREMENT,
+		name       TEXT NOT NULL UNIQUE,
+		created_at INTEGER NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS broadcast_members (
+		list_id    INTEGER NOT NULL REFERENCES broadcast_lists(id) ON DELETE CASCADE,
+		recipient  TEXT NOT NULL,
+		added_at   INTEGER NOT NULL,
+		status     TEXT NOT NULL DEFAULT '',
+		message_id TEXT NOT NULL DEFAULT '',
+		error      TEXT NOT NULL DEFAULT '',
+		sent_at    INTEGER,
+		PRIMARY KEY (list_id, recipient)
+	)`,
+	`CREATE TABLE IF NOT EXISTS status_posts (
+		id         INTEGER PRIMARY KEY AUTOINCREMENT,
+		message_id TEXT NOT NULL,
+		kind       TEXT NOT NULL,
+		text       TEXT NOT NULL DEFAULT '',
+		audience   TEXT NOT NULL DEFAULT '',
+		posted_at  INTEGER NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS group_photos (
+		group_jid  TEXT PRIMARY KEY,
+		photo_hash TEXT NOT NULL,
+		picture_id TEXT NOT NULL,
+		set_at     INTEGER NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS cached_groups (
+		jid        TEXT PRIMARY KEY,
+		info       TEXT NOT NULL,
+		fetched_at INTEGER NOT NULL
+	)`,
+	// The fetch time is kept apart from the groups, so an account without groups is cached too
+	`CREATE TABLE IF NOT EXISTS cached_groups_fetch (
+		id         INTEGER PRIMARY KEY CHECK (id = 1),
+		fetched_at INTEGER NOT NULL
+	)`,
+}
+
+// addedColumns are columns added to existing tables after they were first created.
+// They are added when missing, as CREATE TABLE IF NOT EXISTS leaves existing tables alone.
+var addedColumns = []struct {
+	table, column, definition string
+}{
+	{"scheduled_messages", "claimed_at", "INTEGER"},
+	{"cron_jobs", "disabled_at", "INTEGER"},
+}
+
+// DB is the wavy database, used for data that is not part of the WhatsApp session
+type DB struct {
+	db *sql.DB
+}
+
+// Open opens the SQLite database at path and makes sure the schema is up to date
+func Open(path string) (*DB, error) {
+	// Immediate transactions take the write lock up front, so concurrent wavy processes queue up instead of failing
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?_foreign_keys=on&_busy_timeout=5000&_txlock=immediate", path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	for _, stmt := range migrations {
+		if _, err := db.Exec(stmt); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to migrate database: %w", err)
+		}
+	}
+	for _, added := range addedColumns {
+		if err := addColumn(db, added.table, added.column, added.definition); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to migrate database: %w", err)
+		}
+	}
+
+	return &DB{db: db}, nil
+}
+
+// addColumn adds a column to a table unless it already has it
+func addColumn(db *sql.DB, table, column, definition string) error {
+	var exists int
+	err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, table, column).Scan(&exists)
+	if err != nil || exists > 0 {
+		return err
+	}
+	_, err = db.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, table, column, definition))
+	return err
+}
+
+// Close closes the database
+func (d *DB) Close() error {
+	return d.db.Close()
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"go.mau.fi/whatsmeow"
+	//nolint:staticcheck // Using deprecated package for compatibility
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/types"
+)
+
+const (
+	// typingCharsPerSecond is the typing speed used to derive how long the indicator is shown
+	typingCharsPerSecond = 8
+	minTypingDuration    = time.Second
+	maxTypingDuration    = 10 * time.Second
+	// maxRecordingDuration caps the recording indicator for long voice notes
+	maxRecordingDuration = 30 * time.Second
+)
+
+// typingIndicator returns how long a person would take to write or record the message,
+// and whether they would be typing or recording
+func typingIndicator(message *waProto.Message) (time.Duration, types.ChatPresenceMedia) {
+	if inner := message.GetViewOnceMessageV2().GetMessage(); inner != nil {
+		return typingIndicator(inner)
+	}
+	if inner := message.GetViewOnceMessageV2Extension().GetMessage(); inner != nil {
+		return typingIndicator(inner)
+	}
+
+	if audio := message.GetAudioMessage(); audio != nil {
+		return clampDuration(time.Duration(audio.GetSeconds())*time.Second, minTypingDuration, maxRecordingDuration),
+			types.ChatPresenceMediaAudio
+	}
+
+	text := message.GetConversation()
+	if text == "" {
+		text = message.GetExtendedTextMessage().GetText()
+	}
+	if text == "" {
+		text = message.GetLocationMessage().GetComment()
+	}
+
+	typing := time.Duration(utf8.RuneCountInString(text)) * time.Second / typingCharsPerSecond
+	return clampDuration(typing, minTypingDuration, maxTypingDuration), types.ChatPresenceMediaText
+}
+
+// clampDuration limits d to the range [lower, upper]
+func clampDuration(d, lower, upper time.Duration) time.Duration {
+	if d < lower {
+		return lower
+	}
+	if d > upper {
+		return upper
+	}
+	return d
+}
+
+// simulateTyping shows the typing or recording indicator in the chat for as long as a person would need
+// to write the message, then pauses it. Presence is best effort, so failures are only reported.
+func simulateTyping(client *whatsmeow.Client, recipient types.JID, message *waProto.Message) {
+	duration, media := typingIndicator(message)
+
+	if err := client.SendChatPresence(recipient, types.ChatPresenceComposing, media); err != nil {
+		fmt.Printf("Warning: could not send typing indicator: %v\n", err)
+		return
+	}
+
+	if debug {
+		fmt.Printf("Typing for %s...\n", duration)
+	}
+	time.Sleep(duration)
+
+	if err := client.SendChatPresence(recipient, types.ChatPresencePaused, media); err != nil {
+		fmt.Printf("Warning: could not stop typing indicator: %v\n", err)
+	}
+}
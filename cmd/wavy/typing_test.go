@@ -0,0 +1,69 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	//nolint:staticcheck // Using deprecated package for compatibility
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestTypingIndicator(t *testing.T) {
+	tests := []struct {
+		name      string
+		message   *waProto.Message
+		wantTime  time.Duration
+		wantMedia types.ChatPresenceMedia
+	}{
+		{
+			name:      "Short text",
+			message:   &waProto.Message{Conversation: proto.String("Hi")},
+			wantTime:  time.Second,
+			wantMedia: types.ChatPresenceMediaText,
+		},
+		{
+			name:      "Text proportional to length",
+			message:   &waProto.Message{Conversation: proto.String(strings.Repeat("a", 40))},
+			wantTime:  5 * time.Second,
+			wantMedia: types.ChatPresenceMediaText,
+		},
+		{
+			name:      "Long text is capped",
+			message:   &waProto.Message{ExtendedTextMessage: &waProto.ExtendedTextMessage{Text: proto.String(strings.Repeat("a", 1000))}},
+			wantTime:  maxTypingDuration,
+			wantMedia: types.ChatPresenceMediaText,
+		},
+		{
+			name:      "Voice note records for its length",
+			message:   &waProto.Message{AudioMessage: &waProto.AudioMessage{Seconds: proto.Uint32(12)}},
+			wantTime:  12 * time.Second,
+			wantMedia: types.ChatPresenceMediaAudio,
+		},
+		{
+			name: "View-once voice note",
+			message: &waProto.Message{ViewOnceMessageV2Extension: &waProto.FutureProofMessage{
+				Message: &waProto.Message{AudioMessage: &waProto.AudioMessage{Seconds: proto.Uint32(300)}},
+			}},
+			wantTime:  maxRecordingDuration,
+			wantMedia: types.ChatPresenceMediaAudio,
+		},
+		{
+			name:      "Sticker",
+			message:   &waProto.Message{StickerMessage: &waProto.StickerMessage{}},
+			wantTime:  minTypingDuration,
+			wantMedia: types.ChatPresenceMediaText,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotTime, gotMedia := typingIndicator(tt.message)
+			if gotTime != tt.wantTime || gotMedia != tt.wantMedia {
+				t.Errorf("typingIndicator() = %v, %q, want %v, %q", gotTime, gotMedia, tt.wantTime, tt.wantMedia)
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+// Package vcard parses, validates and generates the vCard contact cards used in WhatsApp contact messages.
+package vcard
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Card is a single vCard
+type Card struct {
+	// Version is the vCard version, such as 3.0
+	Version string
+	// FullName is the formatted name (FN), or a name built from N if FN is missing
+	FullName string
+	// Phones contains the values of all TEL properties
+	Phones []string
+	// Raw is the card text from BEGIN:VCARD to END:VCARD
+	Raw string
+}
+
+// property is a single unfolded content line
+type property struct {
+	Name   string
+	Params string
+	Value  string
+}
+
+// supportedVersions are the vCard versions WhatsApp accepts
+var supportedVersions = map[string]bool{"2.1": true, "3.0": true, "4.0": true}
+
+// Parse parses all cards in data. It returns an error if the data contains no cards
+// or if any card is malformed.
+func Parse(data []byte) ([]Card, error) {
+	var (
+		cards   []Card
+		current []string
+		inCard  bool
+	)
+
+	for i, line := range unfold(string(data)) {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		prop, err := parseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+
+		switch {
+		case prop.Name == "BEGIN" && strings.EqualFold(prop.Value, "VCARD"):
+			if inCard {
+				return nil, fmt.Errorf("line %d: nested BEGIN:VCARD", i+1)
+			}
+			inCard = true
+			current = []string{line}
+		case prop.Name == "END" && strings.EqualFold(prop.Value, "VCARD"):
+			if !inCard {
+				return nil, fmt.Errorf("line %d: END:VCARD without BEGIN:VCARD", i+1)
+			}
+			current = append(current, line)
+			card, err := newCard(current)
+			if err != nil {
+				return nil, fmt.Errorf("card %d: %w", len(cards)+1, err)
+			}
+			cards = append(cards, card)
+			inCard = false
+		case inCard:
+			current = append(current, line)
+		default:
+			return nil, fmt.Errorf("line %d: content outside of BEGIN:VCARD and END:VCARD", i+1)
+		}
+	}
+
+	if inCard {
+		return nil, errors.New("missing END:VCARD")
+	}
+	if len(cards) == 0 {
+		return nil, errors.New("no vCard found")
+	}
+	return cards, nil
+}
+
+// New generates a vCard 3.0 for a single phone number. The phone number is also
+// added as WhatsApp ID, so the card links to the contact's chat.
+func New(name, phone string) (Card, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return Card{}, errors.New("contact name cannot be empty")
+	}
+
+	digits := phoneDigits(phone)
+	if len(digits) < 5 {
+		return Card{}, fmt.Errorf("invalid contact phone number %q", phone)
+	}
+
+	lines := []string{
+		"BEGIN:VCARD",
+		"VERSION:3.0",
+		"N:;" + escape(name) + ";;;",
+		"FN:" + escape(name),
+		fmt.Sprintf("TEL;type=CELL;type=VOICE;waid=%s:+%s", digits, digits),
+		"END:VCARD",
+	}
+	return newCard(lines)
+}
+
+// Validate checks that the card has a supported version and a name
+func (c Card) Validate() error {
+	if c.Version == "" {
+		return errors.New("missing VERSION")
+	}
+	if !supportedVersions[c.Version] {
+		return fmt.Errorf("unsupported vCard version %q", c.Version)
+	}
+	if c.FullName == "" {
+		return errors.New("missing contact name (FN or N)")
+	}
+	return nil
+}
+
+// newCard builds a card from the unfolded lines between and including BEGIN and END
+func newCard(lines []string) (Card, error) {
+	card := Card{Raw: strings.Join(lines, "\n")}
+
+	var structuredName string
+	for _, line := range lines {
+		prop, err := parseLine(line)
+		if err != nil {
+			return Card{}, err
+		}
+
+		switch prop.Name {
+		case "VERSION":
+			card.Version = strings.TrimSpace(prop.Value)
+		case "FN":
+			card.FullName = unescape(strings.TrimSpace(prop.Value))
+		case "N":
+			structuredName = nameFromN(prop.Value)
+		case "TEL":
+			if phone := strings.TrimSpace(prop.Value); phone != "" {
+				card.Phones = append(card.Phones, phone)
+			}
+		}
+	}
+
+	if card.FullName == "" {
+		card.FullName = structuredName
+	}
+
+	if err := card.Validate(); err != nil {
+		return Card{}, err
+	}
+	return card, nil
+}
+
+// unfold splits the text into lines and joins folded continuation lines
+func unfold(text string) []string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// parseLine splits a content line into its name, parameters and value
+func parseLine(line string) (property, error) {
+	colon := strings.Index(line, ":")
+	if colon < 0 {
+		return property{}, fmt.Errorf("malformed line %q", line)
+	}
+
+	name, params, _ := strings.Cut(line[:colon], ";")
+
+	// Strip the optional group prefix, as in "item1.TEL"
+	if dot := strings.LastIndex(name, "."); dot >= 0 {
+		name = name[dot+1:]
+	}
+
+	name = strings.ToUpper(strings.TrimSpace(name))
+	if name == "" {
+		return property{}, fmt.Errorf("malformed line %q", line)
+	}
+	return property{Name: name, Params: params, Value: line[colon+1:]}, nil
+}
+
+// nameFromN builds a display name from the structured N property (family;given;additional;prefix;suffix)
+func nameFromN(value string) string {
+	parts := splitComponents(value)
+	order := []int{3, 1, 2, 0, 4}
+
+	var names []string
+	for _, i := range order {
+		if i < len(parts) {
+			if part := strings.TrimSpace(unescape(parts[i])); part != "" {
+				names = append(names, part)
+			}
+		}
+	}
+	return strings.Join(names, " ")
+}
+
+// splitComponents splits a structured value on semicolons that are not escaped
+func splitComponents(value string) []string {
+	var (
+		parts   []string
+		current strings.Builder
+		escaped bool
+	)
+	for _, r := range value {
+		switch {
+		case escaped:
+			current.WriteRune('\\')
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == ';':
+			parts = append(parts, current.String())
+			current.Reset()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	return append(parts, current.String())
+}
+
+// phoneDigits returns only the digits of a phone number
+func phoneDigits(phone string) string {
+	var b strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, ",", `\,`, ";", `\;`, "\n", `\n`)
+
+// escape escapes special characters in a text value
+func escape(value string) string {
+	return escaper.Replace(value)
+}
+
+var unescaper = strings.NewReplacer(`\\`, `\`, `\,`, ",", `\;`, ";", `\n`, "\n", `\N`, "\n")
+
+// unescape reverses escape
+func unescape(value string) string {
+	return unescaper.Replace(value)
+}
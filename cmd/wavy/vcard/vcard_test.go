@@ -0,0 +1,87 @@
+package vcard
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	data := "BEGIN:VCARD\r\nVERSION:3.0\r\nN:Doe;Jane;;Dr.;\r\nFN:Jane Doe\r\nitem1.TEL;type=CELL:+1 555 0100\r\n" +
+		"NOTE:On-call this week\\, call any\r\n  time\r\nEND:VCARD\r\n" +
+		"BEGIN:VCARD\nVERSION:2.1\nN:Smith;John\nTEL:+1 555 0101\nTEL:+1 555 0102\nEND:VCARD\n"
+
+	cards, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatalf("Parse() returned error: %v", err)
+	}
+	if len(cards) != 2 {
+		t.Fatalf("Expected 2 cards, got %d", len(cards))
+	}
+
+	if cards[0].FullName != "Jane Doe" || cards[0].Version != "3.0" {
+		t.Errorf("Unexpected first card: %+v", cards[0])
+	}
+	if len(cards[0].Phones) != 1 || cards[0].Phones[0] != "+1 555 0100" {
+		t.Errorf("Unexpected phones in first card: %v", cards[0].Phones)
+	}
+	if !strings.Contains(cards[0].Raw, "call any time") {
+		t.Errorf("Expected folded line to be unfolded, got %q", cards[0].Raw)
+	}
+
+	// Without FN the name is built from N
+	if cards[1].FullName != "John Smith" || len(cards[1].Phones) != 2 {
+		t.Errorf("Unexpected second card: %+v", cards[1])
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := map[string]string{
+		"Empty":               "",
+		"Missing end":         "BEGIN:VCARD\nVERSION:3.0\nFN:Jane\n",
+		"End without begin":   "END:VCARD\n",
+		"Nested":              "BEGIN:VCARD\nBEGIN:VCARD\nEND:VCARD\n",
+		"Missing version":     "BEGIN:VCARD\nFN:Jane\nEND:VCARD\n",
+		"Unsupported version": "BEGIN:VCARD\nVERSION:5.0\nFN:Jane\nEND:VCARD\n",
+		"Missing name":        "BEGIN:VCARD\nVERSION:3.0\nTEL:+15550100\nEND:VCARD\n",
+		"Malformed line":      "BEGIN:VCARD\nVERSION:3.0\nFN Jane\nEND:VCARD\n",
+		"Outside of card":     "FN:Jane\n",
+	}
+
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Parse([]byte(data)); err == nil {
+				t.Errorf("Expected error for %s", name)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	card, err := New("On-call; Ops", "+1 (555) 010-0")
+	if err != nil {
+		t.Fatalf("New() returned error: %v", err)
+	}
+
+	if card.FullName != "On-call; Ops" {
+		t.Errorf("Expected FullName 'On-call; Ops', got %q", card.FullName)
+	}
+	if !strings.Contains(card.Raw, "waid=15550100:+15550100") {
+		t.Errorf("Expected WhatsApp ID in card, got %q", card.Raw)
+	}
+
+	// The generated card must survive a round trip through the parser
+	cards, err := Parse([]byte(card.Raw))
+	if err != nil {
+		t.Fatalf("Parse() of generated card returned error: %v", err)
+	}
+	if cards[0].FullName != card.FullName {
+		t.Errorf("Round trip changed name: %q != %q", cards[0].FullName, card.FullName)
+	}
+
+	if _, err := New("", "+15550100"); err == nil {
+		t.Error("Expected error for empty name")
+	}
+	if _, err := New("Ops", "12"); err == nil {
+		t.Error("Expected error for short phone number")
+	}
+}
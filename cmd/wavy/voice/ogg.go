@@ -0,0 +1,104 @@
+package voice
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// oggPage is the part of an Ogg page header that is needed to read Opus streams
+type oggPage struct {
+	Serial   uint32
+	Granule  int64
+	Segments []byte
+	Body     []byte
+}
+
+// oggStream is the first logical stream of an Ogg file, split into packets
+type oggStream struct {
+	Packets [][]byte
+	// Granule is the granule position of the last page, the number of 48 kHz samples for Opus
+	Granule int64
+}
+
+// readOggStream reads all packets of the first logical stream in an Ogg file
+func readOggStream(data []byte) (*oggStream, error) {
+	stream := &oggStream{}
+
+	var (
+		serial  uint32
+		packet  []byte
+		started bool
+	)
+
+	for len(data) > 0 {
+		page, size, err := readOggPage(data)
+		if err != nil {
+			return nil, err
+		}
+		data = data[size:]
+
+		if !started {
+			serial = page.Serial
+			started = true
+		} else if page.Serial != serial {
+			// Only the first logical stream is used
+			continue
+		}
+
+		if page.Granule >= 0 {
+			stream.Granule = page.Granule
+		}
+
+		// A lacing value below 255 ends a packet, 255 means it continues in the next segment
+		body := page.Body
+		for _, lacing := range page.Segments {
+			packet = append(packet, body[:lacing]...)
+			body = body[lacing:]
+			if lacing < 255 {
+				stream.Packets = append(stream.Packets, packet)
+				packet = nil
+			}
+		}
+	}
+
+	if !started {
+		return nil, errors.New("not an Ogg file")
+	}
+	return stream, nil
+}
+
+// readOggPage reads the page at the start of data and returns it with its total size
+func readOggPage(data []byte) (*oggPage, int, error) {
+	const headerSize = 27
+	if len(data) < headerSize || !bytes.HasPrefix(data, []byte("OggS")) {
+		return nil, 0, errors.New("not an Ogg file")
+	}
+	if data[4] != 0 {
+		return nil, 0, fmt.Errorf("unsupported Ogg version %d", data[4])
+	}
+
+	segmentCount := int(data[26])
+	if len(data) < headerSize+segmentCount {
+		return nil, 0, errors.New("truncated Ogg page header")
+	}
+
+	segments := data[headerSize : headerSize+segmentCount]
+	bodySize := 0
+	for _, lacing := range segments {
+		bodySize += int(lacing)
+	}
+
+	size := headerSize + segmentCount + bodySize
+	if len(data) < size {
+		return nil, 0, errors.New("truncated Ogg page")
+	}
+
+	return &oggPage{
+		Granule:  int64(binary.LittleEndian.Uint64(data[6:14])),
+		Serial:   binary.LittleEndian.Uint32(data[14:18]),
+		Segments: segments,
+		Body:     data[headerSize+segmentCount : size],
+	}, size, nil
+}
@@ -0,0 +1,145 @@
+// Package voice validates Ogg/Opus recordings and computes the metadata of WhatsApp voice notes.
+package voice
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/pion/opus"
+)
+
+const (
+	// MimeType is the MIME type WhatsApp uses for voice notes
+	MimeType = "audio/ogg; codecs=opus"
+	// WaveformSamples is the number of samples in a voice note waveform
+	WaveformSamples = 64
+
+	// opusSampleRate is the rate of Ogg/Opus granule positions, regardless of the input rate
+	opusSampleRate = 48000
+	// maxWaveformValue is the highest value in a waveform
+	maxWaveformValue = 100
+)
+
+// Note describes a voice note recording
+type Note struct {
+	// Seconds is the duration of the recording, rounded up
+	Seconds uint32
+	// Waveform contains the loudness of the recording over time, from 0 to 100
+	Waveform []byte
+	// EstimatedWaveform is set when the audio could not be decoded and the waveform
+	// was estimated from packet sizes instead
+	EstimatedWaveform bool
+}
+
+// Analyze validates that data is an Ogg/Opus recording and computes its duration and waveform
+func Analyze(data []byte) (*Note, error) {
+	stream, err := readOggStream(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(stream.Packets) < 2 || !bytes.HasPrefix(stream.Packets[0], []byte("OpusHead")) {
+		return nil, errors.New("not an Opus stream")
+	}
+	head := stream.Packets[0]
+	if len(head) < 19 {
+		return nil, errors.New("invalid Opus header")
+	}
+	if !bytes.HasPrefix(stream.Packets[1], []byte("OpusTags")) {
+		return nil, errors.New("missing Opus comment header")
+	}
+
+	audio := stream.Packets[2:]
+	if len(audio) == 0 {
+		return nil, errors.New("recording contains no audio")
+	}
+
+	preSkip := int64(binary.LittleEndian.Uint16(head[10:12]))
+	samples := stream.Granule - preSkip
+	if samples <= 0 {
+		return nil, errors.New("recording has no duration")
+	}
+
+	levels, estimated := waveform(audio)
+	return &Note{
+		Seconds:           uint32(math.Ceil(float64(samples) / opusSampleRate)),
+		Waveform:          levels,
+		EstimatedWaveform: estimated,
+	}, nil
+}
+
+// waveform computes the loudness of the packets in WaveformSamples buckets.
+// The pure Go Opus decoder only supports 20 ms mono SILK frames, which covers typical voice
+// recordings. For other streams, such as the CELT or hybrid frames most encoders produce by
+// default, the packet sizes are used instead, since Opus spends more bits on louder audio.
+// estimated reports whether that fallback was used.
+func waveform(packets [][]byte) (levels []byte, estimated bool) {
+	decoded, err := decodeLevels(packets)
+	if err != nil {
+		decoded = make([]float64, len(packets))
+		for i, packet := range packets {
+			decoded[i] = float64(len(packet))
+		}
+		estimated = true
+	}
+	return bucketize(decoded, WaveformSamples), estimated
+}
+
+// decodeLevels decodes every packet and returns the RMS level of each one
+func decodeLevels(packets [][]byte) ([]float64, error) {
+	decoder := opus.NewDecoder()
+	pcm := make([]float32, 960)
+	levels := make([]float64, len(packets))
+
+	for i, packet := range packets {
+		if _, _, err := decoder.DecodeFloat32(packet, pcm); err != nil {
+			return nil, fmt.Errorf("failed to decode Opus packet: %w", err)
+		}
+
+		var sum float64
+		for _, sample := range pcm {
+			sum += float64(sample) * float64(sample)
+		}
+		levels[i] = math.Sqrt(sum / float64(len(pcm)))
+	}
+	return levels, nil
+}
+
+// bucketize averages levels into n buckets and scales them so the loudest bucket is 100
+func bucketize(levels []float64, n int) []byte {
+	buckets := make([]float64, n)
+	for i := range buckets {
+		start := i * len(levels) / n
+		end := (i + 1) * len(levels) / n
+		if end <= start {
+			// Fewer levels than buckets, so stretch them
+			end = start + 1
+		}
+		if start >= len(levels) {
+			start, end = len(levels)-1, len(levels)
+		}
+
+		var sum float64
+		for _, level := range levels[start:end] {
+			sum += level
+		}
+		buckets[i] = sum / float64(end-start)
+	}
+
+	var peak float64
+	for _, bucket := range buckets {
+		peak = math.Max(peak, bucket)
+	}
+
+	result := make([]byte, n)
+	if peak == 0 {
+		return result
+	}
+	for i, bucket := range buckets {
+		result[i] = byte(math.Round(bucket / peak * maxWaveformValue))
+	}
+	return result
+}
@@ -0,0 +1,122 @@
+package voice
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// buildOgg writes every packet on its own Ogg page. The last page carries the granule position.
+func buildOgg(packets [][]byte, granule int64) []byte {
+	var buf bytes.Buffer
+	for i, packet := range packets {
+		var segments []byte
+		remaining := len(packet)
+		for remaining >= 255 {
+			segments = append(segments, 255)
+			remaining -= 255
+		}
+		segments = append(segments, byte(remaining))
+
+		pageGranule := int64(0)
+		if i == len(packets)-1 {
+			pageGranule = granule
+		}
+
+		header := make([]byte, 27)
+		copy(header, "OggS")
+		binary.LittleEndian.PutUint64(header[6:14], uint64(pageGranule))
+		binary.LittleEndian.PutUint32(header[14:18], 1)
+		binary.LittleEndian.PutUint32(header[18:22], uint32(i))
+		header[26] = byte(len(segments))
+
+		buf.Write(header)
+		buf.Write(segments)
+		buf.Write(packet)
+	}
+	return buf.Bytes()
+}
+
+func opusHead(preSkip uint16) []byte {
+	head := make([]byte, 19)
+	copy(head, "OpusHead")
+	head[8] = 1
+	head[9] = 1
+	binary.LittleEndian.PutUint16(head[10:12], preSkip)
+	binary.LittleEndian.PutUint32(head[12:16], 48000)
+	return head
+}
+
+func TestAnalyze(t *testing.T) {
+	// CELT packets cannot be decoded, so the waveform falls back to packet sizes
+	packets := [][]byte{opusHead(312), []byte("OpusTags\x00\x00\x00\x00\x00\x00\x00\x00")}
+	for i := 0; i < 128; i++ {
+		packet := make([]byte, 10+i%300)
+		packet[0] = 0xF8
+		packets = append(packets, packet)
+	}
+
+	note, err := Analyze(buildOgg(packets, 48000*2+312+1))
+	if err != nil {
+		t.Fatalf("Analyze() returned error: %v", err)
+	}
+
+	if !note.EstimatedWaveform {
+		t.Error("Expected the waveform of CELT packets to be estimated")
+	}
+	if note.Seconds != 3 {
+		t.Errorf("Expected duration to round up to 3 seconds, got %d", note.Seconds)
+	}
+	if len(note.Waveform) != WaveformSamples {
+		t.Fatalf("Expected %d waveform samples, got %d", WaveformSamples, len(note.Waveform))
+	}
+
+	var peak byte
+	for _, sample := range note.Waveform {
+		if sample > peak {
+			peak = sample
+		}
+	}
+	if peak != 100 {
+		t.Errorf("Expected waveform to be normalized to 100, got peak %d", peak)
+	}
+	if note.Waveform[0] >= note.Waveform[WaveformSamples-1] {
+		t.Errorf("Expected growing packets to produce a rising waveform, got %v", note.Waveform)
+	}
+}
+
+func TestAnalyzeInvalid(t *testing.T) {
+	tags := []byte("OpusTags")
+	audio := []byte{0xF8, 0x01}
+
+	tests := map[string][]byte{
+		"Not Ogg":       []byte("RIFF....WAVE"),
+		"Truncated":     buildOgg([][]byte{opusHead(0), tags, audio}, 48000)[:40],
+		"Not Opus":      buildOgg([][]byte{[]byte("\x01vorbis"), tags, audio}, 48000),
+		"Missing tags":  buildOgg([][]byte{opusHead(0), audio, audio}, 48000),
+		"No audio":      buildOgg([][]byte{opusHead(0), tags}, 48000),
+		"Zero duration": buildOgg([][]byte{opusHead(312), tags, audio}, 100),
+	}
+
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Analyze(data); err == nil {
+				t.Errorf("Expected error for %s", name)
+			}
+		})
+	}
+}
+
+func TestBucketize(t *testing.T) {
+	// Fewer levels than buckets are stretched over the waveform
+	result := bucketize([]float64{1, 2}, 4)
+	expected := []byte{50, 50, 100, 100}
+	if !bytes.Equal(result, expected) {
+		t.Errorf("bucketize() = %v, want %v", result, expected)
+	}
+
+	silent := bucketize([]float64{0, 0, 0}, 4)
+	if !bytes.Equal(silent, make([]byte, 4)) {
+		t.Errorf("Expected silent waveform, got %v", silent)
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"go.mau.fi/whatsmeow"
+	//nolint:staticcheck // Using deprecated package for compatibility
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"google.golang.org/protobuf/proto"
+
+	"whatsmeow-go/cmd/wavy/voice"
+)
+
+// buildVoiceMessage builds a voice note (push-to-talk audio) message from an Ogg/Opus file.
+// The returned upload must be completed before the message is sent.
+func buildVoiceMessage(path string) (*waProto.Message, *mediaUpload, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read voice note: %w", err)
+	}
+
+	note, err := voice.Analyze(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid voice note %s (expected Ogg/Opus): %w", path, err)
+	}
+
+	if note.EstimatedWaveform {
+		fmt.Fprintf(os.Stderr, "Note: only SILK-mode Opus audio can be decoded, so the waveform of %s is estimated from packet sizes.\n", path)
+	}
+
+	audioMsg := &waProto.AudioMessage{
+		Mimetype: proto.String(voice.MimeType),
+		Seconds:  proto.Uint32(note.Seconds),
+		PTT:      proto.Bool(true),
+		Waveform: note.Waveform,
+	}
+
+	message := &waProto.Message{AudioMessage: audioMsg}
+
+	upload := &mediaUpload{
+		Data:      data,
+		MediaType: whatsmeow.MediaAudio,
+		apply:     uploadTarget(message),
+	}
+
+	return message, upload, nil
+}
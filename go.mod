@@ -5,11 +5,17 @@ go 1.23.0
 toolchain go1.24.4
 
 require (
+	github.com/HugoSmits86/nativewebp v1.2.1
 	github.com/magefile/mage v1.15.0
 	github.com/mattn/go-sqlite3 v1.14.28
+	github.com/pion/opus v0.0.0-20250902022847-c2c56b95f05c
 	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
 	github.com/spf13/cobra v1.9.1
 	go.mau.fi/whatsmeow v0.0.0-20250709212552-0b8557ee0860
+	golang.org/x/image v0.25.0
+	golang.org/x/net v0.41.0
+	google.golang.org/protobuf v1.36.6
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -26,8 +32,6 @@ require (
 	go.mau.fi/util v0.8.8 // indirect
 	golang.org/x/crypto v0.39.0 // indirect
 	golang.org/x/exp v0.0.0-20250606033433-dcc06ee1d476 // indirect
-	golang.org/x/net v0.41.0 // indirect
 	golang.org/x/sys v0.33.0 // indirect
 	golang.org/x/text v0.26.0 // indirect
-	google.golang.org/protobuf v1.36.6 // indirect
 )